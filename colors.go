@@ -5,8 +5,8 @@ import (
 	"os"
 	"reflect"
 	"strings"
-	"sync"
 
+	"github.com/chainreactors/tui/term"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/termenv"
 )
@@ -60,16 +60,8 @@ var (
 	DarkGrayBg  = lipgloss.NewStyle().Background(DarkGray)
 	PinkBg      = lipgloss.NewStyle().Background(Pink)
 )
-var (
-	darkBgOnce  sync.Once
-	darkBgValue bool
-)
-
 func HasDarkBackground() bool {
-	darkBgOnce.Do(func() {
-		darkBgValue = termenv.HasDarkBackground()
-	})
-	return darkBgValue
+	return term.HasDarkBackground()
 }
 
 var (
@@ -83,27 +75,15 @@ var (
 
 //var ClientPrompt = AdaptTermColor()
 
-// adaptTermColor - Adapt term color
-// TODO: Adapt term color by term(fork grumble ColorTableFg)
+// AdaptTermColor - Adapt term color
+// 提示符颜色取自当前主题的 Text 角色, 随背景与颜色配置降级
 func AdaptTermColor(prompt string) string {
-	var color string
-	if HasDarkBackground() {
-		color = fmt.Sprintf("\033[37m%s> \033[0m", prompt)
-	} else {
-		color = fmt.Sprintf("\033[30m%s> \033[0m", prompt)
-	}
-	return color
+	return term.CurrentPalette().Text.Wrap(prompt + "> ")
 }
 
 func AdaptSessionColor(prePrompt, sId string) string {
-	var sessionPrompt string
 	runes := []rune(sId)
-	if HasDarkBackground() {
-		sessionPrompt = fmt.Sprintf("\033[37m%s [%s]> \033[0m", prePrompt, string(runes))
-	} else {
-		sessionPrompt = fmt.Sprintf("\033[30m%s [%s]> \033[0m", prePrompt, string(runes))
-	}
-	return sessionPrompt
+	return term.CurrentPalette().Text.Wrap(fmt.Sprintf("%s [%s]> ", prePrompt, string(runes)))
 }
 
 func NewSessionColor(prePrompt, sId string) string {
@@ -0,0 +1,332 @@
+package console
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+var (
+	aliasName    = regexp.MustCompile(`^[^\s'"\\$|&;<>()=#` + "`" + `]+$`)
+	variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// definitions holds aliases and variables, either global to the console,
+// or specific to a menu (those take precedence over the global ones).
+type definitions struct {
+	Aliases   map[string]string `json:"aliases,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// definitionsFile is the format of the file aliases and variables are saved to.
+type definitionsFile struct {
+	definitions
+	Menus map[string]*definitions `json:"menus,omitempty"`
+}
+
+// definitionStore holds all console aliases and variables (global and per menu).
+type definitionStore struct {
+	mutex  sync.RWMutex
+	global *definitions
+	menus  map[string]*definitions
+	path   string // When set, definitions are saved there on each change.
+}
+
+func newDefinitionStore() *definitionStore {
+	return &definitionStore{
+		global: &definitions{},
+		menus:  make(map[string]*definitions),
+	}
+}
+
+// scope returns the definitions of a menu, or the global ones.
+func (s *definitionStore) scope(menu string, global bool) *definitions {
+	if global {
+		return s.global
+	}
+
+	defs, found := s.menus[menu]
+	if !found {
+		defs = &definitions{}
+		s.menus[menu] = defs
+	}
+
+	return defs
+}
+
+// set adds (or removes, if value is nil) an alias or variable, and saves the definitions.
+func (s *definitionStore) set(menu string, global, alias bool, name string, value *string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	defs := s.scope(menu, global)
+
+	table := &defs.Variables
+	if alias {
+		table = &defs.Aliases
+	}
+
+	if value == nil {
+		delete(*table, name)
+	} else {
+		if *table == nil {
+			*table = make(map[string]string)
+		}
+
+		(*table)[name] = *value
+	}
+
+	return s.save()
+}
+
+// list returns the aliases or variables defined in a menu or globally.
+func (s *definitionStore) list(menu string, global, alias bool) map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	defs := s.global
+	if !global {
+		defs = s.menus[menu]
+	}
+
+	list := make(map[string]string)
+	if defs == nil {
+		return list
+	}
+
+	table := defs.Variables
+	if alias {
+		table = defs.Aliases
+	}
+
+	for name, value := range table {
+		list[name] = value
+	}
+
+	return list
+}
+
+// lookup returns the value of an alias or variable in a menu (if not nil), or globally.
+func (s *definitionStore) lookup(menu *Menu, alias bool, name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	scopes := []*definitions{s.global}
+	if menu != nil {
+		scopes = []*definitions{s.menus[menu.name], s.global}
+	}
+
+	for _, defs := range scopes {
+		if defs == nil {
+			continue
+		}
+
+		table := defs.Variables
+		if alias {
+			table = defs.Aliases
+		}
+
+		if value, found := table[name]; found {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// names returns the sorted names of the aliases or variables visible in a menu.
+func (s *definitionStore) names(menu string, alias bool) []string {
+	if s == nil {
+		return nil
+	}
+
+	visible := s.list("", true, alias)
+	for name, value := range s.list(menu, false, alias) {
+		visible[name] = value
+	}
+
+	names := make([]string, 0, len(visible))
+	for name := range visible {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *definitionStore) load(path string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var file definitionsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("invalid definitions file %s: %w", path, err)
+	}
+
+	s.global = &file.definitions
+	s.menus = file.Menus
+
+	if s.menus == nil {
+		s.menus = make(map[string]*definitions)
+	}
+
+	return nil
+}
+
+// save writes the definitions to their file, if any. Must be called with the lock held.
+func (s *definitionStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := definitionsFile{definitions: *s.global, Menus: make(map[string]*definitions)}
+
+	for name, defs := range s.menus {
+		if len(defs.Aliases) > 0 || len(defs.Variables) > 0 {
+			file.Menus[name] = defs
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that the
+	// definitions are never left half-written.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// SetDefinitionsFile loads the aliases and variables saved in a file (if it
+// exists), replacing the current ones, and saves them there whenever they change.
+func (c *Console) SetDefinitionsFile(path string) error {
+	return c.definitions.load(path)
+}
+
+// SetAlias defines an alias available in all menus: when used as the command
+// word of a line (or of any command in a pipeline or sequence), the alias is
+// replaced by its value, which is then parsed like the rest of the line.
+func (c *Console) SetAlias(name, value string) error {
+	if !aliasName.MatchString(name) {
+		return fmt.Errorf("invalid alias name: %q", name)
+	}
+
+	return c.definitions.set("", true, true, name, &value)
+}
+
+// UnsetAlias removes a global alias.
+func (c *Console) UnsetAlias(name string) error {
+	return c.definitions.set("", true, true, name, nil)
+}
+
+// Aliases returns the global aliases.
+func (c *Console) Aliases() map[string]string {
+	return c.definitions.list("", true, true)
+}
+
+// SetVariable defines a variable available in all menus: `$name` and `${name}`
+// in command lines are replaced by its value, except in single quotes. Values
+// are never split into several words. Undefined variables are left untouched.
+func (c *Console) SetVariable(name, value string) error {
+	if !variableName.MatchString(name) {
+		return fmt.Errorf("invalid variable name: %q", name)
+	}
+
+	return c.definitions.set("", true, false, name, &value)
+}
+
+// UnsetVariable removes a global variable.
+func (c *Console) UnsetVariable(name string) error {
+	return c.definitions.set("", true, false, name, nil)
+}
+
+// Variables returns the global variables.
+func (c *Console) Variables() map[string]string {
+	return c.definitions.list("", true, false)
+}
+
+// SetAlias defines an alias only available in this menu,
+// which takes precedence over a global one with the same name.
+func (m *Menu) SetAlias(name, value string) error {
+	if !aliasName.MatchString(name) {
+		return fmt.Errorf("invalid alias name: %q", name)
+	}
+
+	return m.console.definitions.set(m.name, false, true, name, &value)
+}
+
+// UnsetAlias removes an alias of this menu.
+func (m *Menu) UnsetAlias(name string) error {
+	return m.console.definitions.set(m.name, false, true, name, nil)
+}
+
+// Aliases returns the aliases specific to this menu.
+func (m *Menu) Aliases() map[string]string {
+	return m.console.definitions.list(m.name, false, true)
+}
+
+// Alias returns the value of an alias in this menu, or a global one.
+func (m *Menu) Alias(name string) (string, bool) {
+	return m.console.definitions.lookup(m, true, name)
+}
+
+// SetVariable defines a variable only available in this menu,
+// which takes precedence over a global one with the same name.
+func (m *Menu) SetVariable(name, value string) error {
+	if !variableName.MatchString(name) {
+		return fmt.Errorf("invalid variable name: %q", name)
+	}
+
+	return m.console.definitions.set(m.name, false, false, name, &value)
+}
+
+// UnsetVariable removes a variable of this menu.
+func (m *Menu) UnsetVariable(name string) error {
+	return m.console.definitions.set(m.name, false, false, name, nil)
+}
+
+// Variables returns the variables specific to this menu.
+func (m *Menu) Variables() map[string]string {
+	return m.console.definitions.list(m.name, false, false)
+}
+
+// Variable returns the value of a variable in this menu, or a global one.
+func (m *Menu) Variable(name string) (string, bool) {
+	return m.console.definitions.lookup(m, false, name)
+}
@@ -0,0 +1,415 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// AuditRedactedFlag is a cobra flag annotation marking a flag as sensitive:
+// its values are redacted from the audit log, like those of the flags passed
+// to Console.SetAuditRedactedFlags().
+const AuditRedactedFlag = "console_audit_redacted"
+
+// redacted replaces sensitive values in audit entries.
+const redacted = "[REDACTED]"
+
+// AuditEntry is the record of a console command execution. A command line with
+// several commands (pipes, sequences) produces one entry per console command.
+type AuditEntry struct {
+	Time      time.Time `json:"time"`                // When the command started.
+	Menu      string    `json:"menu"`                // Menu in which the command ran.
+	Line      string    `json:"line"`                // Command line, as typed.
+	Args      []string  `json:"args"`                // Arguments of the command, once resolved.
+	Duration  string    `json:"duration"`            // How long the command ran.
+	Error     string    `json:"error,omitempty"`     // Error returned by the command.
+	Interrupt string    `json:"interrupt,omitempty"` // Signal that interrupted the command, or "detached".
+	Job       int       `json:"job,omitempty"`       // Background job running the command.
+	PrevHash  string    `json:"prev_hash,omitempty"` // Hash of the previous entry, with hash chaining.
+	Hash      string    `json:"hash,omitempty"`      // Hash of this entry, with hash chaining.
+}
+
+// AuditSink receives the entries of executed commands.
+type AuditSink interface {
+	Record(entry AuditEntry) error
+}
+
+// SetAuditSink sets where executed commands are recorded, or stops recording if nil.
+func (c *Console) SetAuditSink(sink AuditSink) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.auditSink = sink
+}
+
+// SetAuditRedactedFlags sets the names of the flags (long names, without dashes)
+// whose values are replaced by "[REDACTED]" in audit entries, in the arguments
+// and in the command line. Flags annotated with AuditRedactedFlag always are.
+func (c *Console) SetAuditRedactedFlags(names ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.auditRedacted = append([]string(nil), names...)
+}
+
+// auditLineKey is the context key of the command line being executed.
+type auditLineKey struct{}
+
+// withAuditLine stores the line typed by the user in the context of its commands.
+func withAuditLine(ctx context.Context, line string) context.Context {
+	return context.WithValue(ctx, auditLineKey{}, line)
+}
+
+// auditCommand returns a function recording the result of
+// a command that starts now, or nil if there is no sink.
+func (c *Console) auditCommand(ctx context.Context, menu *Menu, args []string) func(err error, interrupt string, job int) {
+	c.mutex.RLock()
+	sink, names := c.auditSink, c.auditRedacted
+	c.mutex.RUnlock()
+
+	if sink == nil {
+		return nil
+	}
+
+	line, found := ctx.Value(auditLineKey{}).(string)
+	if !found {
+		line = shellquote.Join(args...)
+	}
+
+	entry := AuditEntry{
+		Time: time.Now(),
+		Menu: menu.Name(),
+		Line: line,
+		Args: args,
+	}
+
+	if target, _, err := menu.Command.Find(args); err == nil && target != nil {
+		entry.Args = redactArgs(target, args, names)
+	}
+
+	// All the commands of the line are redacted, not only this one: if the
+	// line cannot be parsed again, only the (redacted) command is recorded.
+	if redactedLine, ok := c.redactLine(menu, line, names); ok {
+		entry.Line = redactedLine
+	} else {
+		entry.Line = shellquote.Join(entry.Args...)
+	}
+
+	return func(err error, interrupt string, job int) {
+		entry.Duration = time.Since(entry.Time).String()
+		entry.Interrupt = interrupt
+		entry.Job = job
+
+		if err != nil {
+			entry.Error = err.Error()
+		}
+
+		if err := sink.Record(entry); err != nil {
+			fmt.Fprintf(c.terminal.Err, "Audit error: %s\n", err)
+		}
+	}
+}
+
+// redactArgs returns the args with the values of sensitive flags of the target command redacted.
+func redactArgs(target *cobra.Command, args, names []string) (redactedArgs []string) {
+	sensitive := func(flag *pflag.Flag) bool {
+		if flag == nil {
+			return false
+		}
+
+		if _, found := flag.Annotations[AuditRedactedFlag]; found {
+			return true
+		}
+
+		for _, name := range names {
+			if flag.Name == name {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	redactedArgs = append([]string(nil), args...)
+
+	for i := 0; i < len(redactedArgs); i++ {
+		arg := redactedArgs[i]
+		if arg == "--" {
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+
+		var (
+			flag   *pflag.Flag
+			prefix string
+			inline bool
+		)
+
+		if strings.HasPrefix(arg, "--") {
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			flag, prefix, inline = target.Flag(name), "--"+name+"=", hasValue
+		} else {
+			// Short flags may be grouped, the first one taking a value ending the group.
+			for j := 1; j < len(arg); j++ {
+				short := target.Flags().ShorthandLookup(arg[j : j+1])
+				if short == nil {
+					short = target.InheritedFlags().ShorthandLookup(arg[j : j+1])
+				}
+
+				if short == nil || short.NoOptDefVal == "" {
+					flag, prefix, inline = short, arg[:j+1], j+1 < len(arg)
+					if strings.HasPrefix(arg[j+1:], "=") {
+						prefix = arg[:j+2]
+					}
+
+					break
+				}
+			}
+		}
+
+		if !sensitive(flag) {
+			continue
+		}
+
+		if inline {
+			redactedArgs[i] = prefix + redacted
+
+			continue
+		}
+
+		// Boolean flags have no separate value.
+		if flag.NoOptDefVal == "" && i+1 < len(redactedArgs) {
+			redactedArgs[i+1] = redacted
+			i++
+		}
+	}
+
+	return redactedArgs
+}
+
+// redactLine redacts the values of sensitive flags in all the commands of the
+// line as typed. Like for arguments, the words holding them are replaced, and
+// the rest of the line is kept as is. Returns false if the line is invalid.
+func (c *Console) redactLine(menu *Menu, line string, names []string) (string, bool) {
+	file, err := syntax.NewParser(syntax.KeepComments(false)).Parse(strings.NewReader(line), "")
+	if err != nil {
+		return "", false
+	}
+
+	type replacement struct {
+		start, end int
+		value      string
+	}
+
+	var replacements []replacement
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		words := make([]string, len(call.Args))
+		for i, word := range call.Args {
+			words[i] = line[word.Pos().Offset():word.End().Offset()]
+			if split, err := c.splitLine(words[i]); err == nil && len(split) == 1 {
+				words[i] = split[0]
+			}
+		}
+
+		// Aliases are resolved to find the command, but only the words typed are redacted.
+		args := words
+		if expanded, err := c.splitLine(c.expandAliases(menu, words[0], nil)); err == nil && len(expanded) > 0 {
+			args = append(expanded, words[1:]...)
+		}
+
+		target, _, err := menu.Command.Find(args)
+		if err != nil || target == nil {
+			return true
+		}
+
+		offset := len(args) - len(words)
+
+		for i, arg := range redactArgs(target, args, names) {
+			if i < offset+1 || arg == args[i] {
+				continue
+			}
+
+			word := call.Args[i-offset]
+			replacements = append(replacements, replacement{
+				start: int(word.Pos().Offset()),
+				end:   int(word.End().Offset()),
+				value: arg,
+			})
+		}
+
+		return true
+	})
+
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+
+	for _, r := range replacements {
+		line = line[:r.start] + r.value + line[r.end:]
+	}
+
+	return line, true
+}
+
+// AuditLog is an audit sink writing entries as JSON lines, optionally chained:
+// each entry then has the hash of the previous one, and its own hash covering
+// both, so that modified, removed or reordered entries can be detected.
+type AuditLog struct {
+	mutex    sync.Mutex
+	writer   io.Writer
+	chain    bool
+	lastHash string
+}
+
+// NewAuditLog returns an audit log writing to w, with hash chaining if chain is true.
+func NewAuditLog(w io.Writer, chain bool) *AuditLog {
+	return &AuditLog{writer: w, chain: chain}
+}
+
+// OpenAuditLog opens (or creates) an audit log file, appending to it. With
+// hash chaining, the chain goes on from the last entry of the file.
+func OpenAuditLog(path string, chain bool) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	log := NewAuditLog(file, chain)
+
+	if chain {
+		scanner := newLineScanner(file)
+		for scanner.Scan() {
+			var entry AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+				log.lastHash = entry.Hash
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return log, nil
+}
+
+// Record writes an entry to the log.
+func (l *AuditLog) Record(entry AuditEntry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry.PrevHash, entry.Hash = "", ""
+
+	if l.chain {
+		entry.PrevHash = l.lastHash
+
+		hash, err := auditHash(entry)
+		if err != nil {
+			return err
+		}
+
+		entry.Hash = hash
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.writer.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	l.lastHash = entry.Hash
+
+	return nil
+}
+
+// Close closes the underlying writer, if it can be.
+func (l *AuditLog) Close() error {
+	if closer, ok := l.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// VerifyAuditLog checks the hash chain of an audit log, returning an error
+// designating the first entry (by line number) that does not match.
+func VerifyAuditLog(r io.Reader) error {
+	scanner := newLineScanner(r)
+	lastHash := ""
+
+	for number := 1; scanner.Scan(); number++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("line %d: %w", number, err)
+		}
+
+		if entry.PrevHash != lastHash {
+			return fmt.Errorf("line %d: broken chain: previous entry hash does not match", number)
+		}
+
+		hash, err := auditHash(entry)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", number, err)
+		}
+
+		if hash != entry.Hash {
+			return fmt.Errorf("line %d: entry hash does not match its content", number)
+		}
+
+		lastHash = entry.Hash
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if lastHash == "" {
+		return errors.New("no chained entries")
+	}
+
+	return nil
+}
+
+// auditHash hashes the JSON encoding of an entry, without its hash.
+func auditHash(entry AuditEntry) (string, error) {
+	entry.Hash = ""
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,150 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type auditRecorder struct {
+	entries []AuditEntry
+}
+
+func (r *auditRecorder) Record(entry AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestAuditCommands(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+		login := &cobra.Command{
+			Use: "login",
+			Run: func(*cobra.Command, []string) {},
+		}
+		login.Flags().StringP("password", "p", "", "")
+		login.Flags().String("token", "", "")
+		login.Flags().BoolP("verbose", "v", false, "")
+		login.Flags().String("user", "", "")
+		login.Flags().SetAnnotation("token", AuditRedactedFlag, []string{"true"})
+		root.AddCommand(login)
+		fail := &cobra.Command{
+			Use:  "fail",
+			Args: cobra.ArbitraryArgs,
+			RunE: func(*cobra.Command, []string) error { return errors.New("failed") },
+		}
+		fail.Flags().StringP("password", "p", "", "")
+		root.AddCommand(fail)
+		return root
+	})
+
+	recorder := &auditRecorder{}
+	console.SetAuditSink(recorder)
+	console.SetAuditRedactedFlags("password")
+
+	line := "login --user admin -vp hunter2 --token=s3cr3t; login -p 'a b'; fail -p=a 'in a'"
+	if err := menu.RunCommandLine(context.Background(), line); err == nil {
+		t.Fatal("expected the error of the failing command")
+	}
+
+	if len(recorder.entries) != 3 {
+		t.Fatalf("got %d audit entries, want 3", len(recorder.entries))
+	}
+
+	login, quoted, fail := recorder.entries[0], recorder.entries[1], recorder.entries[2]
+
+	wantArgs := []string{"login", "--user", "admin", "-vp", redacted, "--token=" + redacted}
+	if !reflect.DeepEqual(login.Args, wantArgs) {
+		t.Errorf("login args = %q, want %q", login.Args, wantArgs)
+	}
+	if login.Menu != menu.Name() || login.Error != "" || login.Duration == "" {
+		t.Errorf("login entry = %+v", login)
+	}
+	if wantArgs := []string{"login", "-p", redacted}; !reflect.DeepEqual(quoted.Args, wantArgs) {
+		t.Errorf("quoted login args = %q, want %q", quoted.Args, wantArgs)
+	}
+
+	wantArgs = []string{"fail", "-p=" + redacted, "in a"}
+	if fail.Error != "failed" || !reflect.DeepEqual(fail.Args, wantArgs) {
+		t.Errorf("fail entry = %+v, want args %q", fail, wantArgs)
+	}
+
+	// Each entry has the whole line, with the secrets of all its commands redacted.
+	wantLine := "login --user admin -vp [REDACTED] --token=[REDACTED]; login -p [REDACTED]; fail -p=[REDACTED] 'in a'"
+	for _, entry := range recorder.entries {
+		if entry.Line != wantLine {
+			t.Errorf("%s line = %q, want %q", entry.Args[0], entry.Line, wantLine)
+		}
+	}
+
+	console.SetAuditSink(nil)
+	menu.RunCommandLine(context.Background(), "fail")
+
+	if len(recorder.entries) != 3 {
+		t.Fatal("commands recorded without an audit sink")
+	}
+}
+
+func TestAuditLogChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	log, err := OpenAuditLog(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Record(AuditEntry{Menu: "main", Line: "one", Args: []string{"one"}})
+	log.Record(AuditEntry{Menu: "main", Line: "two", Args: []string{"two"}})
+	log.Close()
+
+	// The chain goes on in a reopened log.
+	log, err = OpenAuditLog(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Record(AuditEntry{Menu: "main", Line: "three", Args: []string{"three"}})
+	log.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyAuditLog(bytes.NewReader(data)); err != nil {
+		t.Fatalf("VerifyAuditLog() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	entry.Line = "changed"
+	changed, _ := json.Marshal(entry)
+	lines[1] = string(changed)
+
+	err = VerifyAuditLog(strings.NewReader(strings.Join(lines, "\n")))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("VerifyAuditLog() of a modified log = %v, want a line 2 error", err)
+	}
+
+	err = VerifyAuditLog(strings.NewReader(lines[0] + "\n" + lines[2]))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("VerifyAuditLog() of a truncated log = %v, want a line 2 error", err)
+	}
+}
@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+
+	"github.com/chainreactors/tui/console"
+)
+
+// Alias returns a command to define aliases (`alias ll="sessions --all"`, or
+// `alias ll sessions --all`), or to list them when called without arguments.
+// Aliases are global, unless the --menu flag is used to define them in the
+// current menu only.
+func Alias(c *console.Console) *cobra.Command {
+	var local bool
+
+	aliasCmd := &cobra.Command{
+		Use:     "alias [name[=value] [value...]]",
+		Short:   "Define or list command aliases",
+		GroupID: "core",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			menu := c.ActiveMenu()
+
+			if len(args) == 0 {
+				printDefinitions(cmd.OutOrStdout(), "alias", c.Aliases(), menu.Aliases())
+				return nil
+			}
+
+			name, value, hasValue := definitionArgs(args)
+			if !hasValue {
+				value, found := menu.Alias(name)
+				if !found {
+					return fmt.Errorf("alias not found: %s", name)
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "alias %s=%s\n", name, shellquote.Join(value))
+
+				return nil
+			}
+
+			if local {
+				return menu.SetAlias(name, value)
+			}
+
+			return c.SetAlias(name, value)
+		},
+	}
+
+	aliasCmd.Flags().SetInterspersed(false)
+	aliasCmd.Flags().BoolVarP(&local, "menu", "m", false, "define the alias in the current menu only")
+
+	return aliasCmd
+}
+
+// Unalias returns a command to remove aliases, global or of the current menu.
+func Unalias(c *console.Console) *cobra.Command {
+	var local bool
+
+	unaliasCmd := &cobra.Command{
+		Use:     "unalias name...",
+		Short:   "Remove command aliases",
+		GroupID: "core",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return unsetDefinitions(args, local, c.UnsetAlias, c.ActiveMenu().UnsetAlias)
+		},
+		ValidArgsFunction: func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return definitionNames(c.Aliases(), c.ActiveMenu().Aliases()), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	unaliasCmd.Flags().BoolVarP(&local, "menu", "m", false, "remove the alias of the current menu")
+
+	return unaliasCmd
+}
+
+// Set returns a command to define variables (`set target 10.0.0.5`, or
+// `set target=10.0.0.5`), used as `$target` or `${target}` in command lines,
+// or to list them when called without arguments. Variables are global, unless
+// the --menu flag is used to define them in the current menu only.
+func Set(c *console.Console) *cobra.Command {
+	var local bool
+
+	setCmd := &cobra.Command{
+		Use:     "set [name[=value] [value...]]",
+		Short:   "Define or list variables",
+		GroupID: "core",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			menu := c.ActiveMenu()
+
+			if len(args) == 0 {
+				printDefinitions(cmd.OutOrStdout(), "set", c.Variables(), menu.Variables())
+				return nil
+			}
+
+			name, value, hasValue := definitionArgs(args)
+			if !hasValue {
+				value, found := menu.Variable(name)
+				if !found {
+					return fmt.Errorf("variable not found: %s", name)
+				}
+
+				fmt.Fprintln(cmd.OutOrStdout(), value)
+
+				return nil
+			}
+
+			if local {
+				return menu.SetVariable(name, value)
+			}
+
+			return c.SetVariable(name, value)
+		},
+	}
+
+	setCmd.Flags().SetInterspersed(false)
+	setCmd.Flags().BoolVarP(&local, "menu", "m", false, "define the variable in the current menu only")
+
+	return setCmd
+}
+
+// Unset returns a command to remove variables, global or of the current menu.
+func Unset(c *console.Console) *cobra.Command {
+	var local bool
+
+	unsetCmd := &cobra.Command{
+		Use:     "unset name...",
+		Short:   "Remove variables",
+		GroupID: "core",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return unsetDefinitions(args, local, c.UnsetVariable, c.ActiveMenu().UnsetVariable)
+		},
+		ValidArgsFunction: func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return definitionNames(c.Variables(), c.ActiveMenu().Variables()), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	unsetCmd.Flags().BoolVarP(&local, "menu", "m", false, "remove the variable of the current menu")
+
+	return unsetCmd
+}
+
+// definitionArgs parses `name=value`, `name value...` or `name`.
+func definitionArgs(args []string) (name, value string, hasValue bool) {
+	if name, value, found := strings.Cut(args[0], "="); found {
+		return name, strings.Join(append([]string{value}, args[1:]...), " "), true
+	}
+
+	if len(args) == 1 {
+		return args[0], "", false
+	}
+
+	return args[0], strings.Join(args[1:], " "), true
+}
+
+func unsetDefinitions(names []string, local bool, global, menu func(string) error) error {
+	unset := global
+	if local {
+		unset = menu
+	}
+
+	for _, name := range names {
+		if err := unset(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printDefinitions prints global definitions, then those of the menu, which override them.
+func printDefinitions(out io.Writer, command string, global, menu map[string]string) {
+	for _, defs := range []struct {
+		flag  string
+		table map[string]string
+	}{{"", global}, {" -m", menu}} {
+		names := make([]string, 0, len(defs.table))
+		for name := range defs.table {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(out, "%s%s %s=%s\n", command, defs.flag, name, shellquote.Join(defs.table[name]))
+		}
+	}
+}
+
+func definitionNames(tables ...map[string]string) []string {
+	var names []string
+
+	for _, table := range tables {
+		for name := range table {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chainreactors/tui/console"
+)
+
+// Jobs returns a command to list the console background jobs (started with a
+// trailing `&`, or detached with Ctrl-Z), with `fg` and `cancel` subcommands to
+// bring a job to the foreground or to cancel it. Jobs are designated by their
+// number, optionally prefixed with `%`: the most recent job is used by default.
+func Jobs(c *console.Console) *cobra.Command {
+	jobsCmd := &cobra.Command{
+		Use:     "jobs",
+		Short:   "List background jobs",
+		GroupID: "core",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			jobs := c.Jobs()
+			if len(jobs) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No jobs")
+				return
+			}
+
+			table := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(table, "ID\tMenu\tStatus\tElapsed\tCommand")
+
+			for _, job := range jobs {
+				menu := job.Menu
+				if menu == "" {
+					menu = "-"
+				}
+
+				fmt.Fprintf(table, "%%%d\t%s\t%s\t%s\t%s\n",
+					job.ID, menu, job.Status(), job.Elapsed().Round(time.Second), job.Line)
+			}
+
+			table.Flush()
+		},
+	}
+
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:               "fg [job]",
+		Short:             "Bring a job to the foreground and wait for it",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeJobs(c),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := jobID(c, args)
+			if err != nil {
+				return err
+			}
+
+			return c.ForegroundJob(cmd.Context(), id)
+		},
+	})
+
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:               "cancel [job]",
+		Short:             "Cancel a running job",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeJobs(c),
+		RunE: func(_ *cobra.Command, args []string) error {
+			id, err := jobID(c, args)
+			if err != nil {
+				return err
+			}
+
+			return c.CancelJob(id)
+		},
+	})
+
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:   "clean",
+		Short: "Remove finished jobs from the list",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			c.CleanJobs()
+		},
+	})
+
+	return jobsCmd
+}
+
+// jobID parses a job number, or returns the most recent job.
+func jobID(c *console.Console, args []string) (int, error) {
+	if len(args) == 0 {
+		jobs := c.Jobs()
+		if len(jobs) == 0 {
+			return 0, errors.New("no current job")
+		}
+
+		return jobs[len(jobs)-1].ID, nil
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "%"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid job: %s", args[0])
+	}
+
+	return id, nil
+}
+
+func completeJobs(c *console.Console) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var comps []string
+		for _, job := range c.Jobs() {
+			comps = append(comps, fmt.Sprintf("%d\t%s (%s)", job.ID, job.Line, job.Status()))
+		}
+
+		return comps, cobra.ShellCompDirectiveNoFileComp
+	}
+}
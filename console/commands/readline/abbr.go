@@ -0,0 +1,120 @@
+package readline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+
+	"github.com/chainreactors/tui/readline"
+)
+
+// Abbr returns a command named `abbr`, for defining, listing and erasing abbreviations
+// expanded in the input line when followed by a space, or reading them from files.
+func Abbr(shell *readline.Shell) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "abbr [word[=expansion] [expansion...]]",
+		Short: "Define, list or erase readline abbreviations",
+		Long: `Define, list or erase readline abbreviations.
+
+Abbreviations are words expanded in the input line when followed by a space,
+or when they end the line when it is accepted, so that the command actually run
+can be read and edited. Only words in command position (first word of the line,
+or after a pipe, a semicolon or an ampersand) are expanded, and undoing after an
+expansion reverts it. The abbreviation-cursor option (%| by default) marks the
+position of the cursor in expansions.
+
+Without arguments, abbreviations are listed in a form that can be reused as input.
+Files of abbreviations use the inputrc syntax, where they are written like macros.`,
+		Example: `    abbr gco git checkout         # "gco " expands to "git checkout "
+    abbr gcm='git commit -m "%|"' # The cursor is placed between the quotes.
+    abbr -e gco gcm               # Erase abbreviations.
+    abbr -f ~/.abbreviations      # Read lines like "gco": "git checkout"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file, _ := cmd.Flags().GetString("file"); file != "" {
+				return shell.Abbreviations.ReadFile(file)
+			}
+
+			if erase, _ := cmd.Flags().GetBool("erase"); erase {
+				for _, word := range args {
+					if !shell.Abbreviations.Delete(word) {
+						return fmt.Errorf("abbreviation not found: %s", word)
+					}
+				}
+
+				return nil
+			}
+
+			if len(args) == 0 {
+				printAbbreviations(cmd, shell.Abbreviations.List())
+				return nil
+			}
+
+			word, expansion, hasValue := abbreviationArgs(args)
+			if hasValue {
+				return shell.Abbreviations.Set(word, expansion)
+			}
+
+			expansion, found := shell.Abbreviations.Get(word)
+			if !found {
+				return fmt.Errorf("abbreviation not found: %s", word)
+			}
+
+			printAbbreviations(cmd, map[string]string{word: expansion})
+
+			return nil
+		},
+	}
+
+	// Flags
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().BoolP("erase", "e", false, "Erase the abbreviations")
+	cmd.Flags().StringP("file", "f", "", "Read abbreviations from FILENAME")
+
+	// Completions
+	comps := carapace.Gen(cmd)
+
+	comps.FlagCompletion(carapace.ActionMap{
+		"file": carapace.ActionFiles(),
+	})
+
+	comps.PositionalCompletion(carapace.ActionCallback(func(_ carapace.Context) carapace.Action {
+		words := make([]string, 0)
+		for word, expansion := range shell.Abbreviations.List() {
+			words = append(words, word, expansion)
+		}
+
+		return carapace.ActionValuesDescribed(words...).Tag("abbreviations").Usage("abbreviation")
+	}))
+
+	return cmd
+}
+
+// abbreviationArgs parses `word=expansion`, `word expansion...` or `word`.
+func abbreviationArgs(args []string) (word, expansion string, hasValue bool) {
+	if word, expansion, found := strings.Cut(args[0], "="); found {
+		return word, strings.Join(append([]string{expansion}, args[1:]...), " "), true
+	}
+
+	if len(args) == 1 {
+		return args[0], "", false
+	}
+
+	return args[0], strings.Join(args[1:], " "), true
+}
+
+func printAbbreviations(cmd *cobra.Command, abbreviations map[string]string) {
+	words := make([]string, 0, len(abbreviations))
+	for word := range abbreviations {
+		words = append(words, word)
+	}
+
+	sort.Strings(words)
+
+	for _, word := range words {
+		fmt.Fprintf(cmd.OutOrStdout(), "abbr %s=%s\n", word, shellquote.Join(abbreviations[word]))
+	}
+}
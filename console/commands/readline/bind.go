@@ -25,6 +25,8 @@ Note that the keymap name is optional, and if omitted, the default keymap is use
 The default keymap is 'vi' only if 'set editing-mode vi' is found in inputrc , and
 unless the -m option is used to set a different keymap.
 Also, note that the bind [seq] [command] slightly differs from the original bash 'bind' command.
+Keys only reported by extended keyboard protocols (see the keyboard-protocol option) are
+bound with the \S- (shift) prefix or modifiers of named keys, like "\C-\S-a" or "\C-\r".
 
 Exporting binds:
 - Since all applications always look up to the same file for a given user,
@@ -35,6 +37,7 @@ Exporting binds:
     bind "\C-x\C-r": re-read-init-file          # C-x C-r to reload the inputrc file, in the default keymap.
     bind -m vi-insert "\C-l" clear-screen       # C-l to clear-screen in vi-insert mode
     bind -m menu-complete '\C-n' menu-complete  # C-n to cycle through choices in the completion keymap.
+    bind "\C-\r" accept-and-hold               # Control-Enter, with keyboard-protocol set to kitty/xterm/auto.
 
 Exporting binds:
    bind --binds-rc --lib --changed # Only changed options/binds to stdout applying to all apps using this lib
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/chainreactors/tui/console"
+)
+
+// Source returns a command running a script of console command lines, read from
+// a file or from the standard input (`-`), and printing a summary of the results.
+// The command fails if one of the script lines failed.
+func Source(c *console.Console) *cobra.Command {
+	var opts console.ScriptOptions
+
+	sourceCmd := &cobra.Command{
+		Use:     "source <file|->",
+		Short:   "Run the command lines of a script file",
+		GroupID: "core",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := c.RunScriptFile(cmd.Context(), args[0], opts)
+			if err != nil {
+				return err
+			}
+
+			if err := report.WriteSummary(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+
+			return report.Err()
+		},
+	}
+
+	sourceCmd.Flags().BoolVarP(&opts.StopOnError, "stop-on-error", "e", false, "stop at the first command line that fails")
+	sourceCmd.Flags().BoolVarP(&opts.Echo, "echo", "x", false, "print each command line before running it")
+	sourceCmd.Flags().StringVarP(&opts.Menu, "menu", "m", "", "run the commands in this menu instead of the active one")
+
+	return sourceCmd
+}
@@ -44,6 +44,11 @@ func (c *Console) complete(line []rune, pos int) (comps readline.Completions) {
 	// what the right buffer (up to the cursor)
 	args, prefixComp, prefixLine := splitArgs(line, pos, c.getEscapeMode())
 
+	// Variable names are completed by the console itself.
+	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], "$") {
+		return c.completeVariables(menu, args[len(args)-1])
+	}
+
 	// Cobra/pflag parsing state is mutable: both real command executions and
 	// carapace completion parsing will mark flags as Changed and record dash state.
 	//
@@ -100,11 +105,11 @@ func (c *Console) complete(line []rune, pos int) (comps readline.Completions) {
 
 	// Assign both completions and command/flags/args usage strings.
 	comps = readline.CompleteRaw(raw)
+
+	// The usage of commands themselves is part of the contextual hint of the line.
 	usage := completions.Usage
-	if usage == "" {
-		if target := findCompletionTarget(menu.Command, args[2:]); target != nil && target != menu.Command {
-			usage = target.UseLine()
-		}
+	if target := findCompletionTarget(menu.Command, args[2:]); target != nil && usage == target.Use {
+		usage = ""
 	}
 	comps = comps.Usage("%s", usage)
 	comps = c.justifyCommandComps(comps)
@@ -147,6 +152,25 @@ func (c *Console) complete(line []rune, pos int) (comps readline.Completions) {
 	return comps
 }
 
+// completeVariables completes the names of the variables visible
+// in the menu, in the `$name` or `${name}` form being typed.
+func (c *Console) completeVariables(menu *Menu, word string) readline.Completions {
+	braced := strings.HasPrefix(word, "${")
+	values := make([]string, 0)
+
+	for _, name := range c.definitions.names(menu.name, false) {
+		value, _ := menu.Variable(name)
+
+		if braced {
+			values = append(values, "${"+name+"}", value)
+		} else {
+			values = append(values, "$"+name, value)
+		}
+	}
+
+	return readline.CompleteValuesDescribed(values...).Tag("variables")
+}
+
 func resetFlagParsingState(root *cobra.Command, args []string) {
 	if root == nil {
 		return
@@ -2,10 +2,13 @@ package console
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sync"
 
 	"github.com/chainreactors/tui/readline"
 	rlterm "github.com/chainreactors/tui/readline/terminal"
+	"github.com/chainreactors/tui/term"
 )
 
 // Console is an integrated console application instance.
@@ -14,9 +17,10 @@ type Console struct {
 	name          string          // Used in the prompt, and for readline `.inputrc` application-specific settings.
 	shell         *readline.Shell // Provides readline functionality (inputs, completions, hints, history)
 	terminal      *rlterm.Terminal
+	input         *inputMonitor    // Interrupt keys of terminals not on the process stdin.
 	printLogo     func(c *Console) // Simple logo printer.
-	cmdHighlight  string           // Ansi code for highlighting of command in default highlighter. Green by default.
-	flagHighlight string           // Ansi code for highlighting of flag in default highlighter. Grey by default.
+	cmdHighlight  string           // Ansi code for highlighting of command in default highlighter. Theme command color when empty.
+	flagHighlight string           // Ansi code for highlighting of flag in default highlighter. Theme flag color when empty.
 	menus         map[string]*Menu // Different command trees, prompt engines, etc.
 	filters       []string         // Hide commands based on their attributes and current context.
 	escapeMode    EscapeMode       // How input lines are split into words (guarded by mutex).
@@ -24,6 +28,24 @@ type Console struct {
 	printed       bool             // Used to adjust asynchronous messages too.
 	mutex         *sync.RWMutex    // Concurrency management.
 
+	pipeFilters map[string]PipeFilter // Built-in commands usable after a pipe (guarded by mutex).
+	hostAccess  bool                  // Lines may run system commands and redirect to files (guarded by mutex).
+	outputMutex sync.Mutex
+	capture     io.Writer        // Output of the pipeline stage running, instead of the terminal.
+	definitions *definitionStore // Aliases and variables, global and per menu.
+
+	renderers     map[string]Renderer // Output formats of command results (guarded by mutex).
+	outputFlag    bool                // Add the --output flag to menu commands.
+	defaultOutput string              // Format used when the --output flag is not set.
+
+	auditSink     AuditSink // Records executed commands (guarded by mutex).
+	auditRedacted []string  // Flags whose values are redacted from audit entries.
+
+	jobsMutex  sync.Mutex
+	jobs       map[int]*Job  // Background jobs, running or not yet collected.
+	foreground *Job          // Job waited for by ForegroundJob(), if any.
+	detach     chan struct{} // DetachForeground() requests.
+
 	pasteMu      sync.Mutex
 	pasteConfig  PasteReferenceConfig
 	pasteCounter int
@@ -88,13 +110,31 @@ func NewWithTerminal(app string, t *rlterm.Terminal) *Console {
 	if t == nil {
 		t = rlterm.Local()
 	}
+
+	// Only the process terminal turns Ctrl-C and friends into OS signals:
+	// for all others, they are read from the input while commands run.
+	var input *inputMonitor
+
+	if t.In != os.Stdin {
+		input = newInputMonitor(t.In)
+		t = &rlterm.Terminal{In: input, Out: t.Out, Err: t.Err, Control: t.Control}
+	}
+
 	console := &Console{
 		name: app,
 		//shell: readline.NewShell(inputrc.WithApp(strings.ToLower(app))),
 		shell:    readline.NewShellWithTerminal(t),
 		terminal: t,
+		input:    input,
 		menus:    make(map[string]*Menu),
 		mutex:    &sync.RWMutex{},
+
+		pipeFilters: defaultPipeFilters(),
+		definitions: newDefinitionStore(),
+		renderers:   defaultRenderers(),
+		hostAccess:  true,
+		jobs:        make(map[int]*Job),
+		detach:      make(chan struct{}, 1),
 	}
 
 	// Quality of life improvements.
@@ -111,10 +151,16 @@ func NewWithTerminal(app string, t *rlterm.Terminal) *Console {
 	}
 
 	// Syntax highlighting, multiline callbacks, etc.
-	console.cmdHighlight = seqFgGreen
-	console.flagHighlight = seqBrightWigth
+	// Resolve the capabilities and the theme palette now: probing and
+	// background detection query the terminal, which must not happen
+	// once readline owns the input.
+	if input == nil {
+		term.ProbeTerminal()
+	}
+	console.colors()
 	console.shell.AcceptMultiline = console.acceptMultiline
 	console.shell.SyntaxHighlighter = console.highlightSyntax
+	console.shell.Hinter = console.hint
 
 	// Completion
 	console.shell.Completer = console.complete
@@ -161,6 +207,25 @@ func (c *Console) getEscapeMode() EscapeMode {
 	return c.escapeMode
 }
 
+// SetHostAccess sets whether command lines may run system commands after pipes
+// (`cmd | sort`) and redirect their output to files (`cmd > out.txt`). It is
+// enabled by default, and should be disabled for consoles whose users must not
+// reach the host, like those of remote clients: only console commands and
+// built-in filters then run, and redirections fail.
+func (c *Console) SetHostAccess(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.hostAccess = enabled
+}
+
+func (c *Console) getHostAccess() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.hostAccess
+}
+
 // SetPrintLogo - Sets the function that will be called to print the logo.
 func (c *Console) SetPrintLogo(f func(c *Console)) {
 	c.printLogo = f
@@ -238,7 +303,7 @@ func (c *Console) SwitchMenu(menu string) {
 // below the line, and will not print the prompt. In any other case this function works normally.
 func (c *Console) TransientPrintf(msg string, args ...any) (n int, err error) {
 	if c.isExecuting {
-		return fmt.Fprintf(c.terminal.Out, msg, args...)
+		return fmt.Fprintf(c.output(), msg, args...)
 	}
 
 	// If the last message we printed asynchronously
@@ -264,7 +329,7 @@ func (c *Console) TransientPrintf(msg string, args ...any) (n int, err error) {
 // below the line, and will not print the prompt. In any other case this function works normally.
 func (c *Console) Printf(msg string, args ...any) (n int, err error) {
 	if c.isExecuting {
-		return fmt.Fprintf(c.terminal.Out, msg, args...)
+		return fmt.Fprintf(c.output(), msg, args...)
 	}
 
 	return c.shell.Printf(msg, args...)
@@ -0,0 +1,358 @@
+// Package consoletest runs consoles on a virtual terminal, so that their
+// interactive behavior (prompts, key bindings, completions, hints) can be
+// tested without a TTY: tests type keys, and assert on the emulated screen.
+package consoletest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/vt"
+
+	"github.com/chainreactors/tui/console"
+	"github.com/chainreactors/tui/readline/terminal"
+)
+
+var update = flag.Bool("consoletest.update", false, "update the consoletest golden files")
+
+// Options configures a Harness. Zero values use the defaults.
+type Options struct {
+	App     string        // Name of the console application (default "test").
+	Cols    int           // Width of the virtual terminal (default 80).
+	Rows    int           // Height of the virtual terminal (default 24).
+	Timeout time.Duration // How long waits last before failing the test (default 5s).
+	Quiet   time.Duration // How long without output the console is considered idle (default 50ms).
+}
+
+// Harness is a console running on a virtual terminal.
+type Harness struct {
+	t       testing.TB
+	opts    Options
+	console *console.Console
+	control *terminal.StreamControl
+	input   *inputQueue
+
+	mutex     sync.Mutex
+	screen    *vt.Emulator
+	lastWrite time.Time
+
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// New creates a console on a virtual terminal. Set it up (menus, commands, prompts)
+// through Console() before calling Start(). The console is stopped when the test ends.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+
+	if opts.App == "" {
+		opts.App = "test"
+	}
+
+	if opts.Cols <= 0 {
+		opts.Cols = 80
+	}
+
+	if opts.Rows <= 0 {
+		opts.Rows = 24
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	if opts.Quiet <= 0 {
+		opts.Quiet = 50 * time.Millisecond
+	}
+
+	h := &Harness{
+		t:         t,
+		opts:      opts,
+		control:   terminal.NewControl(true, opts.Cols, opts.Rows),
+		input:     newInputQueue(),
+		screen:    vt.NewEmulator(opts.Cols, opts.Rows),
+		lastWrite: time.Now(),
+	}
+
+	// The emulator answers terminal queries (cursor position, etc.)
+	// through its input pipe: forward those answers to the console.
+	go io.Copy(h.input, h.screen)
+
+	out := screenWriter{h}
+	h.console = console.NewWithTerminal(opts.App, terminal.Stream(h.input, out, out, h.control))
+
+	t.Cleanup(h.stop)
+
+	return h
+}
+
+// Console returns the console under test.
+func (h *Harness) Console() *console.Console {
+	return h.console
+}
+
+// Start runs the console read loop, and waits for the first prompt to be displayed.
+func (h *Harness) Start() {
+	h.t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.done = make(chan error, 1)
+
+	go func() { h.done <- h.console.StartContext(ctx) }()
+
+	h.WaitIdle()
+}
+
+// Type sends keys to the console, as typed by the user: plain text,
+// control characters and escape sequences (see the Key constants).
+func (h *Harness) Type(keys ...string) {
+	for _, key := range keys {
+		h.input.Write([]byte(key))
+	}
+}
+
+// TypeLine types a line and presses Enter, then waits for the console to be idle.
+func (h *Harness) TypeLine(line string) {
+	h.t.Helper()
+
+	h.Type(line, KeyEnter)
+	h.WaitIdle()
+}
+
+// Resize changes the size of the virtual terminal, notifying the console.
+func (h *Harness) Resize(cols, rows int) {
+	h.mutex.Lock()
+	h.screen.Resize(cols, rows)
+	h.mutex.Unlock()
+
+	h.control.SetSize(cols, rows)
+}
+
+// WaitIdle waits until the console has not written anything for a while,
+// which is the case once it is done redrawing the prompt, completions, etc.
+func (h *Harness) WaitIdle() {
+	h.t.Helper()
+
+	deadline := time.Now().Add(h.opts.Timeout)
+
+	for time.Now().Before(deadline) {
+		h.mutex.Lock()
+		quiet := time.Since(h.lastWrite)
+		h.mutex.Unlock()
+
+		if quiet >= h.opts.Quiet {
+			return
+		}
+
+		time.Sleep(h.opts.Quiet - quiet)
+	}
+
+	h.t.Fatalf("console still writing after %s:\n%s", h.opts.Timeout, h.Screen())
+}
+
+// WaitFor waits until the screen contains text.
+func (h *Harness) WaitFor(text string) {
+	h.t.Helper()
+
+	h.WaitUntil(fmt.Sprintf("screen to contain %q", text), func(screen string) bool {
+		return strings.Contains(screen, text)
+	})
+}
+
+// WaitUntil waits until cond returns true for the screen contents,
+// then until the console is idle. desc describes the condition on failure.
+func (h *Harness) WaitUntil(desc string, cond func(screen string) bool) {
+	h.t.Helper()
+
+	deadline := time.Now().Add(h.opts.Timeout)
+
+	for !cond(h.Screen()) {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %s, screen:\n%s", desc, h.Screen())
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	h.WaitIdle()
+}
+
+// Lines returns the lines of the screen, without trailing spaces.
+func (h *Harness) Lines() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	lines := make([]string, h.screen.Height())
+
+	for y := range lines {
+		var line strings.Builder
+
+		for x := 0; x < h.screen.Width(); {
+			cell := h.screen.CellAt(x, y)
+			if cell == nil || cell.Content == "" {
+				line.WriteByte(' ')
+				x++
+
+				continue
+			}
+
+			line.WriteString(cell.Content)
+			x += max(cell.Width, 1)
+		}
+
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+
+	return lines
+}
+
+// Screen returns the text of the screen, without trailing spaces and empty lines.
+func (h *Harness) Screen() string {
+	return strings.TrimRight(strings.Join(h.Lines(), "\n"), "\n")
+}
+
+// Cursor returns the position of the cursor on the screen (0-based).
+func (h *Harness) Cursor() (x, y int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	pos := h.screen.CursorPosition()
+
+	return pos.X, pos.Y
+}
+
+// Snapshot compares the screen with the golden file testdata/<name>.golden,
+// after waiting for the console to be idle. Run the tests with -consoletest.update
+// to write the golden files instead.
+func (h *Harness) Snapshot(name string) {
+	h.t.Helper()
+
+	h.WaitIdle()
+
+	screen := h.Screen() + "\n"
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			h.t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(screen), 0o644); err != nil {
+			h.t.Fatal(err)
+		}
+
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("reading golden file (run with -consoletest.update to create it): %v", err)
+	}
+
+	if string(golden) != screen {
+		h.t.Errorf("screen does not match %s:\n--- got\n%s--- want\n%s", path, screen, golden)
+	}
+}
+
+// stop ends the console read loop, if started.
+func (h *Harness) stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+
+	h.input.Close()
+
+	if h.done != nil {
+		select {
+		case <-h.done:
+		case <-time.After(h.opts.Timeout):
+			h.t.Errorf("console did not stop after %s", h.opts.Timeout)
+		}
+	}
+
+	// Emulator.Close() is not safe to use while reading its answers:
+	// end those by closing the pipe they are written to instead.
+	if pipe, ok := h.screen.InputPipe().(io.Closer); ok {
+		pipe.Close()
+	}
+}
+
+// screenWriter writes the console output to the emulated screen.
+type screenWriter struct {
+	h *Harness
+}
+
+func (w screenWriter) Write(p []byte) (int, error) {
+	w.h.mutex.Lock()
+	defer w.h.mutex.Unlock()
+
+	w.h.lastWrite = time.Now()
+
+	return w.h.screen.Write(p)
+}
+
+// inputQueue is the console input: writes never block, so that
+// tests can type keys ahead of the console reading them.
+type inputQueue struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newInputQueue() *inputQueue {
+	q := &inputQueue{}
+	q.cond = sync.NewCond(&q.mutex)
+
+	return q
+}
+
+func (q *inputQueue) Write(p []byte) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return 0, errors.New("console input closed")
+	}
+
+	q.buf.Write(p)
+	q.cond.Broadcast()
+
+	return len(p), nil
+}
+
+func (q *inputQueue) Read(p []byte) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for q.buf.Len() == 0 && !q.closed {
+		q.cond.Wait()
+	}
+
+	if q.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+
+	return q.buf.Read(p)
+}
+
+func (q *inputQueue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+
+	return nil
+}
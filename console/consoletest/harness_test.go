@@ -0,0 +1,92 @@
+package consoletest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newTestHarness(t *testing.T) *Harness {
+	h := New(t, Options{Cols: 60, Rows: 12})
+
+	menu := h.Console().ActiveMenu()
+	menu.Prompt().Primary = func() string { return "test > " }
+
+	menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root"}
+		root.AddCommand(&cobra.Command{
+			Use:   "sessions",
+			Short: "List sessions",
+			Run: func(cmd *cobra.Command, _ []string) {
+				fmt.Fprintln(cmd.OutOrStdout(), "1 windows")
+				fmt.Fprintln(cmd.OutOrStdout(), "2 linux")
+			},
+		})
+		root.AddCommand(&cobra.Command{
+			Use:   "settings",
+			Short: "Show settings",
+			Run:   func(*cobra.Command, []string) {},
+		})
+		root.AddCommand(&cobra.Command{
+			Use:   "upload <local> <remote>",
+			Short: "Upload a file",
+			Args:  cobra.ExactArgs(2),
+			Run:   func(*cobra.Command, []string) {},
+		})
+		root.Flags().Bool("verbose", false, "")
+		return root
+	})
+
+	h.Start()
+
+	return h
+}
+
+func TestHarnessRunsCommands(t *testing.T) {
+	h := newTestHarness(t)
+
+	h.TypeLine("sessions | grep linux")
+	h.WaitFor("2 linux")
+
+	if strings.Contains(h.Screen(), "1 windows") {
+		t.Fatalf("pipe not applied, screen:\n%s", h.Screen())
+	}
+
+	if lines := h.Lines(); !strings.HasPrefix(lines[0], "test > sessions | grep linux") {
+		t.Fatalf("first line = %q", lines[0])
+	}
+}
+
+func TestHarnessEditing(t *testing.T) {
+	h := newTestHarness(t)
+
+	h.Type("sesions", KeyLeft, KeyLeft, KeyLeft, KeyLeft, "s")
+	h.WaitFor("test > sessions")
+
+	h.Type(Ctrl('u'))
+	h.WaitUntil("line to be cleared", func(screen string) bool {
+		return !strings.Contains(screen, "sessions")
+	})
+
+	if x, _ := h.Cursor(); x != len("test > ") {
+		t.Fatalf("cursor at column %d after clearing the line", x)
+	}
+}
+
+func TestHarnessCompletionSnapshot(t *testing.T) {
+	h := newTestHarness(t)
+
+	h.Type("se", KeyTab)
+	h.WaitFor("settings")
+	h.Snapshot("completion-menu")
+}
+
+func TestHarnessHintSnapshot(t *testing.T) {
+	h := newTestHarness(t)
+
+	h.Type("upload ")
+	h.WaitFor("upload <local> <remote>")
+	h.Snapshot("usage-hint")
+}
@@ -0,0 +1,29 @@
+package consoletest
+
+// Keys, as sent by a terminal (xterm) in normal cursor mode.
+const (
+	KeyEnter     = "\r"
+	KeyTab       = "\t"
+	KeyBackspace = "\x7f"
+	KeyEscape    = "\x1b"
+	KeyUp        = "\x1b[A"
+	KeyDown      = "\x1b[B"
+	KeyRight     = "\x1b[C"
+	KeyLeft      = "\x1b[D"
+	KeyHome      = "\x1b[H"
+	KeyEnd       = "\x1b[F"
+	KeyDelete    = "\x1b[3~"
+	KeyShiftTab  = "\x1b[Z"
+	KeyCtrlC     = "\x03"
+	KeyCtrlD     = "\x04"
+)
+
+// Ctrl returns the key typed with Control and a letter (eg. Ctrl('r')).
+func Ctrl(r rune) string {
+	return string(r & 0x1f)
+}
+
+// Alt returns the key typed with Alt (Meta) and another key.
+func Alt(key string) string {
+	return KeyEscape + key
+}
@@ -0,0 +1,174 @@
+package console
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// variablePattern matches variable references in raw input, for highlighting.
+var variablePattern = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandAliases replaces the command words of the line that are aliases with their
+// values. Like in shells, this is a textual replacement (so aliases may contain
+// pipes and sequences), an alias is not expanded again in its own value, and
+// quoted or escaped command words are not aliases.
+func (c *Console) expandAliases(menu *Menu, input string, seen map[string]bool) string {
+	file, err := syntax.NewParser(syntax.KeepComments(false)).Parse(strings.NewReader(input), "")
+	if err != nil {
+		return input
+	}
+
+	type expansion struct {
+		start, end int
+		name       string
+		value      string
+	}
+
+	var expansions []expansion
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		name, ok := plainWord(call.Args[0])
+		if !ok || seen[name] {
+			return true
+		}
+
+		if value, found := c.definitions.lookup(menu, true, name); found {
+			expansions = append(expansions, expansion{
+				start: int(call.Args[0].Pos().Offset()),
+				end:   int(call.Args[0].End().Offset()),
+				name:  name,
+				value: value,
+			})
+		}
+
+		return true
+	})
+
+	sort.Slice(expansions, func(i, j int) bool { return expansions[i].start > expansions[j].start })
+
+	for _, exp := range expansions {
+		nested := map[string]bool{exp.name: true}
+		for name := range seen {
+			nested[name] = true
+		}
+
+		value := c.expandAliases(menu, exp.value, nested)
+		input = input[:exp.start] + value + input[exp.end:]
+	}
+
+	return input
+}
+
+// expandVariables replaces the references to defined variables in the words of the
+// parsed line with their values, quoted so that they are neither split nor re-expanded.
+func (c *Console) expandVariables(menu *Menu, file *syntax.File) {
+	syntax.Walk(file, func(node syntax.Node) bool {
+		word, ok := node.(*syntax.Word)
+		if !ok {
+			return true
+		}
+
+		var parts []syntax.WordPart
+
+		for _, part := range word.Parts {
+			switch part := part.(type) {
+			case *syntax.ParamExp:
+				parts = append(parts, c.expandParam(menu, part)...)
+
+			case *syntax.DblQuoted:
+				// Split the double-quoted string around
+				// expanded variables, which are single-quoted.
+				quoted := &syntax.DblQuoted{Dollar: part.Dollar}
+
+				for _, inner := range part.Parts {
+					param, ok := inner.(*syntax.ParamExp)
+					if !ok {
+						quoted.Parts = append(quoted.Parts, inner)
+						continue
+					}
+
+					expanded := c.expandParam(menu, param)
+					if len(expanded) == 1 && expanded[0] == param {
+						quoted.Parts = append(quoted.Parts, inner)
+						continue
+					}
+
+					if len(quoted.Parts) > 0 {
+						parts = append(parts, quoted)
+					}
+
+					parts = append(parts, expanded...)
+					quoted = &syntax.DblQuoted{}
+				}
+
+				if len(quoted.Parts) > 0 || len(parts) == 0 || quoted.Dollar {
+					parts = append(parts, quoted)
+				}
+
+			default:
+				parts = append(parts, part)
+			}
+		}
+
+		word.Parts = parts
+
+		return true
+	})
+}
+
+// expandParam returns the value of a simple `$name` or `${name}` reference to a
+// defined variable, quoted, or the reference itself if it is not one.
+func (c *Console) expandParam(menu *Menu, param *syntax.ParamExp) []syntax.WordPart {
+	if param.Param == nil || param.Excl || param.Length || param.Width || param.Index != nil ||
+		param.Slice != nil || param.Repl != nil || param.Names != 0 || param.Exp != nil {
+		return []syntax.WordPart{param}
+	}
+
+	value, found := c.definitions.lookup(menu, false, param.Param.Value)
+	if !found {
+		return []syntax.WordPart{param}
+	}
+
+	return quoteWordParts(value)
+}
+
+// quoteWordParts quotes a value without backslashes, since their meaning
+// depends on the escape mode: single quotes are themselves double-quoted.
+func quoteWordParts(value string) []syntax.WordPart {
+	pieces := strings.Split(value, "'")
+	parts := make([]syntax.WordPart, 0, len(pieces)*2)
+
+	for i, piece := range pieces {
+		if i > 0 {
+			parts = append(parts, &syntax.DblQuoted{Parts: []syntax.WordPart{&syntax.Lit{Value: "'"}}})
+		}
+
+		if piece != "" || len(pieces) == 1 {
+			parts = append(parts, &syntax.SglQuoted{Value: piece})
+		}
+	}
+
+	return parts
+}
+
+// plainWord returns the word if it is made of unquoted, unescaped characters only.
+func plainWord(word *syntax.Word) (string, bool) {
+	if len(word.Parts) != 1 {
+		return "", false
+	}
+
+	lit, ok := word.Parts[0].(*syntax.Lit)
+	if !ok || strings.ContainsRune(lit.Value, '\\') {
+		return "", false
+	}
+
+	return lit.Value, true
+}
@@ -0,0 +1,94 @@
+package console
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandAliasesAndVariables(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	console.SetAlias("ll", "sessions --all")
+	console.SetAlias("wins", "ll | grep windows")
+	console.SetAlias("ls", "ls -l")
+	console.SetVariable("target", "10.0.0.5")
+	console.SetVariable("quote", `it's "here"`)
+	menu.SetVariable("target", "192.168.1.1")
+
+	other := console.NewMenu("other")
+
+	tests := []struct {
+		menu   *Menu
+		input  string
+		stages [][]string
+	}{
+		{nil, "ll -v", [][]string{{"sessions", "--all", "-v"}}},
+		{nil, "wins", [][]string{{"sessions", "--all"}, {"grep", "windows"}}},
+		{nil, "ls", [][]string{{"ls", "-l"}}},
+		{nil, `'ll' \ll`, [][]string{{"ll", "ll"}}},
+		{nil, "echo ll", [][]string{{"echo", "ll"}}},
+		{nil, "use $target", [][]string{{"use", "10.0.0.5"}}},
+		{menu, "use $target", [][]string{{"use", "192.168.1.1"}}},
+		{other, "use ${target}:80", [][]string{{"use", "10.0.0.5:80"}}},
+		{nil, `echo "host $target up" '$target' $unknown`, [][]string{{"echo", "host 10.0.0.5 up", "$target", "$unknown"}}},
+		{nil, "echo $quote", [][]string{{"echo", `it's "here"`}}},
+	}
+
+	for _, tt := range tests {
+		steps, err := console.parseLine(tt.menu, tt.input)
+		if err != nil {
+			t.Fatalf("parseLine(%q) error = %v", tt.input, err)
+		}
+
+		if len(steps) != 1 || !reflect.DeepEqual(steps[0].pipe.stages, tt.stages) {
+			t.Errorf("parseLine(%q) = %v, want %q", tt.input, steps, tt.stages)
+		}
+	}
+}
+
+func TestDefinitionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "definitions.json")
+
+	console := New("test")
+	if err := console.SetDefinitionsFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	console.SetAlias("ll", "sessions --all")
+	console.NewMenu("implant").SetVariable("target", "10.0.0.5")
+
+	if err := console.SetVariable("bad name", "x"); err == nil {
+		t.Fatal("expected an error for an invalid variable name")
+	}
+
+	loaded := New("test")
+	if err := loaded.SetDefinitionsFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := loaded.Aliases()["ll"]; got != "sessions --all" {
+		t.Fatalf("loaded alias = %q", got)
+	}
+
+	if got, _ := loaded.NewMenu("implant").Variable("target"); got != "10.0.0.5" {
+		t.Fatalf("loaded menu variable = %q", got)
+	}
+}
+
+func TestHighlightExpansions(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	console.SetVariable("target", "10.0.0.5")
+
+	word := console.highlightVariables(menu, "$target:$other")
+	if want := console.expansionHighlight() + "$target" + seqFgReset + ":$other"; word != want {
+		t.Fatalf("highlighted word = %q", word)
+	}
+
+	if word := console.highlightVariables(menu, "'$target'"); word != "'$target'" {
+		t.Fatalf("single-quoted word highlighted: %q", word)
+	}
+}
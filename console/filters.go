@@ -0,0 +1,467 @@
+package console
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/pflag"
+)
+
+// PipeFilter is a built-in command that can be used as a pipeline stage
+// (eg. `sessions | grep win`). It reads the output of the previous stage
+// from in, and writes its result to out. Args do not include the filter name.
+type PipeFilter func(args []string, in io.Reader, out io.Writer) error
+
+// AddPipeFilter registers a filter usable after a pipe, or replaces an existing one.
+// Menu commands with the same name take precedence over filters.
+func (c *Console) AddPipeFilter(name string, filter PipeFilter) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pipeFilters == nil {
+		c.pipeFilters = make(map[string]PipeFilter)
+	}
+
+	c.pipeFilters[name] = filter
+}
+
+// RemovePipeFilter unregisters a pipe filter, including built-in ones.
+func (c *Console) RemovePipeFilter(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.pipeFilters, name)
+}
+
+func (c *Console) pipeFilter(name string) (PipeFilter, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	filter, found := c.pipeFilters[name]
+
+	return filter, found
+}
+
+func defaultPipeFilters() map[string]PipeFilter {
+	return map[string]PipeFilter{
+		"grep": grepFilter,
+		"head": headFilter,
+		"tail": tailFilter,
+		"wc":   wcFilter,
+		"jq":   jqFilter,
+	}
+}
+
+func filterFlags(name string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	return flags
+}
+
+// grep [-i] [-v] [-n] [-c] [-F] pattern
+func grepFilter(args []string, in io.Reader, out io.Writer) error {
+	flags := filterFlags("grep")
+	ignoreCase := flags.BoolP("ignore-case", "i", false, "")
+	invert := flags.BoolP("invert-match", "v", false, "")
+	number := flags.BoolP("line-number", "n", false, "")
+	count := flags.BoolP("count", "c", false, "")
+	fixed := flags.BoolP("fixed-strings", "F", false, "")
+	flags.BoolP("extended-regexp", "E", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("grep: %w", err)
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("grep: usage: grep [-ivncF] pattern")
+	}
+
+	pattern := flags.Arg(0)
+	if *fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("grep: %w", err)
+	}
+
+	matches := 0
+	scanner := newLineScanner(in)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if re.MatchString(stripANSI(line)) == *invert {
+			continue
+		}
+
+		matches++
+
+		if *count {
+			continue
+		}
+
+		if *number {
+			fmt.Fprintf(out, "%d:", lineNo)
+		}
+
+		fmt.Fprintln(out, line)
+	}
+
+	if *count {
+		fmt.Fprintln(out, matches)
+	}
+
+	return scanner.Err()
+}
+
+// head [-n N | -N]
+func headFilter(args []string, in io.Reader, out io.Writer) error {
+	n, err := lineCountArg("head", args)
+	if err != nil {
+		return err
+	}
+
+	scanner := newLineScanner(in)
+	for i := 0; i < n && scanner.Scan(); i++ {
+		fmt.Fprintln(out, scanner.Text())
+	}
+
+	return scanner.Err()
+}
+
+// tail [-n N | -N]
+func tailFilter(args []string, in io.Reader, out io.Writer) error {
+	n, err := lineCountArg("tail", args)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+
+	scanner := newLineScanner(in)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(out, line)
+	}
+
+	return scanner.Err()
+}
+
+func lineCountArg(name string, args []string) (int, error) {
+	// Support the historical -N form.
+	for i, arg := range args {
+		if len(arg) > 1 && arg[0] == '-' {
+			if _, err := strconv.Atoi(arg[1:]); err == nil {
+				args = append(append([]string{}, args[:i]...), append([]string{"-n", arg[1:]}, args[i+1:]...)...)
+				break
+			}
+		}
+	}
+
+	flags := filterFlags(name)
+	n := flags.IntP("lines", "n", 10, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if *n < 0 {
+		return 0, fmt.Errorf("%s: invalid number of lines: %d", name, *n)
+	}
+
+	return *n, nil
+}
+
+// wc [-l] [-w] [-c]
+func wcFilter(args []string, in io.Reader, out io.Writer) error {
+	flags := filterFlags("wc")
+	lines := flags.BoolP("lines", "l", false, "")
+	words := flags.BoolP("words", "w", false, "")
+	chars := flags.BoolP("chars", "c", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("wc: %w", err)
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	text := stripANSI(string(data))
+
+	if !*lines && !*words && !*chars {
+		*lines, *words, *chars = true, true, true
+	}
+
+	var counts []string
+	if *lines {
+		counts = append(counts, strconv.Itoa(strings.Count(text, "\n")))
+	}
+
+	if *words {
+		counts = append(counts, strconv.Itoa(len(strings.Fields(text))))
+	}
+
+	if *chars {
+		counts = append(counts, strconv.Itoa(utf8.RuneCountInString(text)))
+	}
+
+	fmt.Fprintln(out, strings.Join(counts, " "))
+
+	return nil
+}
+
+// jq [-r] [-c] path, where path is a jq-like field selection:
+// `.`, `.field.sub`, `.[0]`, `.[]`, `.items[].name`, `.["odd key"]`.
+// The input is either one JSON document or a stream of them (JSON lines).
+func jqFilter(args []string, in io.Reader, out io.Writer) error {
+	flags := filterFlags("jq")
+	raw := flags.BoolP("raw-output", "r", false, "")
+	compact := flags.BoolP("compact-output", "c", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("jq: %w", err)
+	}
+
+	path := "."
+	if flags.NArg() > 1 {
+		return errors.New("jq: usage: jq [-rc] path")
+	} else if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+
+	selectors, err := parseJSONPath(path)
+	if err != nil {
+		return fmt.Errorf("jq: %w", err)
+	}
+
+	decoder := json.NewDecoder(in)
+	decoder.UseNumber()
+
+	for {
+		var doc any
+		if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("jq: invalid JSON input: %w", err)
+		}
+
+		results, err := selectJSON([]any{doc}, selectors)
+		if err != nil {
+			return fmt.Errorf("jq: %w", err)
+		}
+
+		for _, result := range results {
+			if err := writeJSONValue(out, result, *raw, *compact); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// jsonSelector selects a key (key != nil), an index (index != nil), or all elements.
+type jsonSelector struct {
+	key   *string
+	index *int
+}
+
+func parseJSONPath(path string) ([]jsonSelector, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("path must start with '.': %s", path)
+	}
+
+	var selectors []jsonSelector
+
+	rest := path
+	for len(rest) > 0 {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' in %s", path)
+			}
+
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "":
+				selectors = append(selectors, jsonSelector{})
+			case strings.HasPrefix(inner, `"`):
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid key %s", inner)
+				}
+				selectors = append(selectors, jsonSelector{key: &key})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %s", inner)
+				}
+				selectors = append(selectors, jsonSelector{index: &index})
+			}
+
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			if key := rest[:end]; key != "" {
+				selectors = append(selectors, jsonSelector{key: &key})
+			}
+
+			rest = rest[end:]
+
+		default:
+			return nil, fmt.Errorf("unexpected %q in %s", rest, path)
+		}
+	}
+
+	return selectors, nil
+}
+
+func selectJSON(values []any, selectors []jsonSelector) ([]any, error) {
+	for _, sel := range selectors {
+		var next []any
+
+		for _, value := range values {
+			switch {
+			case sel.key != nil:
+				if value == nil {
+					next = append(next, nil)
+					continue
+				}
+
+				object, ok := value.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("cannot index %s with %q", jsonType(value), *sel.key)
+				}
+
+				next = append(next, object[*sel.key])
+
+			case sel.index != nil:
+				if value == nil {
+					next = append(next, nil)
+					continue
+				}
+
+				array, ok := value.([]any)
+				if !ok {
+					return nil, fmt.Errorf("cannot index %s with number", jsonType(value))
+				}
+
+				index := *sel.index
+				if index < 0 {
+					index += len(array)
+				}
+
+				if index < 0 || index >= len(array) {
+					next = append(next, nil)
+				} else {
+					next = append(next, array[index])
+				}
+
+			default:
+				switch v := value.(type) {
+				case []any:
+					next = append(next, v...)
+				case map[string]any:
+					for _, elem := range v {
+						next = append(next, elem)
+					}
+				default:
+					return nil, fmt.Errorf("cannot iterate over %s", jsonType(value))
+				}
+			}
+		}
+
+		values = next
+	}
+
+	return values, nil
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func writeJSONValue(out io.Writer, value any, raw, compact bool) error {
+	if str, ok := value.(string); ok && raw {
+		_, err := fmt.Fprintln(out, str)
+		return err
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	if compact {
+		data, err = json.Marshal(value)
+	} else {
+		data, err = json.MarshalIndent(value, "", "  ")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, string(data))
+
+	return err
+}
+
+func newLineScanner(in io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	return scanner
+}
+
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;:?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(\x07|\x1b\\\\)")
+
+// stripANSI removes escape sequences, so that filters
+// match and count what the user actually sees.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+
+	return ansiPattern.ReplaceAllString(s, "")
+}
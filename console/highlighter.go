@@ -3,16 +3,13 @@ package console
 import (
 	"strings"
 
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+	"github.com/chainreactors/tui/term"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
 )
 
-var (
-	seqFgGreen  = "\x1b[32m"
-	seqFgYellow = "\x1b[33m"
-	seqFgReset  = "\x1b[39m"
-
-	seqBrightWigth = "\x1b[38;05;244m"
-)
+var seqFgReset = "\x1b[39m"
 
 // Base text effects.
 var (
@@ -34,7 +31,7 @@ var (
 // SetDefaultCommandHighlight allows the user to change the highlight color for a command in the default syntax
 // highlighter using an ansi code.
 // This action has no effect if a custom syntax highlighter for the shell is set.
-// By default, the highlight code is green ("\x1b[32m").
+// By default, the command color of the current term theme is used (green).
 func (c *Console) SetDefaultCommandHighlight(seq string) {
 	c.cmdHighlight = seq
 }
@@ -42,15 +39,76 @@ func (c *Console) SetDefaultCommandHighlight(seq string) {
 // SetDefaultFlagHighlight allows the user to change the highlight color for a flag in the default syntax
 // highlighter using an ansi color code.
 // This action has no effect if a custom syntax highlighter for the shell is set.
-// By default, the highlight code is grey ("\x1b[38;05;244m").
+// By default, the flag color of the current term theme is used (grey).
 func (c *Console) SetDefaultFlagHighlight(seq string) {
 	c.flagHighlight = seq
 }
 
+func (c *Console) commandHighlight() string {
+	if c.cmdHighlight != "" {
+		return c.cmdHighlight
+	}
+	colors := c.colors()
+	return colors.fg(colors.Command)
+}
+
+func (c *Console) flagHighlightSeq() string {
+	if c.flagHighlight != "" {
+		return c.flagHighlight
+	}
+	colors := c.colors()
+	return colors.fg(colors.Flag)
+}
+
+// colors are the theme palette and the color profile of a console terminal.
+type colors struct {
+	term.Palette
+	profile termenv.Profile
+}
+
+// colors returns the palette and color profile of the console terminal. Those
+// of the process terminal are the term package ones. Other terminals (like
+// those of remote clients) cannot be queried for their background: they use
+// the dark palette, with the profile of their environment if their control
+// knows it, or 256 colors unless they are not terminals.
+func (c *Console) colors() colors {
+	if c.input == nil {
+		return colors{term.CurrentPalette(), term.ColorProfile()}
+	}
+
+	isTerminal := c.terminal.Control.IsTerminal()
+
+	if environ, ok := c.terminal.Control.(rlterm.Environ); ok && (environ.Getenv("TERM") != "" || environ.Getenv("OS") != "") {
+		caps := term.TerminalCapabilities(environ.Getenv, isTerminal)
+		if caps.Dumb {
+			caps.Profile = termenv.Ascii
+		}
+
+		return colors{term.CurrentTheme().Dark, caps.Profile}
+	}
+
+	profile := termenv.Ascii
+	if isTerminal {
+		profile = termenv.ANSI256
+	}
+
+	return colors{term.CurrentTheme().Dark, profile}
+}
+
+// fg returns the foreground sequence of a color, or nothing if colors are disabled.
+func (c colors) fg(color term.ThemeColor) string {
+	return color.Sequence(c.profile, false)
+}
+
 // highlightSyntax - Entrypoint to all input syntax highlighting in the Wiregost console.
 func (c *Console) highlightSyntax(input []rune) (line string) {
+	colors := c.colors()
+	if colors.profile == termenv.Ascii {
+		return string(input)
+	}
+
 	// Split the line as shellwords
-	args, unprocessed, err := split(string(input), true, c.getEscapeMode())
+	args, unprocessed, err := split(string(input), true, colors.fg(colors.Warning), c.getEscapeMode())
 	if err != nil {
 		args = append(args, unprocessed)
 	}
@@ -59,9 +117,14 @@ func (c *Console) highlightSyntax(input []rune) (line string) {
 	remain := args                     // List of words to process, draw from
 	trimmed := trimSpacesMatch(remain) // Match stuff against trimmed words
 
-	// Highlight the root command when found.
-	cmd, _, _ := c.activeMenu().Find(trimmed)
-	if cmd != nil {
+	// Highlight the root command when found, or the alias used instead.
+	menu := c.activeMenu()
+	cmd, _, _ := menu.Find(trimmed)
+
+	if len(trimmed) > 0 && c.isAlias(menu, trimmed[0]) {
+		highlighted = append(highlighted, bold+c.expansionHighlight()+args[0]+seqFgReset+boldReset)
+		remain = args[1:]
+	} else if cmd != nil {
 		highlighted, remain = c.highlightCommand(highlighted, args, cmd)
 	}
 
@@ -71,6 +134,11 @@ func (c *Console) highlightSyntax(input []rune) (line string) {
 	// Done with everything, add remainind, non-processed words
 	highlighted = append(highlighted, remain...)
 
+	// Highlight the variables that will be expanded.
+	for i, word := range highlighted {
+		highlighted[i] = c.highlightVariables(menu, word)
+	}
+
 	// Join all words.
 	line = strings.Join(highlighted, "")
 
@@ -98,7 +166,7 @@ func (c *Console) highlightCommand(done, args []string, _ *cobra.Command) ([]str
 		}
 
 		if cmdFound {
-			highlighted = append(highlighted, bold+c.cmdHighlight+args[0]+seqFgReset+boldReset)
+			highlighted = append(highlighted, bold+c.commandHighlight()+args[0]+seqFgReset+boldReset)
 			rest = args[1:]
 
 			return append(done, highlighted...), rest
@@ -118,7 +186,7 @@ func (c *Console) highlightCommandFlags(done, args []string, _ *cobra.Command) (
 
 	for _, arg := range args {
 		if strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
-			highlighted = append(highlighted, bold+c.flagHighlight+arg+seqFgReset+boldReset)
+			highlighted = append(highlighted, bold+c.flagHighlightSeq()+arg+seqFgReset+boldReset)
 		} else {
 			highlighted = append(highlighted, arg)
 		}
@@ -126,3 +194,87 @@ func (c *Console) highlightCommandFlags(done, args []string, _ *cobra.Command) (
 
 	return append(done, highlighted...), rest
 }
+
+// expansionHighlight returns the color used for aliases and variables.
+func (c *Console) expansionHighlight() string {
+	colors := c.colors()
+	return colors.fg(colors.Special)
+}
+
+// isAlias returns true if word is an alias to be expanded in menu.
+func (c *Console) isAlias(menu *Menu, word string) bool {
+	_, found := c.definitions.lookup(menu, true, word)
+	return found
+}
+
+// highlightVariables highlights the references to defined variables in a word,
+// except those in single quotes or escaped, which are not expanded. The word
+// may already be highlighted: its color sequences are skipped.
+func (c *Console) highlightVariables(menu *Menu, word string) string {
+	literal := literalBytes(word, c.getEscapeMode() != EscapeLiteral)
+
+	var highlighted strings.Builder
+
+	last := 0
+
+	for _, match := range variablePattern.FindAllStringIndex(word, -1) {
+		ref := word[match[0]:match[1]]
+		if literal[match[0]] {
+			continue
+		}
+
+		if _, found := c.definitions.lookup(menu, false, strings.Trim(ref, "${}")); !found {
+			continue
+		}
+
+		highlighted.WriteString(word[last:match[0]])
+		highlighted.WriteString(c.expansionHighlight() + ref + seqFgReset)
+		last = match[1]
+	}
+
+	highlighted.WriteString(word[last:])
+
+	return highlighted.String()
+}
+
+// literalBytes returns which bytes of a word are taken literally by the shell
+// parser: those in single quotes, and those escaped by a backslash if escapes
+// is true. Color sequences are skipped, and are never literal.
+func literalBytes(word string, escapes bool) []bool {
+	literal := make([]bool, len(word))
+
+	var quote byte
+
+	for i := 0; i < len(word); i++ {
+		char := word[i]
+
+		if char == '\x1b' && i+1 < len(word) && word[i+1] == '[' {
+			for i += 2; i < len(word) && (word[i] < 0x40 || word[i] > 0x7e); i++ {
+			}
+
+			continue
+		}
+
+		literal[i] = quote == '\''
+
+		switch {
+		case quote == '\'':
+			if char == '\'' {
+				quote = 0
+			}
+		case char == '\\' && escapes:
+			if i+1 < len(word) {
+				i++
+				literal[i] = true
+			}
+		case quote == '"':
+			if char == '"' {
+				quote = 0
+			}
+		case char == '\'' || char == '"':
+			quote = char
+		}
+	}
+
+	return literal
+}
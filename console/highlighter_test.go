@@ -0,0 +1,81 @@
+package console
+
+import (
+	"strings"
+	"testing"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+	"github.com/spf13/cobra"
+)
+
+func TestHighlightTerminalColors(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		env      map[string]string // Sent by the client, if any.
+		colored  bool
+	}{
+		{"terminal", true, nil, true},
+		{"stream", false, nil, false},
+		{"client terminal", true, map[string]string{"TERM": "xterm-256color"}, true},
+		{"dumb client", true, map[string]string{"TERM": "dumb"}, false},
+		{"client without colors", true, map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The colors of a console depend on its terminal, not on the process output.
+			control := rlterm.NewControl(test.terminal, 80, 24)
+			control.SetEnv(test.env)
+
+			terminal := rlterm.Stream(strings.NewReader(""), nil, nil, control)
+			console := NewWithTerminal("test", terminal)
+
+			console.ActiveMenu().SetCommands(func() *cobra.Command {
+				root := &cobra.Command{Use: "root"}
+				root.AddCommand(&cobra.Command{Use: "run", Run: func(*cobra.Command, []string) {}})
+				return root
+			})
+
+			line := console.highlightSyntax([]rune(`run --flag "quoted"`))
+			if colored := strings.Contains(line, "\x1b[38;5;"); colored != test.colored {
+				t.Fatalf("highlighted line = %q, want colored = %v", line, test.colored)
+			}
+		})
+	}
+}
+
+func TestHighlightQuotedVariables(t *testing.T) {
+	terminal := rlterm.Stream(strings.NewReader(""), nil, nil, rlterm.NewControl(true, 80, 24))
+	console := NewWithTerminal("test", terminal)
+	console.SetVariable("target", "10.0.0.5")
+
+	console.ActiveMenu().SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root"}
+		root.AddCommand(&cobra.Command{Use: "run", Run: func(*cobra.Command, []string) {}})
+		return root
+	})
+
+	// Variables are expanded in double quotes, not in single quotes or escaped.
+	tests := []struct {
+		line     string
+		expanded bool
+	}{
+		{`run $target`, true},
+		{`run "$target"`, true},
+		{`run "'$target'"`, true},
+		{`run '$target'`, false},
+		{`run '"$target"'`, false},
+		{`run \$target`, false},
+		{`run "\$target"`, false},
+	}
+
+	highlight := console.expansionHighlight() + "$target"
+
+	for _, test := range tests {
+		line := console.highlightSyntax([]rune(test.line))
+		if expanded := strings.Contains(line, highlight); expanded != test.expanded {
+			t.Errorf("highlightSyntax(%s) = %q, want variable highlighted = %v", test.line, line, test.expanded)
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package console
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// hint returns the contextual hint of the command line at the cursor, displayed below
+// the input line: the usage of the command with the positional argument expected,
+// or the flag whose value is typed, followed by the errors found when checking the
+// words of the line against the command flags. Flags are not parsed into the command.
+func (c *Console) hint(line []rune, pos int) string {
+	menu := c.activeMenu()
+	if menu.Command == nil {
+		return ""
+	}
+
+	// Aliases are expanded first, since their values may hold several commands.
+	input := c.expandAliases(menu, strip(string(line[:pos])), nil)
+	statement := currentStatement([]rune(input), c.getEscapeMode() != EscapeLiteral)
+
+	args, _, _ := splitArgs(statement, len(statement), c.getEscapeMode())
+
+	// The last word is the one under the cursor, possibly being typed.
+	cmd, depth := menu.Command, 0
+	for _, arg := range args {
+		next := findSubcommand(cmd, arg)
+		if next == nil {
+			break
+		}
+
+		cmd, depth = next, depth+1
+	}
+
+	if depth == 0 {
+		return ""
+	}
+
+	check := checkArgs(commandFlags(cmd), args[depth:])
+
+	var hint string
+
+	if check.value != nil {
+		hint = flagHint(check.value)
+	} else {
+		hint = usageHint(cmd, check.positional)
+	}
+
+	if len(check.errs) == 0 {
+		return hint
+	}
+
+	colors := c.colors()
+	errColor := colors.fg(colors.Error)
+
+	for _, err := range check.errs {
+		hint += "\n" + errColor + err.Error() + seqFgReset
+	}
+
+	return hint
+}
+
+// commandFlags returns the flags accepted by a command: its own ones, those
+// inherited from its parents and the help flag, which cobra only merges into
+// the command flags on execution. The flag sets of the commands are not modified.
+func commandFlags(cmd *cobra.Command) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	flags.AddFlagSet(cmd.Flags())
+	flags.AddFlagSet(cmd.PersistentFlags())
+
+	for parent := cmd.Parent(); parent != nil; parent = parent.Parent() {
+		flags.AddFlagSet(parent.PersistentFlags())
+	}
+
+	if flags.Lookup("help") == nil {
+		usage := "help for " + cmd.Name()
+
+		if flags.ShorthandLookup("h") == nil {
+			flags.BoolP("help", "h", false, usage)
+		} else {
+			flags.Bool("help", false, usage)
+		}
+	}
+
+	return flags
+}
+
+// argsCheck is the state of command words checked against the command flags.
+type argsCheck struct {
+	positional int         // Positional argument under the cursor, or -1 if not on one.
+	value      *pflag.Flag // Flag whose value is under the cursor.
+	errs       []error     // Unknown flags, invalid values and missing required flags.
+}
+
+// checkArgs checks the arguments of a command against its flags, the last argument
+// being the one under the cursor: it is not reported as an unknown flag while typed.
+func checkArgs(flags *pflag.FlagSet, args []string) (check argsCheck) {
+	seen := make(map[string]bool)
+	dashed := false
+	positionals := 0
+
+	check.positional = -1
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		current := i == len(args)-1
+
+		switch {
+		case dashed || arg == "" || arg == "-" || !strings.HasPrefix(arg, "-"):
+			if current {
+				check.positional = positionals
+			}
+
+			positionals++
+
+		case arg == "--":
+			dashed = true
+
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+
+			flag := flags.Lookup(name)
+			if flag == nil {
+				if !current {
+					check.errs = append(check.errs, fmt.Errorf("unknown flag: --%s", name))
+				}
+
+				continue
+			}
+
+			seen[flag.Name] = true
+
+			i += check.flagValue(flag, value, hasValue, current, args[i+1:])
+
+		default:
+			for j, short := range arg[1:] {
+				flag := flags.ShorthandLookup(string(short))
+				if flag == nil {
+					if !current {
+						check.errs = append(check.errs, fmt.Errorf("unknown shorthand flag: %q in %s", short, arg))
+					}
+
+					break
+				}
+
+				seen[flag.Name] = true
+
+				// The rest of the word is the value of the flag, if it takes one.
+				if flag.NoOptDefVal == "" {
+					value := strings.TrimPrefix(arg[1+j+len(string(short)):], "=")
+					i += check.flagValue(flag, value, value != "", current, args[i+1:])
+
+					break
+				}
+			}
+		}
+	}
+
+	var missing []string
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		required := flag.Annotations[cobra.BashCompOneRequiredFlag]
+		if len(required) > 0 && required[0] == "true" && !seen[flag.Name] {
+			missing = append(missing, strconv.Quote(flag.Name))
+		}
+	})
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		check.errs = append(check.errs, fmt.Errorf("required flag(s) %s not set", strings.Join(missing, ", ")))
+	}
+
+	return check
+}
+
+// flagValue checks the value of a flag, given in the flag word or the next one,
+// and returns the number of words consumed after the flag word.
+func (check *argsCheck) flagValue(flag *pflag.Flag, value string, hasValue, current bool, next []string) (consumed int) {
+	switch {
+	case hasValue:
+		if current {
+			check.value = flag
+		}
+
+	case flag.NoOptDefVal != "" || len(next) == 0:
+		return 0
+
+	default:
+		value, consumed = next[0], 1
+		if len(next) == 1 {
+			check.value = flag
+		}
+	}
+
+	if value == "" {
+		return consumed
+	}
+
+	if err := checkFlagValue(flag, value); err != nil {
+		err = fmt.Errorf("invalid argument %q for %q flag: %w", value, flagNames(flag), err)
+		check.errs = append(check.errs, err)
+	}
+
+	return consumed
+}
+
+// checkFlagValue returns an error if a value cannot be parsed as the type of a flag.
+// Values of types unknown to pflag (custom flag values) are not checked.
+func checkFlagValue(flag *pflag.Flag, value string) error {
+	typ := flag.Value.Type()
+
+	// Slices are lists of comma-separated values of their element type.
+	if elem, found := strings.CutSuffix(typ, "Slice"); found {
+		for _, value := range strings.Split(value, ",") {
+			if err := checkValue(elem, value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return checkValue(typ, value)
+}
+
+func checkValue(typ, value string) (err error) {
+	switch {
+	case typ == "bool":
+		_, err = strconv.ParseBool(value)
+	case typ == "count":
+		_, err = strconv.ParseInt(value, 0, 0)
+	case typ == "duration":
+		_, err = time.ParseDuration(value)
+	case typ == "ip":
+		if net.ParseIP(strings.TrimSpace(value)) == nil {
+			err = fmt.Errorf("invalid string being converted to IP address: %s", value)
+		}
+	case strings.HasPrefix(typ, "int"):
+		_, err = strconv.ParseInt(value, 0, bitSize(typ, "int"))
+	case strings.HasPrefix(typ, "uint"):
+		_, err = strconv.ParseUint(value, 0, bitSize(typ, "uint"))
+	case strings.HasPrefix(typ, "float"):
+		_, err = strconv.ParseFloat(value, bitSize(typ, "float"))
+	}
+
+	return err
+}
+
+// bitSize returns the size of numeric flag types, like int8, or 0 for int and uint.
+func bitSize(typ, prefix string) int {
+	size, _ := strconv.Atoi(strings.TrimPrefix(typ, prefix))
+	return size
+}
+
+// usageHint returns the usage line of a command, with the
+// positional argument expected under the cursor highlighted.
+func usageHint(cmd *cobra.Command, positional int) string {
+	// The root command of menus is not typed.
+	words := strings.Fields(cmd.CommandPath())[1:]
+	path := len(words)
+
+	if use := strings.Fields(cmd.Use); len(use) > 1 {
+		words = append(words, use[1:]...)
+	}
+
+	if positional < 0 || path >= len(words) {
+		return strings.Join(words, " ")
+	}
+
+	// Placeholders of positional arguments, the last one being possibly variadic.
+	var placeholders []int
+
+	for i := path; i < len(words); i++ {
+		if words[i] != "[flags]" {
+			placeholders = append(placeholders, i)
+		}
+	}
+
+	switch {
+	case positional < len(placeholders):
+		words[placeholders[positional]] = bold + words[placeholders[positional]] + boldReset
+	case len(placeholders) > 0 && strings.HasSuffix(words[placeholders[len(placeholders)-1]], "..."):
+		last := placeholders[len(placeholders)-1]
+		words[last] = bold + words[last] + boldReset
+	}
+
+	return strings.Join(words, " ")
+}
+
+// flagHint returns the names, type and description of a flag whose value is typed.
+func flagHint(flag *pflag.Flag) string {
+	name, usage := pflag.UnquoteUsage(flag)
+
+	hint := flagNames(flag)
+	if name != "" {
+		hint += " " + bold + name + boldReset
+	}
+
+	if usage != "" {
+		hint += "  " + usage
+	}
+
+	return hint
+}
+
+func flagNames(flag *pflag.Flag) string {
+	if flag.Shorthand != "" {
+		return "-" + flag.Shorthand + ", --" + flag.Name
+	}
+
+	return "--" + flag.Name
+}
+
+// currentStatement returns the last command of a pipeline or a list of commands
+// in the line, which is the one being typed. Its redirections are blanked out, so
+// that their targets are not counted as arguments. Operators are only recognized
+// outside quotes and, if escapes is true, when not escaped by a backslash.
+func currentStatement(line []rune, escapes bool) []rune {
+	const (
+		noTarget = iota
+		awaitTarget
+		inTarget
+	)
+
+	var (
+		statement []rune
+		quote     rune
+		target    = noTarget
+	)
+
+	// add appends a character of the statement, blanked if it is part of a redirection.
+	add := func(char rune) {
+		if target != noTarget {
+			char = ' '
+		}
+
+		statement = append(statement, char)
+	}
+
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+
+		switch {
+		case quote != 0:
+			if quote == '"' && char == '\\' && escapes && i+1 < len(line) {
+				add(char)
+				i++
+				char = line[i]
+			} else if char == quote {
+				quote = 0
+			}
+
+			add(char)
+
+		case char == '\\' && escapes:
+			if target == awaitTarget {
+				target = inTarget
+			}
+
+			add(char)
+
+			if i+1 < len(line) {
+				i++
+				add(line[i])
+			}
+
+		case char == ' ' || char == '\t':
+			if target == inTarget {
+				target = noTarget
+			}
+
+			add(char)
+
+		case char == '|' || char == ';' || char == '\n' || (char == '&' && !isRedirect(line, i+1)):
+			statement, target = statement[:0], noTarget
+
+		case isRedirect(line, i):
+			// A file descriptor number is part of the operator if it is the whole word.
+			digits := len(statement)
+			for digits > 0 && unicode.IsDigit(statement[digits-1]) {
+				digits--
+			}
+
+			if digits == 0 || unicode.IsSpace(statement[digits-1]) {
+				for k := digits; k < len(statement); k++ {
+					statement[k] = ' '
+				}
+			}
+
+			target = awaitTarget
+			add(char)
+
+			for i+1 < len(line) && strings.ContainsRune("<>&", line[i+1]) {
+				i++
+				add(line[i])
+			}
+
+			// The `>|` operator overrides the noclobber option.
+			if line[i] == '>' && i+1 < len(line) && line[i+1] == '|' {
+				i++
+				add(line[i])
+			}
+
+		default:
+			if target == awaitTarget {
+				target = inTarget
+			}
+
+			if char == '\'' || char == '"' {
+				quote = char
+			}
+
+			add(char)
+		}
+	}
+
+	return statement
+}
+
+// isRedirect returns true if the character at pos starts a redirection operator.
+func isRedirect(line []rune, pos int) bool {
+	if pos >= len(line) {
+		return false
+	}
+
+	switch line[pos] {
+	case '<', '>':
+		return true
+	case '&':
+		return pos+1 < len(line) && line[pos+1] == '>'
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package console
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestHint(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root"}
+		root.PersistentFlags().Bool("verbose", false, "verbose output")
+
+		upload := &cobra.Command{Use: "upload <local> <remote>", Run: func(*cobra.Command, []string) {}}
+		upload.Flags().IntP("port", "p", 22, "port of the `server`")
+		upload.Flags().Duration("timeout", 0, "timeout of the transfer")
+
+		connect := &cobra.Command{Use: "connect", Run: func(*cobra.Command, []string) {}}
+		connect.Flags().String("target", "", "host to connect to")
+		connect.MarkFlagRequired("target")
+
+		root.AddCommand(upload, connect)
+
+		return root
+	})
+	menu.resetPreRun()
+	console.SetAlias("up", "upload --port")
+	console.SetAlias("lsup", "ls | upload")
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"upl", []string{""}},
+		{"upload ", []string{"upload <local> <remote>"}},
+		{"upload a ", []string{"upload <local> <remote>"}},
+		{"upload --port ", []string{"-p, --port server  port of the server"}},
+		{"upload --timeout=1s", []string{"--timeout duration  timeout of the transfer"}},
+		{"upload --port x a", []string{"upload <local> <remote>", `invalid argument "x" for "-p, --port" flag`}},
+		{"upload -p8 --timeout 5 a", []string{"upload", `invalid argument "5" for "--timeout" flag`}},
+		{"upload --nope a", []string{"upload", "unknown flag: --nope"}},
+		{"upload --nop", []string{"upload <local> <remote>"}},
+		{"ls | upload -x a", []string{"upload", "unknown shorthand flag: 'x' in -x"}},
+		{"ls|upload -x a", []string{"upload", "unknown shorthand flag: 'x' in -x"}},
+		{"upload --nope a;connect ", []string{"connect", `required flag(s) "target" not set`}},
+		{"connect&&upload -x a", []string{"upload", "unknown shorthand flag: 'x' in -x"}},
+		{"connect --target 'a|b' --nope a", []string{"connect", "unknown flag: --nope"}},
+		{"upload a b > out.txt ", []string{"upload <local> <remote>"}},
+		{"upload a b 2>>err.log ", []string{"upload <local> <remote>"}},
+		{"upload a>out b&>/dev/null ", []string{"upload <local> <remote>"}},
+		{"up x a", []string{"upload", `invalid argument "x" for "-p, --port" flag`}},
+		{"lsup -x a", []string{"upload", "unknown shorthand flag: 'x' in -x"}},
+		{"connect ", []string{"connect", `required flag(s) "target" not set`}},
+		{"connect --target host", []string{"connect"}},
+		{"connect --target host --verbose -h", []string{"connect"}},
+	}
+
+	// The argument expected is highlighted, and redirections are not arguments.
+	for _, line := range []string{"upload a ", "upload a >&2 ", "upload >out a ", "upload a < in "} {
+		if hint := console.hint([]rune(line), len(line)); !strings.Contains(hint, bold+"<remote>"+boldReset) {
+			t.Errorf("hint(%q) = %q, want the <remote> argument highlighted", line, hint)
+		}
+	}
+
+	for _, test := range tests {
+		hint := console.hint([]rune(test.line), len(test.line))
+		lines := strings.Split(hint, "\n")
+
+		if len(lines) != len(test.want) {
+			t.Errorf("hint(%q) = %q, want %d lines", test.line, hint, len(test.want))
+			continue
+		}
+
+		for i, want := range test.want {
+			if !strings.Contains(strip(lines[i]), want) {
+				t.Errorf("hint(%q) line %d = %q, want %q", test.line, i, lines[i], want)
+			}
+		}
+	}
+
+	// Inherited flags and the help flag are not merged into the command.
+	upload, _, _ := menu.Command.Find([]string{"upload"})
+	if upload.Flags().Lookup("help") != nil || upload.Flags().Lookup("verbose") != nil {
+		t.Error("hint() modified the flags of the command")
+	}
+}
@@ -0,0 +1,43 @@
+package console
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chainreactors/tui/readline"
+)
+
+func TestHistoryStoreResults(t *testing.T) {
+	console := New("test")
+	menu := console.NewMenu("implant")
+
+	store, _ := readline.NewHistoryStore("")
+	menu.AddHistoryStore("history", store)
+
+	source := menu.histories["history"]
+
+	source.Write("ls")
+	menu.finishHistory(time.Second, false, nil)
+
+	source.Write("download /etc/shadow")
+	menu.finishHistory(time.Second, false, errors.New("denied"))
+
+	source.Write("sleep 60")
+	menu.finishHistory(time.Second, true, nil)
+
+	entries := store.Entries(readline.HistoryFilter{Menu: "implant"})
+	if len(entries) != 3 {
+		t.Fatalf("menu entries = %+v", entries)
+	}
+
+	for i, want := range []int{0, 1, statusInterrupted} {
+		if entries[i].Status != want || entries[i].Duration != time.Second {
+			t.Errorf("entry %d = %+v, want status %d", i, entries[i], want)
+		}
+	}
+
+	if other := store.Entries(readline.HistoryFilter{Menu: "main"}); len(other) != 0 {
+		t.Fatalf("entries of another menu = %+v", other)
+	}
+}
@@ -6,8 +6,9 @@ package console
 // On most systems, the following errors will be returned with keypresses:
 // - Linux/MacOS/Windows : Ctrl-C will return os.Interrupt.
 //
-// Many will want to use this to switch menus. Note that these interrupt errors only
-// work when the console is NOT currently executing a command, only when reading input.
+// Many will want to use this to switch menus. While a command runs, handlers are also
+// called for the signals interrupting it: OS signals for consoles on the process terminal,
+// and control keys (Ctrl-C, Ctrl-\) or carrier signals for remote ones (eg. errors.New("interrupt")).
 func (m *Menu) AddInterrupt(err error, handler func(c *Console)) {
 	m.mutex.RLock()
 	m.interruptHandlers[err] = handler
@@ -0,0 +1,444 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of a background job.
+type JobStatus int
+
+const (
+	JobRunning  JobStatus = iota // The job is still running.
+	JobDone                      // The job completed successfully.
+	JobFailed                    // The job returned an error.
+	JobCanceled                  // The job was canceled with Job.Cancel().
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	case JobCanceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrJobCanceled is the context cause of commands of a canceled job.
+var ErrJobCanceled = errors.New("job canceled")
+
+// errDetached is the context cause of a command calling ForegroundJob()
+// when the foreground job is detached again: the job is left running.
+var errDetached = errors.New("detached")
+
+// errJobCommands is returned when running commands in the background in a menu
+// without a command generator: jobs need their own command tree, since the
+// foreground one is reused by the next lines.
+var errJobCommands = errors.New("commands of this menu cannot run in the background: it has no command generator (see Menu.SetCommands)")
+
+// Job is a command line running in the background, either started with
+// a trailing `&`, or detached from the foreground while it was running
+// (with Ctrl-Z, or Console.DetachForeground()).
+//
+// Commands of lines started with `&` have their output buffered in the job,
+// which is printed when the job is brought to the foreground. Detached commands
+// go on printing where they did.
+type Job struct {
+	ID      int       // Job number, as used by `fg` and friends.
+	Line    string    // Command line of the job.
+	Menu    string    // Name of the menu in which the job was started.
+	Started time.Time // When the job was started.
+
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+	output *jobOutput
+
+	mutex      sync.Mutex
+	status     JobStatus
+	err        error
+	ended      time.Time
+	foreground bool
+}
+
+// Status returns the current status of the job.
+func (j *Job) Status() JobStatus {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.status
+}
+
+// Err returns the error of a failed or canceled job.
+func (j *Job) Err() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.err
+}
+
+// Elapsed returns how long the job has been (or was) running.
+func (j *Job) Elapsed() time.Duration {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.status == JobRunning {
+		return time.Since(j.Started)
+	}
+
+	return j.ended.Sub(j.Started)
+}
+
+// Done returns a channel closed when the job is finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Output returns the output of the job not yet printed to the terminal.
+func (j *Job) Output() string {
+	return j.output.String()
+}
+
+// Cancel cancels the context of the job commands. Like for interrupted
+// commands, those must return on their own when their context is done.
+func (j *Job) Cancel() {
+	j.cancel(ErrJobCanceled)
+}
+
+// Jobs returns the background jobs of the console, running or finished,
+// ordered by ID. Finished jobs are kept until brought to the foreground,
+// so that their output can be read, or until removed with CleanJobs().
+func (c *Console) Jobs() []*Job {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+
+	jobs := make([]*Job, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	return jobs
+}
+
+// Job returns a background job by ID, or nil if there is none.
+func (c *Console) Job(id int) *Job {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+
+	return c.jobs[id]
+}
+
+// CleanJobs removes all finished jobs from the job list.
+func (c *Console) CleanJobs() {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+
+	for id, job := range c.jobs {
+		if job.Status() != JobRunning {
+			delete(c.jobs, id)
+		}
+	}
+}
+
+// CancelJob cancels a running background job.
+func (c *Console) CancelJob(id int) error {
+	job := c.Job(id)
+	if job == nil {
+		return fmt.Errorf("no such job: %d", id)
+	}
+
+	if job.Status() != JobRunning {
+		return fmt.Errorf("job %d is already %s", id, job.Status())
+	}
+
+	job.Cancel()
+
+	return nil
+}
+
+// ForegroundJob brings a background job to the foreground: its buffered output
+// is printed, and the call blocks until the job is done, returning its error.
+// The job is then removed from the job list.
+//
+// This is meant to be called by a console command, with its context: if the
+// command is interrupted, the job is canceled, and if the command is detached
+// (Ctrl-Z), the job goes back to the background.
+func (c *Console) ForegroundJob(ctx context.Context, id int) error {
+	job := c.Job(id)
+	if job == nil {
+		return fmt.Errorf("no such job: %d", id)
+	}
+
+	job.mutex.Lock()
+	if job.foreground {
+		job.mutex.Unlock()
+		return fmt.Errorf("job %d is already in the foreground", id)
+	}
+	job.foreground = true
+	job.mutex.Unlock()
+
+	c.jobsMutex.Lock()
+	c.foreground = job
+	c.jobsMutex.Unlock()
+
+	job.output.attach(c.terminal.Out)
+
+	defer func() {
+		job.output.detach()
+
+		job.mutex.Lock()
+		job.foreground = false
+		job.mutex.Unlock()
+
+		c.jobsMutex.Lock()
+		c.foreground = nil
+		c.jobsMutex.Unlock()
+	}()
+
+	select {
+	case <-job.done:
+		c.removeJob(job)
+
+		return job.Err()
+
+	case <-ctx.Done():
+		cause := context.Cause(ctx)
+		if errors.Is(cause, errDetached) {
+			fmt.Fprintf(c.terminal.Out, "\n[%d] %s\n", job.ID, job.Line)
+			return nil
+		}
+
+		job.cancel(cause)
+
+		return cause
+	}
+}
+
+// DetachForeground detaches the command currently running in the foreground
+// into a background job, and gives the prompt back to the user. This is what
+// Ctrl-Z does, on systems that have job control signals.
+func (c *Console) DetachForeground() {
+	select {
+	case c.detach <- struct{}{}:
+	default:
+	}
+}
+
+// detachRequests returns the channel of DetachForeground() calls,
+// after dropping those made when nothing was running.
+func (c *Console) detachRequests() <-chan struct{} {
+	select {
+	case <-c.detach:
+	default:
+	}
+
+	return c.detach
+}
+
+// startJob runs a `&`-terminated list of steps in a background job.
+func (c *Console) startJob(ctx context.Context, menu *Menu, steps []step, hooks bool) (*Job, error) {
+	if menu.cmds == nil {
+		return nil, errJobCommands
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	job := c.newJob(menu, formatSteps(steps), cancel)
+
+	// The menu copy is created here, so that its commands
+	// are not those of a later line run in the foreground.
+	jobMenu := menu.jobMenu(job)
+
+	// In the job, the steps run in the "foreground".
+	steps = append([]step(nil), steps...)
+	for i := range steps {
+		steps[i].background = false
+	}
+
+	go func() {
+		_, err := c.executeLine(ctx, jobMenu, steps, true, hooks)
+		cancel(nil)
+
+		c.finishJob(job, err)
+	}()
+
+	return job, nil
+}
+
+// detachCommand turns a command running in the foreground into a background job,
+// which it returns, or nil if the command was itself waiting for a job.
+func (c *Console) detachCommand(ctx context.Context, cancel context.CancelCauseFunc, menu *Menu, args []string) *Job {
+	c.jobsMutex.Lock()
+	foreground := c.foreground
+	c.jobsMutex.Unlock()
+
+	// The command is waiting for a job: send this one back instead.
+	if foreground != nil {
+		cancel(errDetached)
+		return nil
+	}
+
+	job := c.newJob(menu, formatSteps([]step{{pipe: &pipeline{stages: [][]string{args}}}}), cancel)
+
+	go func() {
+		<-ctx.Done()
+
+		err := context.Cause(ctx)
+		if errors.Is(err, context.Canceled) {
+			err = nil
+		}
+
+		c.finishJob(job, err)
+	}()
+
+	fmt.Fprintf(c.terminal.Out, "\n[%d] %s\n", job.ID, job.Line)
+
+	return job
+}
+
+func (c *Console) newJob(menu *Menu, line string, cancel context.CancelCauseFunc) *Job {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+
+	// Like shells, number jobs after the highest current one.
+	id := 1
+	for existing := range c.jobs {
+		if existing >= id {
+			id = existing + 1
+		}
+	}
+
+	job := &Job{
+		ID:      id,
+		Line:    line,
+		Menu:    menu.Name(),
+		Started: time.Now(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		output:  &jobOutput{},
+		status:  JobRunning,
+	}
+
+	if c.jobs == nil {
+		c.jobs = make(map[int]*Job)
+	}
+
+	c.jobs[id] = job
+
+	return job
+}
+
+// finishJob records the result of a job, and notifies
+// the user if the job is not waited for in the foreground.
+func (c *Console) finishJob(job *Job, err error) {
+	job.mutex.Lock()
+
+	job.ended = time.Now()
+	job.err = err
+
+	switch {
+	case errors.Is(err, ErrJobCanceled):
+		job.status = JobCanceled
+	case err != nil:
+		job.status = JobFailed
+	default:
+		job.status = JobDone
+	}
+
+	foreground := job.foreground
+
+	job.mutex.Unlock()
+
+	close(job.done)
+
+	if foreground {
+		return
+	}
+
+	if job.status == JobFailed {
+		c.TransientPrintf("[%d] %s  %s: %s\n", job.ID, job.status, job.Line, err)
+	} else {
+		c.TransientPrintf("[%d] %s  %s\n", job.ID, job.status, job.Line)
+	}
+}
+
+func (c *Console) removeJob(job *Job) {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+
+	if c.jobs[job.ID] == job {
+		delete(c.jobs, job.ID)
+	}
+}
+
+// jobOutput buffers the output of a job, or writes
+// it to the terminal while the job is in the foreground.
+type jobOutput struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+	live  io.Writer
+}
+
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.live != nil {
+		return o.live.Write(p)
+	}
+
+	return o.buf.Write(p)
+}
+
+func (o *jobOutput) String() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.buf.String()
+}
+
+// attach flushes the buffered output to w, and writes directly to it from now on.
+func (o *jobOutput) attach(w io.Writer) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	w.Write(o.buf.Bytes())
+	o.buf.Reset()
+
+	o.live = w
+}
+
+func (o *jobOutput) detach() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.live = nil
+}
+
+// isDetachSignal returns true if sig detaches the foreground command.
+func isDetachSignal(sig os.Signal) bool {
+	if sig == detachSignal {
+		return true
+	}
+
+	for _, detach := range detachSignals {
+		if sig == detach {
+			return true
+		}
+	}
+
+	return false
+}
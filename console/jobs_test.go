@@ -0,0 +1,186 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newJobsTestMenu(t *testing.T) (*Console, *Menu, chan struct{}) {
+	t.Helper()
+
+	console := New("test")
+	menu := console.ActiveMenu()
+	started := make(chan struct{}, 1)
+
+	menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+		root.AddCommand(&cobra.Command{
+			Use: "say",
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Fprintln(cmd.OutOrStdout(), args[0])
+			},
+		})
+		root.AddCommand(&cobra.Command{
+			Use: "block",
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				started <- struct{}{}
+				<-cmd.Context().Done()
+				return context.Cause(cmd.Context())
+			},
+		})
+		return root
+	})
+
+	return console, menu, started
+}
+
+func waitJob(t *testing.T, job *Job) {
+	t.Helper()
+
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job %d did not finish", job.ID)
+	}
+}
+
+func TestBackgroundJob(t *testing.T) {
+	console, menu, _ := newJobsTestMenu(t)
+
+	if err := menu.RunCommandLine(context.Background(), "say one && say two | grep t &"); err != nil {
+		t.Fatalf("RunCommandLine error = %v", err)
+	}
+
+	jobs := console.Jobs()
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(jobs))
+	}
+
+	job := jobs[0]
+	if job.ID != 1 || job.Line != "say one && say two | grep t" {
+		t.Fatalf("job = %d %q", job.ID, job.Line)
+	}
+
+	waitJob(t, job)
+
+	if job.Status() != JobDone {
+		t.Fatalf("job status = %s (%v), want done", job.Status(), job.Err())
+	}
+
+	if got, want := job.Output(), "one\ntwo\n"; got != want {
+		t.Fatalf("job output = %q, want %q", got, want)
+	}
+
+	if err := console.ForegroundJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("ForegroundJob error = %v", err)
+	}
+
+	if len(console.Jobs()) != 0 {
+		t.Fatal("finished job still listed after being brought to the foreground")
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	console, menu, started := newJobsTestMenu(t)
+
+	if err := menu.RunCommandLine(context.Background(), "block &"); err != nil {
+		t.Fatalf("RunCommandLine error = %v", err)
+	}
+
+	<-started
+
+	job := console.Job(1)
+	if job == nil || job.Status() != JobRunning {
+		t.Fatal("job 1 is not running")
+	}
+
+	if err := console.CancelJob(1); err != nil {
+		t.Fatalf("CancelJob error = %v", err)
+	}
+
+	waitJob(t, job)
+
+	if job.Status() != JobCanceled || !errors.Is(job.Err(), ErrJobCanceled) {
+		t.Fatalf("job status = %s (%v), want canceled", job.Status(), job.Err())
+	}
+}
+
+func TestDetachForeground(t *testing.T) {
+	console, menu, started := newJobsTestMenu(t)
+
+	done := make(chan error)
+	go func() { done <- menu.RunCommandLine(context.Background(), "block; say after") }()
+
+	<-started
+	console.DetachForeground()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunCommandLine error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command was not detached")
+	}
+
+	job := console.Job(1)
+	if job == nil || job.Status() != JobRunning || job.Line != "block" {
+		t.Fatalf("detached job = %+v", job)
+	}
+
+	job.Cancel()
+	waitJob(t, job)
+
+	if job.Status() != JobCanceled {
+		t.Fatalf("job status = %s, want canceled", job.Status())
+	}
+}
+
+func TestJobsWithoutCommandGenerator(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	// The command tree is set directly: there is none to generate for jobs.
+	menu.Command.AddCommand(&cobra.Command{
+		Use: "wait",
+		Run: func(*cobra.Command, []string) {
+			started <- struct{}{}
+			<-release
+		},
+	})
+	menu.Command.AddCommand(&cobra.Command{Use: "say", Run: func(*cobra.Command, []string) {}})
+
+	if err := menu.RunCommandLine(context.Background(), "say &"); !errors.Is(err, errJobCommands) {
+		t.Fatalf("RunCommandLine error = %v, want %v", err, errJobCommands)
+	}
+
+	done := make(chan error)
+	go func() { done <- menu.RunCommandLine(context.Background(), "wait") }()
+
+	<-started
+	console.DetachForeground()
+
+	select {
+	case err := <-done:
+		t.Fatalf("command was detached (error = %v)", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("RunCommandLine error = %v", err)
+	}
+
+	if jobs := console.Jobs(); len(jobs) != 0 {
+		t.Fatalf("got %d jobs, want none", len(jobs))
+	}
+}
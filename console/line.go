@@ -43,20 +43,26 @@ func (c *Console) parse(input string) (args []string, err error) {
 		return nil, err
 	}
 
+	return c.splitLine(parsedLine.String())
+}
+
+// splitLine splits an already parsed (comment-free) line into words,
+// according to the console escape mode.
+func (c *Console) splitLine(line string) (args []string, err error) {
 	if c.getEscapeMode() == EscapeLiteral {
-		args, _, err = split(parsedLine.String(), false, EscapeLiteral)
+		args, _, err = split(line, false, "", EscapeLiteral)
 
 		return args, err
 	}
 
-	return shellquote.Split(parsedLine.String())
+	return shellquote.Split(line)
 }
 
 // acceptMultiline determines if the line just accepted is complete (in which case
 // we should execute it), or incomplete (in which case we must read in multiline).
 func (c *Console) acceptMultiline(line []rune) (accept bool) {
 	// Errors are either: unterminated quotes, or unterminated escapes.
-	_, _, err := split(string(line), false, c.getEscapeMode())
+	_, _, err := split(string(line), false, "", c.getEscapeMode())
 	if err == nil {
 		return true
 	}
@@ -78,8 +84,9 @@ func (c *Console) acceptMultiline(line []rune) (accept bool) {
 
 // split has been copied from go-shellquote and slightly modified so as to also
 // return the remainder when the parsing failed because of an unterminated quote.
-// In EscapeLiteral mode, backslashes are ordinary characters.
-func split(input string, hl bool, mode EscapeMode) (words []string, remainder string, err error) {
+// In EscapeLiteral mode, backslashes are ordinary characters. When highlighting
+// the line (hl), quoted words are colored with the quote sequence.
+func split(input string, hl bool, quote string, mode EscapeMode) (words []string, remainder string, err error) {
 	var buf bytes.Buffer
 	words = make([]string, 0)
 
@@ -130,7 +137,7 @@ func split(input string, hl bool, mode EscapeMode) (words []string, remainder st
 
 		var word string
 
-		word, input, err = splitWord(input, &buf, hl, mode)
+		word, input, err = splitWord(input, &buf, hl, quote, mode)
 		if err != nil {
 			remainder = input
 			return words, remainder, err
@@ -144,7 +151,7 @@ func split(input string, hl bool, mode EscapeMode) (words []string, remainder st
 
 // splitWord has been modified to return the remainder of the input (the part that has not been
 // added to the buffer) even when an error is returned.
-func splitWord(input string, buf *bytes.Buffer, hl bool, mode EscapeMode) (word string, remainder string, err error) {
+func splitWord(input string, buf *bytes.Buffer, hl bool, quote string, mode EscapeMode) (word string, remainder string, err error) {
 	buf.Reset()
 
 raw:
@@ -208,13 +215,13 @@ single:
 		i := strings.IndexRune(input, singleChar)
 		if i == -1 {
 			if hl {
-				input = buf.String() + seqFgYellow + string(singleChar) + input
+				input = buf.String() + quote + string(singleChar) + input
 			}
 			return "", input, errUnterminatedSingleQuote
 		}
 		// Catch up opening quote
 		if hl {
-			buf.WriteString(seqFgYellow)
+			buf.WriteString(quote)
 			buf.WriteRune(singleChar)
 		}
 
@@ -237,7 +244,7 @@ double:
 			if c == doubleChar {
 				// Catch up opening quote
 				if hl {
-					buf.WriteString(seqFgYellow)
+					buf.WriteString(quote)
 					buf.WriteRune(c)
 				}
 
@@ -266,7 +273,7 @@ double:
 		}
 
 		if hl {
-			input = buf.String() + seqFgYellow + string(doubleChar) + input
+			input = buf.String() + quote + string(doubleChar) + input
 		}
 
 		return "", input, errUnterminatedDoubleQuote
@@ -51,6 +51,9 @@ type Menu struct {
 	historyNames []string
 	histories    map[string]readline.History
 
+	// The background job running this (copied) menu, if any.
+	job *Job
+
 	// Concurrency management
 	mutex *sync.RWMutex
 }
@@ -124,6 +127,15 @@ func (m *Menu) AddHistorySourceFile(name string, filepath string) {
 	m.histories[name], _ = readline.NewHistoryFromFile(filepath)
 }
 
+// AddHistoryStore adds a view of a history store as a history source of the menu:
+// the lines written to it are recorded in the store with the menu name, along with
+// the duration and exit status of their commands, and only the entries of the menu
+// are accessible from it. A store can thus be shared by several menus.
+// On the first call to this function, the default in-memory history source is removed.
+func (m *Menu) AddHistoryStore(name string, store *readline.HistoryStore) {
+	m.AddHistorySource(name, store.View(readline.HistoryFilter{Menu: m.name}))
+}
+
 // DeleteHistorySource removes a history source from the menu.
 // This normally should only be used in two cases:
 // - You want to replace the default in-memory history with another one.
@@ -291,11 +303,74 @@ func (m *Menu) resetPreRun() {
 	// Hide commands that are not available
 	m.hideFilteredCommands(m.Command)
 
+	// Global flags provided by the console.
+	m.console.bindOutputFlag(m.Command)
+
 	// Menu setup
-	m.resetCmdOutput()             // Reset or adjust any buffered command output.
+	m.resetCmdOutput() // Reset or adjust any buffered command output.
+
+	// Background jobs print to their own output, and leave the prompt alone.
+	if m.job != nil {
+		m.Command.SetOut(m.job.output)
+		m.Command.SetErr(m.job.output)
+
+		return
+	}
+
+	// Cobra defaults to the process stdout, which is not where
+	// the user of a remote console (or any other stream) is.
+	if m.console.input != nil {
+		m.Command.SetOut(m.console.terminal.Out)
+		m.Command.SetErr(m.console.terminal.Err)
+	}
+
 	m.prompt.bind(m.console.shell) // Prompt binding
 }
 
+// jobMenu returns a copy of the menu to run a background job in: it has its own
+// command tree (so that the menu can go on running other commands), printing to
+// the job output. The menu must have a command generator.
+func (m *Menu) jobMenu(job *Job) *Menu {
+	menu := &Menu{
+		name:                m.name,
+		prompt:              m.prompt,
+		console:             m.console,
+		interruptHandlers:   make(map[error]func(c *Console)),
+		out:                 bytes.NewBuffer(nil),
+		cmds:                m.cmds,
+		errFilteredTemplate: m.errFilteredTemplate,
+		histories:           make(map[string]readline.History),
+		job:                 job,
+		mutex:               &sync.RWMutex{},
+		ErrorHandler: func(err error) error {
+			fmt.Fprintf(job.output, "Error: %s\n", err)
+			return nil
+		},
+	}
+
+	menu.resetPreRun()
+
+	return menu
+}
+
+// output returns where the menu prints command results.
+func (m *Menu) output() io.Writer {
+	if m.job != nil {
+		return m.job.output
+	}
+
+	return m.console.output()
+}
+
+// errOutput returns where the menu prints command errors.
+func (m *Menu) errOutput() io.Writer {
+	if m.job != nil {
+		return m.job.output
+	}
+
+	return m.console.terminal.Err
+}
+
 // hide commands that are filtered so that they are not
 // shown in the help strings or proposed as completions.
 func (m *Menu) hideFilteredCommands(root *cobra.Command) {
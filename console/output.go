@@ -0,0 +1,426 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+	"github.com/spf13/cobra"
+)
+
+// Output formats provided by the console. Others can be added with SetRenderer().
+//
+// In the user terminal, OutputTable displays tables in the interactive table of
+// the tui package (paged and filtered with the keyboard), and records in its
+// key-value tables. Elsewhere (pipes, redirections, background jobs), it writes
+// tables with aligned columns and records as key-value blocks.
+const (
+	OutputTable = "table" // Human-readable tables and key-value blocks.
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputCSV   = "csv"
+)
+
+// outputFlag is the name of the global flag selecting the output format.
+const outputFlag = "output"
+
+// Table is a tabular command result, ie. a list of rows with the same columns.
+// Commands can return one to control the columns, or any list of structs or maps.
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]any
+}
+
+// Record is a single object command result, displayed as a key-value block.
+// Commands can return one to control the keys and their order, or any struct or map.
+type Record struct {
+	Title  string
+	Keys   []string
+	Values map[string]any
+}
+
+// Result is a value returned by a command, with the views renderers use.
+type Result struct {
+	Value  any     // As returned by the command.
+	Table  *Table  // Set when the value is a list.
+	Record *Record // Set when the value is an object.
+}
+
+// RenderTarget is where a result is rendered.
+type RenderTarget struct {
+	io.Writer
+
+	// Interactive is true when the writer is the user terminal, rather than
+	// a pipe, a file or a background job: renderers may then use interactive
+	// widgets (eg. a table the user can scroll and filter).
+	Interactive bool
+
+	ctx      context.Context  // Canceled when the command is interrupted.
+	terminal *rlterm.Terminal // The user terminal, if interactive.
+}
+
+// Renderer renders a command result in an output format.
+type Renderer func(target RenderTarget, result *Result) error
+
+// SetRenderer registers the renderer of an output format, or replaces an
+// existing one, such as the OutputTable one to use other table widgets.
+// A nil renderer removes the format.
+func (c *Console) SetRenderer(format string, renderer Renderer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if renderer == nil {
+		delete(c.renderers, format)
+		return
+	}
+
+	c.renderers[format] = renderer
+}
+
+// Formats returns the names of the available output formats, sorted.
+func (c *Console) Formats() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	formats := make([]string, 0, len(c.renderers))
+	for format := range c.renderers {
+		formats = append(formats, format)
+	}
+
+	sort.Strings(formats)
+
+	return formats
+}
+
+// EnableOutputFlag adds a global --output flag to the commands of all menus,
+// which selects the format used by Render(): defaultFormat (OutputTable if
+// empty) when the flag is not used. Menus whose root command already has
+// an --output flag keep theirs.
+func (c *Console) EnableOutputFlag(defaultFormat string) {
+	if defaultFormat == "" {
+		defaultFormat = OutputTable
+	}
+
+	c.mutex.Lock()
+	c.outputFlag = true
+	c.defaultOutput = defaultFormat
+	c.mutex.Unlock()
+
+	for _, menu := range c.menus {
+		c.bindOutputFlag(menu.Command)
+	}
+}
+
+// RunResult adapts a command function returning a result into a cobra RunE
+// function, rendering the result with Render() when there is no error.
+func (c *Console) RunResult(run func(cmd *cobra.Command, args []string) (any, error)) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		value, err := run(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		return c.Render(cmd, value)
+	}
+}
+
+// Render writes a command result to the command output, in the format
+// selected with the --output flag (see EnableOutputFlag), or the default
+// one. Values are converted to JSON first, so that their fields have the
+// same names and order in all formats: struct fields use their json tags.
+func (c *Console) Render(cmd *cobra.Command, value any) error {
+	format := c.outputFormat(cmd)
+
+	c.mutex.RLock()
+	renderer, found := c.renderers[format]
+	c.mutex.RUnlock()
+
+	if !found {
+		return fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(c.Formats(), ", "))
+	}
+
+	result, err := NewResult(value)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	target := RenderTarget{Writer: out, ctx: cmd.Context()}
+
+	if c.isTerminalOutput(out) {
+		target.Interactive, target.terminal = true, c.terminal
+	}
+
+	return renderer(target, result)
+}
+
+// NewResult returns the views of a value for renderers: returned tables and
+// records are used as is, lists become tables and objects become records.
+func NewResult(value any) (*Result, error) {
+	result := &Result{Value: value}
+
+	switch v := value.(type) {
+	case nil:
+		return result, nil
+	case *Table:
+		result.Table = v
+		return result, nil
+	case Table:
+		result.Table = &v
+		return result, nil
+	case *Record:
+		result.Record = v
+		return result, nil
+	case Record:
+		result.Record = &v
+		return result, nil
+	}
+
+	model, err := orderedModel(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := model.(type) {
+	case orderedObject:
+		result.Record = data.record()
+	case []any:
+		result.Table = listTable(data)
+	}
+
+	return result, nil
+}
+
+func (c *Console) bindOutputFlag(root *cobra.Command) {
+	c.mutex.RLock()
+	enabled, format := c.outputFlag, c.defaultOutput
+	c.mutex.RUnlock()
+
+	if !enabled || root == nil || root.PersistentFlags().Lookup(outputFlag) != nil {
+		return
+	}
+
+	usage := fmt.Sprintf("output format (%s)", strings.Join(c.Formats(), "|"))
+	root.PersistentFlags().String(outputFlag, format, usage)
+
+	root.RegisterFlagCompletionFunc(outputFlag, func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return c.Formats(), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func (c *Console) outputFormat(cmd *cobra.Command) string {
+	c.mutex.RLock()
+	enabled, format := c.outputFlag, c.defaultOutput
+	c.mutex.RUnlock()
+
+	if !enabled {
+		return OutputTable
+	}
+
+	if flag := cmd.Flag(outputFlag); flag != nil && flag.Value.String() != "" {
+		return flag.Value.String()
+	}
+
+	return format
+}
+
+// isTerminalOutput returns true if w is the console terminal,
+// rather than the output captured in a pipeline or a job.
+func (c *Console) isTerminalOutput(w io.Writer) bool {
+	switch w.(type) {
+	case *lockedWriter, *jobOutput:
+		return false
+	}
+
+	return w == c.terminal.Out && c.terminal.Control.IsTerminal()
+}
+
+// MarshalJSON encodes the table as a list of objects.
+func (t *Table) MarshalJSON() ([]byte, error) {
+	rows := make([]orderedObject, len(t.Rows))
+
+	for i, row := range t.Rows {
+		object := make(orderedObject, len(t.Columns))
+		for col, name := range t.Columns {
+			object[col] = objectField{Key: name, Value: cell(row, col)}
+		}
+
+		rows[i] = object
+	}
+
+	return json.Marshal(rows)
+}
+
+// MarshalJSON encodes the record as an object, with keys in order.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	object := make(orderedObject, 0, len(r.Keys))
+	for _, key := range r.Keys {
+		object = append(object, objectField{Key: key, Value: r.Values[key]})
+	}
+
+	return json.Marshal(object)
+}
+
+func cell(row []any, col int) any {
+	if col < len(row) {
+		return row[col]
+	}
+
+	return nil
+}
+
+// orderedObject is a JSON object decoded with its keys in order.
+type orderedObject []objectField
+
+type objectField struct {
+	Key   string
+	Value any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (o orderedObject) record() *Record {
+	record := &Record{Values: make(map[string]any, len(o))}
+
+	for _, field := range o {
+		record.Keys = append(record.Keys, field.Key)
+		record.Values[field.Key] = field.Value
+	}
+
+	return record
+}
+
+// listTable makes a table out of a list: objects have their keys as columns
+// (in order of appearance), and other values are rows of a "value" column.
+func listTable(list []any) *Table {
+	table := &Table{}
+	columns := make(map[string]int)
+
+	for _, elem := range list {
+		object, ok := elem.(orderedObject)
+		if !ok {
+			object = orderedObject{{Key: "value", Value: elem}}
+		}
+
+		row := make([]any, len(table.Columns))
+
+		for _, field := range object {
+			col, found := columns[field.Key]
+			if !found {
+				col = len(table.Columns)
+				columns[field.Key] = col
+				table.Columns = append(table.Columns, field.Key)
+				row = append(row, nil)
+			}
+
+			row[col] = field.Value
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
+
+// orderedModel converts a value to its JSON data model,
+// keeping the keys of objects in the order they are encoded.
+func orderedModel(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return decodeOrdered(decoder)
+}
+
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		object := orderedObject{}
+
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			object = append(object, objectField{Key: key.(string), Value: value})
+		}
+
+		_, err = decoder.Token()
+
+		return object, err
+
+	case '[':
+		list := []any{}
+
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, value)
+		}
+
+		_, err = decoder.Token()
+
+		return list, err
+
+	default:
+		return nil, errors.New("unexpected JSON delimiter " + delim.String())
+	}
+}
@@ -0,0 +1,211 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+	"github.com/spf13/cobra"
+)
+
+type testSession struct {
+	ID       int      `json:"id"`
+	Host     string   `json:"host"`
+	Tags     []string `json:"tags,omitempty"`
+	internal string
+}
+
+func TestNewResult(t *testing.T) {
+	sessions := []testSession{{ID: 1, Host: "win01", Tags: []string{"dc"}}, {ID: 2, Host: "lin02"}}
+
+	result, err := NewResult(sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Table == nil || !reflect.DeepEqual(result.Table.Columns, []string{"id", "host", "tags"}) {
+		t.Fatalf("table = %+v, want columns id, host, tags", result.Table)
+	}
+	if got := formatCell(result.Table.Rows[1][1]); got != "lin02" {
+		t.Fatalf("row 2 host = %q, want lin02", got)
+	}
+	if got := formatCell(cell(result.Table.Rows[1], 2)); got != "" {
+		t.Fatalf("row 2 tags = %q, want empty", got)
+	}
+
+	result, err = NewResult(sessions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Record == nil || !reflect.DeepEqual(result.Record.Keys, []string{"id", "host", "tags"}) {
+		t.Fatalf("record = %+v, want keys id, host, tags", result.Record)
+	}
+	if got := formatCell(result.Record.Values["tags"]); got != `["dc"]` {
+		t.Fatalf("record tags = %q", got)
+	}
+
+	result, err = NewResult([]string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Table == nil || !reflect.DeepEqual(result.Table.Columns, []string{"value"}) || len(result.Table.Rows) != 2 {
+		t.Fatalf("table of strings = %+v", result.Table)
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	table := &Table{
+		Columns: []string{"name", "os"},
+		Rows:    [][]any{{"win01", "windows"}, {"lin, 02", nil}},
+	}
+	record := &Record{Keys: []string{"z", "a"}, Values: map[string]any{"a": 1, "z": true}}
+
+	tests := []struct {
+		renderer Renderer
+		value    any
+		want     string
+	}{
+		{renderTable, table, "name     os\nwin01    windows\nlin, 02  \n"},
+		{renderTable, record, "z:  true\na:  1\n"},
+		{renderTable, "plain", "plain\n"},
+		{renderJSON, table, "[\n  {\n    \"name\": \"win01\",\n    \"os\": \"windows\"\n  },\n  {\n    \"name\": \"lin, 02\",\n    \"os\": null\n  }\n]\n"},
+		{renderJSON, record, "{\n  \"z\": true,\n  \"a\": 1\n}\n"},
+		{renderYAML, table, "- name: win01\n  os: windows\n- name: lin, 02\n  os: null\n"},
+		{renderYAML, record, "z: true\na: 1\n"},
+		{renderYAML, map[string]string{"v": "true"}, "v: \"true\"\n"},
+		{renderCSV, table, "name,os\nwin01,windows\n\"lin, 02\",\n"},
+		{renderCSV, record, "z,a\ntrue,1\n"},
+	}
+
+	for _, tt := range tests {
+		result, err := NewResult(tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		if err := tt.renderer(RenderTarget{Writer: &out}, result); err != nil {
+			t.Fatalf("render %v error = %v", tt.value, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("render %v =\n%q\nwant\n%q", tt.value, out.String(), tt.want)
+		}
+	}
+}
+
+func TestOutputFlag(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+		root.AddCommand(&cobra.Command{
+			Use: "sessions",
+			RunE: console.RunResult(func(*cobra.Command, []string) (any, error) {
+				return []testSession{{ID: 1, Host: "win01"}, {ID: 2, Host: "lin02"}}, nil
+			}),
+		})
+		return root
+	})
+
+	console.EnableOutputFlag(OutputJSON)
+
+	var interactive []bool
+	console.SetRenderer("names", func(target RenderTarget, result *Result) error {
+		interactive = append(interactive, target.Interactive)
+		for _, row := range result.Table.Rows {
+			target.Write([]byte(formatCell(row[1]) + "\n"))
+		}
+		return nil
+	})
+
+	if got, want := console.Formats(), []string{"csv", "json", "names", "table", "yaml"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Formats() = %v, want %v", got, want)
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	run := func(line string) string {
+		t.Helper()
+		if err := menu.RunCommandLine(context.Background(), line+" > "+out); err != nil {
+			t.Fatalf("RunCommandLine(%q) error = %v", line, err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if got, want := run("sessions | jq -r .[].host"), "win01\nlin02\n"; got != want {
+		t.Fatalf("default output = %q, want %q", got, want)
+	}
+	if got, want := run("sessions --output csv"), "id,host\n1,win01\n2,lin02\n"; got != want {
+		t.Fatalf("csv output = %q, want %q", got, want)
+	}
+	if got, want := run("sessions --output names"), "win01\nlin02\n"; got != want {
+		t.Fatalf("custom output = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(interactive, []bool{false}) {
+		t.Fatalf("redirected output interactive = %v, want false", interactive)
+	}
+
+	if err := menu.RunCommandLine(context.Background(), "sessions --output xml"); err == nil {
+		t.Fatal("expected an error for an unknown output format")
+	}
+}
+
+// TestRenderTableInteractive checks that tables and records are displayed with the
+// widgets of the tui package in the user terminal, and as plain text elsewhere.
+func TestRenderTableInteractive(t *testing.T) {
+	table := &Table{Columns: []string{"name", "os"}, Rows: [][]any{{"win01", "windows"}}}
+	record := &Record{Keys: []string{"host"}, Values: map[string]any{"host": "win01"}}
+
+	tests := []struct {
+		name        string
+		value       any
+		interactive bool
+		want        []string // Parts of the output.
+		exact       bool
+	}{
+		{"static table", table, false, []string{"name   os\nwin01  windows\n"}, true},
+		{"static record", record, false, []string{"host:  win01\n"}, true},
+		// The interactive table hides the cursor and shows its pager, until enter is pressed.
+		{"interactive table", table, true, []string{"\x1b[?25l", " name   os ", " win01  windows ", " 1/1 "}, false},
+		{"key-value table", record, true, []string{" host    win01 ", "───"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			target := RenderTarget{Writer: &out, Interactive: test.interactive}
+			if test.interactive {
+				target.terminal = rlterm.Stream(strings.NewReader("\r"), &out, &out, rlterm.NewControl(true, 80, 24))
+			}
+
+			result, err := NewResult(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := renderTable(target, result); err != nil {
+				t.Fatalf("renderTable() error = %v", err)
+			}
+
+			got := out.String()
+			if test.exact && got != test.want[0] {
+				t.Fatalf("renderTable() = %q, want %q", got, test.want[0])
+			}
+
+			for _, want := range test.want {
+				if !strings.Contains(got, want) {
+					t.Fatalf("renderTable() = %q, want %q in it", got, want)
+				}
+			}
+		})
+	}
+}
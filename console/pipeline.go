@@ -0,0 +1,354 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/kballard/go-shellquote"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// pipeline is a parsed input line: one or more commands whose output
+// is fed to the next one, the last of which may be redirected to a file.
+type pipeline struct {
+	stages   [][]string
+	redirect string
+	append   bool
+}
+
+// errNoHostAccess is returned by redirections in consoles without host access.
+var errNoHostAccess = errors.New("output redirections are disabled in this console")
+
+func (p *pipeline) empty() bool {
+	return p == nil || len(p.stages) == 0 || len(p.stages[0]) == 0
+}
+
+// simple reports whether the line is a single command printing
+// to the terminal, which runs exactly like it did before pipes.
+func (p *pipeline) simple() bool {
+	return len(p.stages) == 1 && p.redirect == ""
+}
+
+func (p *pipeline) String() string {
+	stages := make([]string, len(p.stages))
+	for i, args := range p.stages {
+		stages[i] = shellquote.Join(args...)
+	}
+
+	line := strings.Join(stages, " | ")
+
+	switch {
+	case p.redirect != "" && p.append:
+		line += " >> " + shellquote.Join(p.redirect)
+	case p.redirect != "":
+		line += " > " + shellquote.Join(p.redirect)
+	}
+
+	return line
+}
+
+// parsePipeline splits a statement into pipeline stages and an optional output redirection.
+func (c *Console) parsePipeline(stmt *syntax.Stmt) (*pipeline, error) {
+	pipe := &pipeline{}
+
+	if err := c.addPipeStmt(pipe, stmt, true); err != nil {
+		return nil, err
+	}
+
+	return pipe, nil
+}
+
+// joinPipeline makes a single command out of several statements separated
+// by newlines: they are the words of a command continued over several lines.
+func (c *Console) joinPipeline(stmts []*syntax.Stmt) (*pipeline, error) {
+	var printed bytes.Buffer
+
+	for _, stmt := range stmts {
+		if _, call := stmt.Cmd.(*syntax.CallExpr); !call || len(stmt.Redirs) > 0 || stmt.Background {
+			return nil, errors.New("only simple commands can be continued over several lines")
+		}
+
+		if err := syntax.NewPrinter().Print(&printed, stmt.Cmd); err != nil {
+			return nil, err
+		}
+
+		printed.WriteString("\n")
+	}
+
+	args, err := c.splitLine(printed.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pipeline{stages: [][]string{args}}, nil
+}
+
+func (c *Console) addPipeStmt(pipe *pipeline, stmt *syntax.Stmt, last bool) error {
+	if stmt.Background {
+		return errors.New("only a whole command list can be run in the background")
+	}
+
+	if stmt.Coprocess || stmt.Negated {
+		return errors.New("coprocesses and negated commands are not supported")
+	}
+
+	// Redirections only make sense at the end of the pipeline,
+	// since anything in the middle is consumed by the next stage.
+	if len(stmt.Redirs) > 0 && !last {
+		return errors.New("only the last command of a pipeline can be redirected")
+	}
+
+	for _, redir := range stmt.Redirs {
+		if err := c.addRedirect(pipe, redir); err != nil {
+			return err
+		}
+	}
+
+	if bin, ok := stmt.Cmd.(*syntax.BinaryCmd); ok {
+		if bin.Op != syntax.Pipe {
+			return fmt.Errorf("operator %s cannot be used inside a pipeline", bin.Op)
+		}
+
+		if err := c.addPipeStmt(pipe, bin.X, false); err != nil {
+			return err
+		}
+
+		return c.addPipeStmt(pipe, bin.Y, last)
+	}
+
+	if stmt.Cmd == nil {
+		return errors.New("missing command")
+	}
+
+	var printed bytes.Buffer
+	if err := syntax.NewPrinter().Print(&printed, stmt.Cmd); err != nil {
+		return err
+	}
+
+	args, err := c.splitLine(printed.String())
+	if err != nil {
+		return err
+	}
+
+	pipe.stages = append(pipe.stages, args)
+
+	return nil
+}
+
+func (c *Console) addRedirect(pipe *pipeline, redir *syntax.Redirect) error {
+	if redir.N != nil && redir.N.Value != "1" {
+		return fmt.Errorf("unsupported redirection %s%s", redir.N.Value, redir.Op)
+	}
+
+	switch redir.Op {
+	case syntax.RdrOut, syntax.ClbOut:
+		pipe.append = false
+	case syntax.AppOut:
+		pipe.append = true
+	default:
+		return fmt.Errorf("unsupported redirection %s", redir.Op)
+	}
+
+	var printed bytes.Buffer
+	if err := syntax.NewPrinter().Print(&printed, redir.Word); err != nil {
+		return err
+	}
+
+	words, err := c.splitLine(printed.String())
+	if err != nil {
+		return err
+	}
+
+	if len(words) != 1 || words[0] == "" {
+		return errors.New("redirection needs a single file name")
+	}
+
+	pipe.redirect = words[0]
+
+	return nil
+}
+
+// executePipeline runs each stage of the pipeline, feeding the captured output of
+// one stage to the next, and prints (or writes to the redirection file) the last one.
+// Stages after the first are console commands, built-in filters or system commands,
+// in that order of precedence. When hooks is true, the console line hooks are run on
+// the arguments of each console command.
+// Without host access (see SetHostAccess), redirections fail before anything runs,
+// and stages that are not console commands nor filters are not found.
+func (c *Console) executePipeline(ctx context.Context, menu *Menu, pipe *pipeline, async, hooks bool) (interrupted bool, err error) {
+	if pipe.redirect != "" && !c.getHostAccess() {
+		return false, errNoHostAccess
+	}
+
+	if hooks {
+		if pipe.stages[0], err = c.runLineHooks(pipe.stages[0]); err != nil {
+			return false, LineHookError{newError(err, "Line error")}
+		}
+	}
+
+	if pipe.simple() {
+		return c.execute(ctx, menu, pipe.stages[0], async)
+	}
+
+	var input []byte
+
+	for i, args := range pipe.stages {
+		var output bytes.Buffer
+
+		if i > 0 {
+			// Regenerate the command tree, so that flags
+			// set by the previous stage don't leak in this one.
+			menu.resetBeforeRun(ctx)
+		}
+
+		interrupted, err = c.runPipeStage(ctx, menu, args, i == 0, hooks, input, &output)
+		if err != nil || interrupted {
+			return interrupted, err
+		}
+
+		input = output.Bytes()
+	}
+
+	if pipe.redirect == "" {
+		_, err = menu.output().Write(input)
+		return false, err
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if pipe.append {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(pipe.redirect, flags, 0o644)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = file.Write(input); err != nil {
+		file.Close()
+		return false, err
+	}
+
+	return false, file.Close()
+}
+
+func (c *Console) runPipeStage(ctx context.Context, menu *Menu, args []string, first, hooks bool, in []byte, out io.Writer) (interrupted bool, err error) {
+	if first || c.isMenuCommand(menu, args) {
+		if !first && hooks {
+			if args, err = c.runLineHooks(args); err != nil {
+				return false, LineHookError{newError(err, "Line error")}
+			}
+		}
+
+		menu.Command.SetIn(bytes.NewReader(in))
+		defer menu.Command.SetIn(nil)
+
+		// Captured commands always run as executing, so that the console
+		// Printf functions write plain output instead of redrawing the prompt.
+		err = c.captureOutput(menu, out, func() error {
+			interrupted, err = c.execute(ctx, menu, args, false)
+			return err
+		})
+
+		return interrupted, err
+	}
+
+	if filter, found := c.pipeFilter(args[0]); found {
+		return false, filter(args[1:], bytes.NewReader(in), out)
+	}
+
+	if !c.getHostAccess() {
+		return false, fmt.Errorf("%s: command not found", args[0])
+	}
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return false, fmt.Errorf("%s: command not found", args[0])
+	}
+
+	cmd := exec.CommandContext(ctx, path, args[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = out
+	cmd.Stderr = menu.errOutput()
+
+	return false, cmd.Run()
+}
+
+// isMenuCommand returns true if the args designate one of the menu subcommands.
+func (c *Console) isMenuCommand(menu *Menu, args []string) bool {
+	target, _, err := menu.Command.Find(args)
+
+	return err == nil && target != nil && target != menu.Command
+}
+
+// captureOutput redirects what a command prints to the user to w while fn runs:
+// its cobra output (cmd.OutOrStdout()) and the menu/console Printf functions.
+// Neither the terminal nor the process stdout are touched, so what commands
+// write to them directly is not captured.
+// Commands of background jobs only have their cobra output captured, since the
+// console output is that of the foreground.
+func (c *Console) captureOutput(menu *Menu, w io.Writer, fn func() error) error {
+	locked := &lockedWriter{w: w}
+
+	if menu.job != nil {
+		menu.Command.SetOut(locked)
+		defer menu.Command.SetOut(menu.job.output)
+
+		return fn()
+	}
+
+	menu.Command.SetOut(locked)
+
+	c.outputMutex.Lock()
+	previous := c.capture
+	c.capture = locked
+	c.outputMutex.Unlock()
+
+	defer func() {
+		c.outputMutex.Lock()
+		c.capture = previous
+		c.outputMutex.Unlock()
+
+		if c.input != nil {
+			menu.Command.SetOut(c.terminal.Out)
+		} else {
+			menu.Command.SetOut(nil)
+		}
+	}()
+
+	return fn()
+}
+
+// output returns where the console prints while commands run: the
+// terminal, or the output of the pipeline stage being captured.
+func (c *Console) output() io.Writer {
+	c.outputMutex.Lock()
+	defer c.outputMutex.Unlock()
+
+	if c.capture != nil {
+		return c.capture
+	}
+
+	return c.terminal.Out
+}
+
+// lockedWriter serializes the writes of a captured command, which may
+// come both from its cobra output and the console Printf functions.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.Write(p)
+}
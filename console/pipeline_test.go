@@ -0,0 +1,177 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseLinePipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		stages   [][]string
+		redirect string
+		append   bool
+	}{
+		{"simple", `sessions --all`, [][]string{{"sessions", "--all"}}, "", false},
+		{"pipe", `sessions | grep win`, [][]string{{"sessions"}, {"grep", "win"}}, "", false},
+		{"quoted pipe is a word", `grep "a|b"`, [][]string{{"grep", "a|b"}}, "", false},
+		{"redirect", `tasks > out.txt`, [][]string{{"tasks"}}, "out.txt", false},
+		{"append", `tasks >> "my out.txt"`, [][]string{{"tasks"}}, "my out.txt", true},
+		{"pipe and redirect", `a | head -n 2 | wc -l >>count # note`, [][]string{{"a"}, {"head", "-n", "2"}, {"wc", "-l"}}, "count", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			console := &Console{mutex: &sync.RWMutex{}}
+
+			steps, err := console.parseLine(nil, tt.input)
+			if err != nil {
+				t.Fatalf("parseLine(%q) error = %v", tt.input, err)
+			}
+			if len(steps) != 1 {
+				t.Fatalf("parseLine(%q) returned %d steps, want 1", tt.input, len(steps))
+			}
+			pipe := steps[0].pipe
+			if !reflect.DeepEqual(pipe.stages, tt.stages) {
+				t.Fatalf("parseLine(%q) stages = %q, want %q", tt.input, pipe.stages, tt.stages)
+			}
+			if pipe.redirect != tt.redirect || pipe.append != tt.append {
+				t.Fatalf("parseLine(%q) redirect = %q (append %v), want %q (append %v)",
+					tt.input, pipe.redirect, pipe.append, tt.redirect, tt.append)
+			}
+		})
+	}
+}
+
+func TestParseLinePipelineErrors(t *testing.T) {
+	for _, input := range []string{
+		`a > out | b`,
+		`a 2> err.txt`,
+		`a < in.txt`,
+	} {
+		console := &Console{mutex: &sync.RWMutex{}}
+		if _, err := console.parseLine(nil, input); err == nil {
+			t.Errorf("parseLine(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestRunCommandLinePipeline(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	root := &cobra.Command{Use: "root"}
+	root.AddCommand(&cobra.Command{
+		Use: "list",
+		Run: func(cmd *cobra.Command, _ []string) {
+			fmt.Fprintln(cmd.OutOrStdout(), "alpha windows")
+			console.Printf("beta linux\n")
+			menu.Printf("gamma windows\n")
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "upper",
+		Run: func(cmd *cobra.Command, _ []string) {
+			var in bytes.Buffer
+			in.ReadFrom(cmd.InOrStdin())
+			fmt.Fprint(cmd.OutOrStdout(), strings.ToUpper(in.String()))
+		},
+	})
+	menu.SetCommands(func() *cobra.Command { return root })
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+
+	run := func(line string) string {
+		t.Helper()
+		if err := menu.RunCommandLine(context.Background(), line); err != nil {
+			t.Fatalf("RunCommandLine(%q) error = %v", line, err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if got, want := run("list | grep windows | upper > "+out), "ALPHA WINDOWS\nGAMMA WINDOWS\n"; got != want {
+		t.Fatalf("pipeline output = %q, want %q", got, want)
+	}
+
+	if got, want := run("list | wc -l >> "+out), "ALPHA WINDOWS\nGAMMA WINDOWS\n3\n"; got != want {
+		t.Fatalf("appended output = %q, want %q", got, want)
+	}
+
+	if err := menu.RunCommandLine(context.Background(), "list | nosuchcommand-xyz"); err == nil {
+		t.Fatal("expected an error for an unknown pipe target")
+	}
+}
+
+func TestRunCommandLineWithoutHostAccess(t *testing.T) {
+	console := New("test")
+	console.SetHostAccess(false)
+	menu := console.ActiveMenu()
+
+	root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+	root.AddCommand(&cobra.Command{
+		Use: "list",
+		Run: func(cmd *cobra.Command, _ []string) { fmt.Fprintln(cmd.OutOrStdout(), "alpha") },
+	})
+	menu.SetCommands(func() *cobra.Command { return root })
+
+	if err := menu.RunCommandLine(context.Background(), "list | grep alpha | wc -l"); err != nil {
+		t.Fatalf("built-in filters error = %v", err)
+	}
+
+	if err := menu.RunCommandLine(context.Background(), "list | sort"); err == nil || !strings.Contains(err.Error(), "sort: command not found") {
+		t.Fatalf("system command error = %v, want command not found", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	if err := menu.RunCommandLine(context.Background(), "list > "+out); !errors.Is(err, errNoHostAccess) {
+		t.Fatalf("redirection error = %v, want %v", err, errNoHostAccess)
+	}
+
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("redirection created %s", out)
+	}
+}
+
+func TestPipeFilters(t *testing.T) {
+	tests := []struct {
+		filter PipeFilter
+		args   []string
+		input  string
+		want   string
+	}{
+		{grepFilter, []string{"-i", "WIN"}, "a win\nb lin\nc Win\n", "a win\nc Win\n"},
+		{grepFilter, []string{"-v", "-n", "win"}, "a win\nb lin\n", "2:b lin\n"},
+		{grepFilter, []string{"-c", "\x1b"}, "\x1b[31mred\x1b[0m\n", "0\n"},
+		{headFilter, []string{"-2"}, "1\n2\n3\n", "1\n2\n"},
+		{tailFilter, []string{"-n", "1"}, "1\n2\n3\n", "3\n"},
+		{wcFilter, nil, "one two\nthree\n", "2 3 14\n"},
+		{jqFilter, []string{"-r", ".[].name"}, `[{"name":"a"},{"name":"b"}]`, "a\nb\n"},
+		{jqFilter, []string{"-c", ".host.ports[1]"}, "{\"host\":{\"ports\":[22,80]}}\n{\"host\":{\"ports\":[443]}}", "80\nnull\n"},
+		{jqFilter, []string{".os"}, `{"os":"linux"}`, "\"linux\"\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := tt.filter(tt.args, strings.NewReader(tt.input), &out); err != nil {
+			t.Fatalf("filter %q error = %v", tt.args, err)
+		}
+		if out.String() != tt.want {
+			t.Fatalf("filter %q = %q, want %q", tt.args, out.String(), tt.want)
+		}
+	}
+}
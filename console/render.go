@@ -0,0 +1,297 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chainreactors/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evertras/bubble-table/table"
+	"gopkg.in/yaml.v3"
+)
+
+func defaultRenderers() map[string]Renderer {
+	return map[string]Renderer{
+		OutputTable: renderTable,
+		OutputJSON:  renderJSON,
+		OutputYAML:  renderYAML,
+		OutputCSV:   renderCSV,
+	}
+}
+
+// renderTable displays tables and records with the widgets of the tui package
+// in the user terminal. Otherwise, it prints tables with aligned columns,
+// records as key-value blocks, and anything else as a plain value.
+func renderTable(target RenderTarget, result *Result) error {
+	switch {
+	case target.Interactive && target.terminal != nil && result.Table != nil:
+		return runTable(target, result.Table)
+
+	case target.Interactive && result.Record != nil:
+		return renderKV(target, result.Record)
+
+	case result.Table != nil:
+		table := result.Table
+		if table.Title != "" {
+			fmt.Fprintf(target, "%s\n\n", table.Title)
+		}
+
+		writer := tabwriter.NewWriter(target, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(table.Columns, "\t"))
+
+		for _, row := range table.Rows {
+			cells := make([]string, len(table.Columns))
+			for col := range table.Columns {
+				cells[col] = formatCell(cell(row, col))
+			}
+
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+
+		return writer.Flush()
+
+	case result.Record != nil:
+		record := result.Record
+		if record.Title != "" {
+			fmt.Fprintf(target, "%s\n\n", record.Title)
+		}
+
+		writer := tabwriter.NewWriter(target, 0, 4, 2, ' ', 0)
+		for _, key := range record.Keys {
+			fmt.Fprintf(writer, "%s:\t%s\n", key, formatCell(record.Values[key]))
+		}
+
+		return writer.Flush()
+
+	case result.Value == nil:
+		return nil
+
+	default:
+		model, err := orderedModel(result.Value)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(target, formatCell(model))
+
+		return err
+	}
+}
+
+// runTable displays a table in the interactive table of the tui package,
+// until the user quits it or the command is interrupted.
+func runTable(target RenderTarget, result *Table) error {
+	columns := make([]table.Column, len(result.Columns))
+	for col, name := range result.Columns {
+		columns[col] = table.NewColumn(name, name, len(name)).WithFiltered(true)
+	}
+
+	rows := make([]table.Row, len(result.Rows))
+	for i, row := range result.Rows {
+		data := make(table.RowData, len(result.Columns))
+		for col, name := range result.Columns {
+			data[name] = formatCell(cell(row, col))
+		}
+
+		rows[i] = table.NewRow(data)
+	}
+
+	model := tui.NewTable(columns, false)
+	model.Title = result.Title
+	model.SetRows(rows)
+
+	ctx := target.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// The shell leaves the terminal in cooked mode while commands run.
+	if restore, err := target.terminal.Control.MakeRaw(); err == nil {
+		defer restore()
+	}
+
+	program := tea.NewProgram(model,
+		tea.WithContext(ctx),
+		tea.WithInput(target.terminal.In),
+		tea.WithOutput(target.Writer),
+	)
+
+	unwatch := target.terminal.Control.OnResize(func(cols, rows int) {
+		program.Send(tea.WindowSizeMsg{Width: cols, Height: rows})
+	})
+	defer unwatch()
+
+	go func() {
+		cols, rows := target.terminal.Control.Size()
+		program.Send(tea.WindowSizeMsg{Width: cols, Height: rows})
+	}()
+
+	_, err := program.Run()
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
+// renderKV prints a record in a key-value table of the tui package.
+func renderKV(target RenderTarget, record *Record) error {
+	values := make(map[string]any, len(record.Keys))
+	for _, key := range record.Keys {
+		values[key] = kvValue(record.Values[key])
+	}
+
+	model := tui.NewOrderedKVTable(values, record.Keys)
+	model.Title = record.Title
+
+	_, err := fmt.Fprintln(target, model.View())
+
+	return err
+}
+
+// kvValue returns a value of a record with the type the key-value
+// tables use to style it: numbers, booleans, or formatted text.
+func kvValue(value any) any {
+	switch v := value.(type) {
+	case bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+
+		if n, err := v.Float64(); err == nil {
+			return n
+		}
+	}
+
+	return formatCell(value)
+}
+
+func renderJSON(target RenderTarget, result *Result) error {
+	data, err := json.MarshalIndent(resultValue(result), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(target, string(data))
+
+	return err
+}
+
+// renderYAML converts the JSON encoding of the result: fields
+// have the same names and order, and values the same types.
+func renderYAML(target RenderTarget, result *Result) error {
+	data, err := json.Marshal(resultValue(result))
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(target)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// renderCSV writes tables with a header line; records
+// are written as a table of one row, other values alone.
+func renderCSV(target RenderTarget, result *Result) error {
+	writer := csv.NewWriter(target)
+
+	switch {
+	case result.Table != nil:
+		writer.Write(result.Table.Columns)
+
+		for _, row := range result.Table.Rows {
+			cells := make([]string, len(result.Table.Columns))
+			for col := range result.Table.Columns {
+				cells[col] = formatCell(cell(row, col))
+			}
+
+			writer.Write(cells)
+		}
+
+	case result.Record != nil:
+		cells := make([]string, len(result.Record.Keys))
+		for i, key := range result.Record.Keys {
+			cells[i] = formatCell(result.Record.Values[key])
+		}
+
+		writer.Write(result.Record.Keys)
+		writer.Write(cells)
+
+	case result.Value != nil:
+		model, err := orderedModel(result.Value)
+		if err != nil {
+			return err
+		}
+
+		writer.Write([]string{formatCell(model)})
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// resultValue returns what the JSON encodings of a result are made of.
+func resultValue(result *Result) any {
+	switch {
+	case result.Table != nil:
+		return result.Table
+	case result.Record != nil:
+		return result.Record
+	default:
+		return result.Value
+	}
+}
+
+// blockStyle removes the flow style that YAML nodes decoded
+// from JSON have, so that they are encoded as YAML blocks.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// formatCell returns the text of a value in a table cell:
+// nested objects and lists are written as compact JSON.
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(bytes.TrimSpace(data))
+}
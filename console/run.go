@@ -5,13 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
+	"time"
 
 	"github.com/kballard/go-shellquote"
 	"github.com/spf13/cobra"
 )
 
+// statusInterrupted is the exit status recorded in history
+// stores for command lines interrupted by a signal.
+const statusInterrupted = 130
+
+// historyFinisher is implemented by history sources recording
+// the duration and exit status of the commands of their lines.
+type historyFinisher interface {
+	Finish(duration time.Duration, status int) error
+}
+
 // Start - Start the console application (readline loop). Blocking.
 // The error returned will always be an error that the console
 // application does not understand or cannot handle.
@@ -20,6 +29,8 @@ func (c *Console) Start() error {
 }
 
 // StartContext is like console.Start(). with a user-provided context.
+// The console returns the context error once it is done, after the
+// current command (or read) returns.
 func (c *Console) StartContext(ctx context.Context) error {
 	c.loadActiveHistories()
 
@@ -31,6 +42,10 @@ func (c *Console) StartContext(ctx context.Context) error {
 	lastLine := "" // used to check if last read line is empty.
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		c.displayPostRun(lastLine)
 
 		// Always ensure we work with the active menu, with freshly
@@ -62,36 +77,30 @@ func (c *Console) StartContext(ctx context.Context) error {
 		// so we must be sure we use the good one.
 		menu = c.activeMenu()
 
-		// Parse the line with bash-syntax, removing comments.
+		// Parse the line with bash-syntax, removing comments,
+		// and split it into a sequence of command pipelines.
 		resolvedLine := c.ResolvePasteReferences(line)
-		args, err := c.parse(resolvedLine)
+		steps, err := c.parseLine(menu, resolvedLine)
 		if err != nil {
 			menu.ErrorHandler(ParseError{newError(err, "Parsing error")})
 			continue
 		}
 
-		if len(args) == 0 {
-			lastLine = line
-			continue
-		}
-
-		// Run user-provided pre-run line hooks,
-		// which may modify the input line args.
-		args, err = c.runLineHooks(args)
-		if err != nil {
-			menu.ErrorHandler(LineHookError{newError(err, "Line error")})
-			continue
-		}
-
-		// Run all pre-run hooks and the command itself
+		// Run user-provided pre-run line hooks (which may modify
+		// the input args), all pre-run hooks and the commands.
 		// Don't check the error: if its a cobra error,
 		// the library user is responsible for setting
 		// the cobra behavior.
 		// If it's an interrupt, we take care of it.
-		if err := c.Execute(ctx, menu, args, false); err != nil {
-			menu.ErrorHandler(ExecutionError{newError(err, "")})
+		start := time.Now()
+
+		interrupted, err := c.executeLine(withAuditLine(ctx, line), menu, steps, false, true)
+		if err != nil {
+			menu.ErrorHandler(stepError(err))
 		}
 
+		menu.finishHistory(time.Since(start), interrupted, err)
+
 		lastLine = line
 	}
 }
@@ -105,32 +114,39 @@ func (c *Console) StartContext(ctx context.Context) error {
 func (m *Menu) RunCommandArgs(ctx context.Context, args []string) (err error) {
 	// The menu used and reset is the active menu.
 	// Prepare its output buffer for the command.
-	m.resetPreRun()
+	m.resetBeforeRun(ctx)
 
 	// Run the command and associated helpers.
+	// Commands run by other commands are not recorded with their line.
+	ctx = withAuditLine(ctx, shellquote.Join(args...))
+
 	return m.console.Execute(ctx, m, args, !m.console.isExecuting)
 }
 
 // RunCommandLine is the equivalent of menu.RunCommandArgs(), but accepts
 // an unsplit command line to execute. This line is split and processed in
-// *sh-compliant form, identically to how lines are in normal console usage.
+// *sh-compliant form, identically to how lines are in normal console usage:
+// pipes, output redirections and `;`, `&&`, `||` sequences included. Unlike
+// interactive lines, the console line hooks are not run.
 func (m *Menu) RunCommandLine(ctx context.Context, line string) (err error) {
 	if len(line) == 0 {
 		return
 	}
 
-	// Split the line into words using the same escape mode as interactive input.
-	var args []string
-	if m.console.getEscapeMode() == EscapeLiteral {
-		args, _, err = split(line, false, EscapeLiteral)
-	} else {
-		args, err = shellquote.Split(line)
-	}
+	steps, err := m.console.parseLine(m, line)
 	if err != nil {
 		return fmt.Errorf("line error: %w", err)
 	}
 
-	return m.RunCommandArgs(ctx, args)
+	if len(steps) == 0 {
+		return nil
+	}
+
+	m.resetBeforeRun(ctx)
+
+	_, err = m.console.executeLine(withAuditLine(ctx, line), m, steps, !m.console.isExecuting, false)
+
+	return err
 }
 
 // execute - The user has entered a command input line, the arguments have been processed:
@@ -140,17 +156,71 @@ func (m *Menu) RunCommandLine(ctx context.Context, line string) (err error) {
 // instead of the menu itself, because if RunCommand() is asynchronously triggered while another
 // command is running, the menu's root command will be overwritten.
 func (c *Console) Execute(ctx context.Context, menu *Menu, args []string, async bool) error {
-	if !async {
-		c.mutex.RLock()
-		c.isExecuting = true
-		c.mutex.RUnlock()
+	_, err := c.execute(ctx, menu, args, async)
+	return err
+}
+
+// nestedKey is the context key marking the context of running commands.
+type nestedKey struct{}
+
+// isNested returns true if ctx is that of a running command: the lines it runs
+// (sourced scripts, RunCommandLine calls...) reuse the execution state and the
+// signal monitoring of the outer command instead of setting up their own.
+func isNested(ctx context.Context) bool {
+	nested, _ := ctx.Value(nestedKey{}).(bool)
+	return nested
+}
+
+// resetBeforeRun resets the menu before running a command, unless the command is
+// run by another one: the command tree is still in use by the outer command, and
+// execute resets the flags of the target command anyway.
+func (m *Menu) resetBeforeRun(ctx context.Context) {
+	if !isNested(ctx) {
+		m.resetPreRun()
 	}
+}
+
+// execute is Execute, also reporting whether the command was interrupted by a signal,
+// so that callers running several commands in a row know they must stop there.
+// Commands run by other commands only wait for their own context, which is canceled
+// by the signals caught for the outer one.
+func (c *Console) execute(ctx context.Context, menu *Menu, args []string, async bool) (interrupted bool, err error) {
+	var (
+		interrupt string // Cause of the interruption, for the audit log.
+		detached  *Job   // Job the command was detached into.
+		nested    = isNested(ctx)
+	)
 
-	defer func() {
-		c.mutex.RLock()
-		c.isExecuting = false
-		c.mutex.RUnlock()
-	}()
+	if audit := c.auditCommand(ctx, menu, args); audit != nil {
+		defer func() {
+			switch {
+			case detached != nil:
+				go func() {
+					<-detached.Done()
+					audit(detached.Err(), interrupt, detached.ID)
+				}()
+			case menu.job != nil:
+				audit(err, interrupt, menu.job.ID)
+			default:
+				audit(err, interrupt, 0)
+			}
+		}()
+	}
+
+	// Background jobs and nested commands leave the foreground state alone.
+	if menu.job == nil && !nested {
+		if !async {
+			c.mutex.RLock()
+			c.isExecuting = true
+			c.mutex.RUnlock()
+		}
+
+		defer func() {
+			c.mutex.RLock()
+			c.isExecuting = false
+			c.mutex.RUnlock()
+		}()
+	}
 
 	// Our root command of interest, used throughout this function.
 	cmd := menu.Command
@@ -159,7 +229,7 @@ func (c *Console) Execute(ctx context.Context, menu *Menu, args []string, async
 	target, _, _ := cmd.Find(args)
 
 	if err := menu.CheckIsAvailable(target); err != nil {
-		return err
+		return false, err
 	}
 
 	// Reset all flags to their default values.
@@ -167,7 +237,7 @@ func (c *Console) Execute(ctx context.Context, menu *Menu, args []string, async
 
 	// Console-wide pre-run hooks, cannot.
 	if err := c.runAllE(c.PreCmdRunHooks); err != nil {
-		return fmt.Errorf("pre-run error: %s", err.Error())
+		return false, fmt.Errorf("pre-run error: %s", err.Error())
 	}
 
 	// Assign those arguments to our parser.
@@ -175,32 +245,85 @@ func (c *Console) Execute(ctx context.Context, menu *Menu, args []string, async
 
 	// The command execution should happen in a separate goroutine,
 	// and should notify the main goroutine when it is done.
-	ctx, cancel := context.WithCancelCause(ctx)
+	ctx, cancel := context.WithCancelCause(context.WithValue(ctx, nestedKey{}, true))
 
 	cmd.SetContext(ctx)
 
-	// Start monitoring keyboard and OS signals.
-	sigchan := c.monitorSignals()
+	// Start monitoring keyboard and OS signals, unless running in a background
+	// job or in another command: those are meant for the foreground command.
+	var (
+		sigchan <-chan os.Signal
+		detach  <-chan struct{}
+	)
+
+	if menu.job == nil && !nested {
+		var stop func()
+
+		sigchan, stop = c.monitorSignals()
+		defer stop()
+
+		detach = c.detachRequests()
+	}
 
 	// And start the command execution.
 	go c.executeCommand(cmd, cancel)
 
-	// Wait for the command to finish, or for an OS signal to be caught.
-	select {
-	case <-ctx.Done():
-		cause := context.Cause(ctx)
+	// Wait for the command to finish, for an OS signal to be caught,
+	// or for the command to be detached into a background job.
+	for {
+		select {
+		case <-ctx.Done():
+			cause := context.Cause(ctx)
 
-		if !errors.Is(cause, context.Canceled) {
-			return cause
-		}
+			if !errors.Is(cause, context.Canceled) {
+				return false, cause
+			}
+
+			return false, nil
+
+		case signal := <-sigchan:
+			if isDetachSignal(signal) {
+				if !c.canDetach(menu) {
+					continue
+				}
+
+				interrupt = errDetached.Error()
+				detached = c.detachCommand(ctx, cancel, menu, args)
+
+				return true, nil
+			}
+
+			interrupt = signal.String()
+
+			cancel(errors.New(signal.String()))
+
+			menu.handleInterrupt(errors.New(signal.String()))
+
+			return true, nil
+
+		case <-detach:
+			if !c.canDetach(menu) {
+				continue
+			}
 
-	case signal := <-sigchan:
-		cancel(errors.New(signal.String()))
+			interrupt = errDetached.Error()
+			detached = c.detachCommand(ctx, cancel, menu, args)
 
-		menu.handleInterrupt(errors.New(signal.String()))
+			return true, nil
+		}
 	}
+}
 
-	return nil
+// canDetach returns true if the command running in the menu can be detached
+// into a background job, or reports why it cannot.
+func (c *Console) canDetach(menu *Menu) bool {
+	if menu.cmds != nil {
+		return true
+	}
+
+	menu.ErrorHandler(ExecutionError{newError(errJobCommands, "")})
+
+	return false
 }
 
 // Run the command in a separate goroutine, and cancel the context when done.
@@ -231,6 +354,25 @@ func (c *Console) loadActiveHistories() {
 	}
 }
 
+// finishHistory records the duration and exit status of the last command
+// line in the history sources of the menu keeping them, like stores.
+func (m *Menu) finishHistory(duration time.Duration, interrupted bool, err error) {
+	status := 0
+
+	switch {
+	case interrupted:
+		status = statusInterrupted
+	case err != nil:
+		status = 1
+	}
+
+	for _, name := range m.historyNames {
+		if history, ok := m.histories[name].(historyFinisher); ok {
+			history.Finish(duration, status)
+		}
+	}
+}
+
 func (c *Console) runAllE(hooks []func() error) error {
 	for _, hook := range hooks {
 		if err := hook(); err != nil {
@@ -281,19 +423,3 @@ func (c *Console) displayPostRun(lastLine string) {
 
 	c.printed = false
 }
-
-// monitorSignals - Monitor the signals that can be sent to the process
-// while a command is running. We want to be able to cancel the command.
-func (c *Console) monitorSignals() <-chan os.Signal {
-	sigchan := make(chan os.Signal, 1)
-
-	signal.Notify(
-		sigchan,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-		// syscall.SIGKILL,
-	)
-
-	return sigchan
-}
@@ -0,0 +1,228 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ScriptOptions configures how a script of command lines is run.
+type ScriptOptions struct {
+	// Menu is the name of the menu in which to run the commands. When empty,
+	// the active menu is used, and menu switches made by the script are
+	// followed, like when lines are typed at the prompt.
+	Menu string
+
+	// StopOnError stops the script at the first line that fails.
+	// Otherwise, errors are reported and the following lines still run.
+	StopOnError bool
+
+	// Echo prints each command line before running it.
+	Echo bool
+}
+
+// ScriptResult is the result of one command line of a script.
+type ScriptResult struct {
+	Line     int           // Number of the (first) line of the command in the script.
+	Command  string        // The command line, with continuations joined.
+	Err      error         // The parsing or execution error, if any.
+	Duration time.Duration // How long the command line took to run.
+}
+
+// ScriptReport summarizes the run of a script.
+type ScriptReport struct {
+	Results     []ScriptResult
+	Stopped     bool // The script was stopped by a failed line (ScriptOptions.StopOnError).
+	Interrupted bool // The script was stopped by an interrupt.
+}
+
+// Failed returns the number of command lines that failed.
+func (r *ScriptReport) Failed() int {
+	failed := 0
+
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// Err returns an error summing up the failures of the script, or nil if all ran fine.
+func (r *ScriptReport) Err() error {
+	switch failed := r.Failed(); {
+	case r.Interrupted:
+		return errors.New("script interrupted")
+	case failed == 1:
+		return errors.New("1 command failed")
+	case failed > 1:
+		return fmt.Errorf("%d commands failed", failed)
+	default:
+		return nil
+	}
+}
+
+// WriteSummary prints the result of each command line of the script,
+// followed by the number of commands that succeeded and failed.
+func (r *ScriptReport) WriteSummary(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for _, result := range r.Results {
+		status := "ok"
+		if result.Err != nil {
+			status = "failed: " + result.Err.Error()
+		}
+
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n",
+			result.Line, result.Command, result.Duration.Round(time.Millisecond), status)
+	}
+
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	failed := r.Failed()
+	summary := fmt.Sprintf("%d commands: %d succeeded, %d failed", len(r.Results), len(r.Results)-failed, failed)
+
+	switch {
+	case r.Interrupted:
+		summary += " (interrupted)"
+	case r.Stopped:
+		summary += " (stopped on error)"
+	}
+
+	_, err := fmt.Fprintln(w, summary)
+
+	return err
+}
+
+// RunScriptFile runs the command lines of a file, or of the standard input if path is `-`.
+// See RunScript for details.
+func (c *Console) RunScriptFile(ctx context.Context, path string, opts ScriptOptions) (*ScriptReport, error) {
+	if path == "-" {
+		return c.RunScript(ctx, os.Stdin, opts)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return c.RunScript(ctx, file, opts)
+}
+
+// RunScript reads command lines from r and runs them one after the other, in the
+// same way as they are run when entered at the prompt: lines are parsed with the
+// same rules (pipes, sequences and background jobs included), and go through the
+// console line hooks, command filters, pre/post-run hooks and the menu error handler.
+//
+// Empty lines and lines starting with `#` are skipped, and lines are continued on
+// the next ones as they would be at the prompt (unterminated quotes, trailing `\`).
+//
+// The returned report holds the result of each command line run. The error is only
+// non-nil if the script could not be read, or ended in the middle of a command line.
+func (c *Console) RunScript(ctx context.Context, r io.Reader, opts ScriptOptions) (*ScriptReport, error) {
+	menu := c.activeMenu()
+	follow := opts.Menu == ""
+
+	if !follow {
+		if menu = c.Menu(opts.Menu); menu == nil {
+			return nil, fmt.Errorf("no such menu: %s", opts.Menu)
+		}
+	}
+
+	report := &ScriptReport{}
+	scanner := newLineScanner(r)
+
+	var (
+		command []string
+		start   int
+	)
+
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+
+		if len(command) == 0 {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+
+			start = number
+		}
+
+		command = append(command, line)
+
+		if !c.acceptMultiline([]rune(strings.Join(command, "\n"))) {
+			continue
+		}
+
+		if follow {
+			menu = c.activeMenu()
+		}
+
+		started := time.Now()
+		result := c.runScriptLine(ctx, menu, start, strings.Join(command, "\n"), opts, report)
+		result.Duration = time.Since(started)
+
+		report.Results = append(report.Results, result)
+		command = nil
+
+		if report.Interrupted || ctx.Err() != nil {
+			report.Interrupted = true
+			return report, nil
+		}
+
+		if result.Err != nil && opts.StopOnError {
+			report.Stopped = true
+			return report, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	if len(command) > 0 {
+		return report, fmt.Errorf("line %d: unexpected end of script in command line", start)
+	}
+
+	return report, nil
+}
+
+func (c *Console) runScriptLine(ctx context.Context, menu *Menu, number int, line string, opts ScriptOptions, report *ScriptReport) ScriptResult {
+	result := ScriptResult{Line: number, Command: line}
+
+	if opts.Echo {
+		fmt.Fprintf(c.output(), "+ %s\n", line)
+	}
+
+	steps, err := c.parseLine(menu, line)
+	if err != nil {
+		result.Err = ParseError{newError(err, "Parsing error")}
+		menu.ErrorHandler(result.Err)
+
+		return result
+	}
+
+	menu.resetBeforeRun(ctx)
+
+	interrupted, err := c.executeLine(withAuditLine(ctx, line), menu, steps, false, true)
+	if interrupted {
+		report.Interrupted = true
+		result.Err = errors.New("interrupted")
+	} else if err != nil {
+		result.Err = stepError(err)
+		menu.ErrorHandler(result.Err)
+	}
+
+	return result
+}
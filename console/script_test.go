@@ -0,0 +1,153 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newScriptTestConsole(t *testing.T) (*Console, *[]string) {
+	t.Helper()
+
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	var ran []string
+
+	menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+		root.AddCommand(&cobra.Command{
+			Use: "run",
+			Run: func(_ *cobra.Command, args []string) { ran = append(ran, strings.Join(args, ",")) },
+		})
+		root.AddCommand(&cobra.Command{
+			Use:  "fail",
+			RunE: func(_ *cobra.Command, _ []string) error { return errors.New("failed") },
+		})
+		return root
+	})
+
+	menu.ErrorHandler = func(error) error { return nil }
+
+	return console, &ran
+}
+
+const testScript = `# setup script
+run a
+
+run "b
+c" # trailing comment
+fail
+run d \
+  e
+`
+
+func TestRunScript(t *testing.T) {
+	console, ran := newScriptTestConsole(t)
+
+	report, err := console.RunScript(context.Background(), strings.NewReader(testScript), ScriptOptions{})
+	if err != nil {
+		t.Fatalf("RunScript error = %v", err)
+	}
+
+	if want := []string{"a", "b\nc", "d,e"}; !reflect.DeepEqual(*ran, want) {
+		t.Fatalf("ran %q, want %q", *ran, want)
+	}
+
+	var lines []int
+	for _, result := range report.Results {
+		lines = append(lines, result.Line)
+	}
+
+	if want := []int{2, 4, 6, 7}; !reflect.DeepEqual(lines, want) {
+		t.Fatalf("result lines = %v, want %v", lines, want)
+	}
+
+	if report.Failed() != 1 || report.Results[2].Err == nil || report.Stopped {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	var summary bytes.Buffer
+	report.WriteSummary(&summary)
+
+	if !strings.Contains(summary.String(), "4 commands: 3 succeeded, 1 failed") {
+		t.Fatalf("summary = %q", summary.String())
+	}
+}
+
+func TestRunScriptStopOnError(t *testing.T) {
+	console, ran := newScriptTestConsole(t)
+
+	report, err := console.RunScript(context.Background(), strings.NewReader(testScript), ScriptOptions{StopOnError: true})
+	if err != nil {
+		t.Fatalf("RunScript error = %v", err)
+	}
+
+	if len(*ran) != 2 || len(report.Results) != 3 || !report.Stopped {
+		t.Fatalf("script not stopped on error: ran %q, report %+v", *ran, report)
+	}
+
+	if report.Err() == nil {
+		t.Fatal("report error is nil")
+	}
+}
+
+func TestRunScriptUnterminated(t *testing.T) {
+	console, _ := newScriptTestConsole(t)
+
+	if _, err := console.RunScript(context.Background(), strings.NewReader("run a\nrun 'b\n"), ScriptOptions{}); err == nil {
+		t.Fatal("expected an error for an unterminated command line")
+	}
+}
+
+func TestRunScriptInCommand(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	var (
+		trees     []*cobra.Command
+		executing []bool
+	)
+
+	menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+		root.AddCommand(&cobra.Command{
+			Use: "check",
+			Run: func(*cobra.Command, []string) { executing = append(executing, console.isExecuting) },
+		})
+		root.AddCommand(&cobra.Command{
+			Use: "source",
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				trees = append(trees, menu.Command)
+				report, err := console.RunScript(cmd.Context(), strings.NewReader("check\ncheck; check\n"), ScriptOptions{})
+				if err != nil {
+					return err
+				}
+				trees = append(trees, menu.Command)
+				executing = append(executing, console.isExecuting)
+				return report.Err()
+			},
+		})
+		return root
+	})
+	menu.resetPreRun()
+
+	// Run as from the prompt.
+	if _, err := console.execute(context.Background(), menu, []string{"source"}, false); err != nil {
+		t.Fatalf("execute error = %v", err)
+	}
+
+	// The lines of the script run in the tree and the execution state of the outer command.
+	if len(trees) != 2 || trees[0] != trees[1] {
+		t.Fatal("the script lines regenerated the commands of the running command")
+	}
+
+	if want := []bool{true, true, true, true}; !reflect.DeepEqual(executing, want) {
+		t.Fatalf("console executing = %v, want %v", executing, want)
+	}
+}
@@ -0,0 +1,234 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// chainOp tells when a step of a command line runs, given the result of the previous one.
+type chainOp int
+
+const (
+	chainAlways chainOp = iota // first step, or `;`
+	chainAnd                   // `&&`: only if the previous step succeeded
+	chainOr                    // `||`: only if the previous step failed
+)
+
+// step is one pipeline of a command line, with the operator preceding it.
+// Background steps belong to a list ended by `&`, which runs as a job.
+type step struct {
+	op         chainOp
+	pipe       *pipeline
+	background bool
+}
+
+func (s step) String() string {
+	switch s.op {
+	case chainAnd:
+		return "&& " + s.pipe.String()
+	case chainOr:
+		return "|| " + s.pipe.String()
+	default:
+		return s.pipe.String()
+	}
+}
+
+// formatSteps prints steps back as a command line.
+func formatSteps(steps []step) string {
+	words := make([]string, len(steps))
+	for i, step := range steps {
+		words[i] = step.String()
+	}
+
+	return strings.Join(words, " ")
+}
+
+// parseLine parses an input line with shell syntax, removing comments, and
+// splits it into steps (separated by `;`, `&&` and `||`), each of them being
+// a pipeline with an optional output redirection. Aliases and variables of the
+// menu (if not nil) and the global ones are expanded.
+func (c *Console) parseLine(menu *Menu, input string) ([]step, error) {
+	input = c.expandAliases(menu, input, nil)
+
+	parser := syntax.NewParser(syntax.KeepComments(false))
+
+	file, err := parser.Parse(strings.NewReader(input), "")
+	if err != nil {
+		return nil, err
+	}
+
+	c.expandVariables(menu, file)
+
+	var (
+		steps   []step
+		pending []*syntax.Stmt
+	)
+
+	// Statements are only separate commands when a `;` ends them: those
+	// separated by newlines are the same command continued on several lines.
+	for i, stmt := range file.Stmts {
+		pending = append(pending, stmt)
+
+		if !stmt.Semicolon.IsValid() && i < len(file.Stmts)-1 {
+			continue
+		}
+
+		if len(pending) == 1 {
+			// A trailing `&` applies to the whole `&&`/`||` list.
+			background := stmt.Background
+			stmt.Background = false
+			added := len(steps)
+
+			if steps, err = c.addChainStmt(steps, stmt, chainAlways); err != nil {
+				return nil, err
+			}
+
+			for i := added; i < len(steps); i++ {
+				steps[i].background = background
+			}
+		} else {
+			pipe, err := c.joinPipeline(pending)
+			if err != nil {
+				return nil, err
+			}
+
+			steps = append(steps, step{op: chainAlways, pipe: pipe})
+		}
+
+		pending = nil
+	}
+
+	return steps, nil
+}
+
+// addChainStmt flattens `a && b || c` (parsed as `(a && b) || c`) into
+// steps, which, like in shells, are evaluated from left to right.
+func (c *Console) addChainStmt(steps []step, stmt *syntax.Stmt, op chainOp) ([]step, error) {
+	if bin, ok := stmt.Cmd.(*syntax.BinaryCmd); ok && len(stmt.Redirs) == 0 {
+		var next chainOp
+
+		switch bin.Op {
+		case syntax.AndStmt:
+			next = chainAnd
+		case syntax.OrStmt:
+			next = chainOr
+		}
+
+		if next != chainAlways {
+			steps, err := c.addChainStmt(steps, bin.X, op)
+			if err != nil {
+				return nil, err
+			}
+
+			return c.addChainStmt(steps, bin.Y, next)
+		}
+	}
+
+	pipe, err := c.parsePipeline(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(steps, step{op: op, pipe: pipe}), nil
+}
+
+// executeLine runs the steps of a command line in order, skipping those whose
+// operator requires it. Each step goes through the same process as a single
+// command: line hooks (if hooks is true), command filters, pre/post-run hooks.
+// Lists ended with `&` are started as background jobs, and succeed immediately.
+//
+// The error of the last step that ran is returned. Errors of earlier steps are
+// passed to the menu error handler as they happen, since later steps still run.
+// An interrupt stops the whole line.
+func (c *Console) executeLine(ctx context.Context, menu *Menu, steps []step, async, hooks bool) (interrupted bool, err error) {
+	// Follow menu switches made by commands of the line,
+	// unless we are running in a menu that is not active.
+	follow := menu == c.activeMenu()
+
+	var status error
+
+	for i := 0; i < len(steps); i++ {
+		step := steps[i]
+
+		if ctx.Err() != nil {
+			return false, context.Cause(ctx)
+		}
+
+		if step.pipe.empty() {
+			continue
+		}
+
+		if step.background {
+			end := i + 1
+			for end < len(steps) && steps[end].background && steps[end].op != chainAlways {
+				end++
+			}
+
+			if follow {
+				menu = c.activeMenu()
+			}
+
+			job, err := c.startJob(ctx, menu, steps[i:end], hooks)
+			if err == nil {
+				fmt.Fprintf(menu.output(), "[%d] %s\n", job.ID, job.Line)
+			} else if willRunAfterFailure(steps[end:]) {
+				menu.ErrorHandler(stepError(err))
+			}
+
+			status = err
+			i = end - 1
+
+			continue
+		}
+
+		if (step.op == chainAnd && status != nil) || (step.op == chainOr && status == nil) {
+			continue
+		}
+
+		if i > 0 {
+			if follow {
+				menu = c.activeMenu()
+			}
+
+			menu.resetBeforeRun(ctx)
+		}
+
+		interrupted, err = c.executePipeline(ctx, menu, step.pipe, async, hooks)
+		if interrupted {
+			return true, nil
+		}
+
+		if err != nil && willRunAfterFailure(steps[i+1:]) {
+			menu.ErrorHandler(stepError(err))
+		}
+
+		status = err
+	}
+
+	return false, status
+}
+
+// willRunAfterFailure returns true if one of the remaining steps will run after a failure.
+func willRunAfterFailure(remaining []step) bool {
+	for _, step := range remaining {
+		if step.op != chainAnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stepError wraps an error returned by a step in its console error type.
+func stepError(err error) error {
+	var hookErr LineHookError
+	if errors.As(err, &hookErr) {
+		return hookErr
+	}
+
+	return ExecutionError{newError(err, "")}
+}
@@ -0,0 +1,105 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseLineSequence(t *testing.T) {
+	console := &Console{mutex: &sync.RWMutex{}}
+
+	steps, err := console.parseLine(nil, "a 1; b | grep x && c > out || d e")
+	if err != nil {
+		t.Fatalf("parseLine error = %v", err)
+	}
+
+	want := []struct {
+		op     chainOp
+		stages [][]string
+	}{
+		{chainAlways, [][]string{{"a", "1"}}},
+		{chainAlways, [][]string{{"b"}, {"grep", "x"}}},
+		{chainAnd, [][]string{{"c"}}},
+		{chainOr, [][]string{{"d", "e"}}},
+	}
+
+	if len(steps) != len(want) {
+		t.Fatalf("parseLine returned %d steps, want %d", len(steps), len(want))
+	}
+
+	for i, step := range steps {
+		if step.op != want[i].op || !reflect.DeepEqual(step.pipe.stages, want[i].stages) {
+			t.Errorf("step %d = %v %q, want %v %q", i, step.op, step.pipe.stages, want[i].op, want[i].stages)
+		}
+	}
+
+	if steps[2].pipe.redirect != "out" {
+		t.Errorf("step 2 redirect = %q, want %q", steps[2].pipe.redirect, "out")
+	}
+}
+
+func TestRunCommandLineSequence(t *testing.T) {
+	console := New("test")
+	menu := console.ActiveMenu()
+
+	var ran []string
+
+	root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+	root.AddCommand(&cobra.Command{
+		Use: "ok",
+		Run: func(_ *cobra.Command, args []string) { ran = append(ran, "ok"+args[0]) },
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "fail",
+		RunE: func(_ *cobra.Command, args []string) error {
+			ran = append(ran, "fail"+args[0])
+			return errors.New("failed")
+		},
+	})
+	menu.SetCommands(func() *cobra.Command { return root })
+
+	var handled []error
+	menu.ErrorHandler = func(err error) error {
+		handled = append(handled, err)
+		return nil
+	}
+
+	tests := []struct {
+		line    string
+		ran     []string
+		wantErr bool
+	}{
+		{"ok 1; ok 2", []string{"ok1", "ok2"}, false},
+		{"fail 1 && ok 2", []string{"fail1"}, true},
+		{"fail 1 || ok 2", []string{"fail1", "ok2"}, false},
+		{"ok 1 || ok 2 && ok 3", []string{"ok1", "ok3"}, false},
+		{"fail 1 && ok 2 || ok 3", []string{"fail1", "ok3"}, false},
+		{"fail 1; ok 2 && fail 3", []string{"fail1", "ok2", "fail3"}, true},
+	}
+
+	for _, tt := range tests {
+		ran, handled = nil, nil
+
+		err := menu.RunCommandLine(context.Background(), tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("RunCommandLine(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+		}
+
+		if !reflect.DeepEqual(ran, tt.ran) {
+			t.Errorf("RunCommandLine(%q) ran %q, want %q", tt.line, ran, tt.ran)
+		}
+	}
+
+	// Errors of steps followed by others are reported as they happen.
+	ran, handled = nil, nil
+	menu.RunCommandLine(context.Background(), "fail 1; fail 2")
+
+	if len(handled) != 1 {
+		t.Errorf("intermediate errors handled = %d, want 1", len(handled))
+	}
+}
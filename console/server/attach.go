@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// Dial connects to a console server listening on a Unix socket or a TCP
+// address, and returns the carrier to pass to Attach().
+func Dial(ctx context.Context, network, address string) (rlterm.Carrier, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStreamCarrier(conn), nil
+}
+
+// Attach connects a terminal (rlterm.Local() if nil) to a remote console,
+// until the server closes the connection or the context is done: input is
+// sent to the server, output is written to the terminal, which is switched
+// to raw mode when the server asks for it, and whose size changes are sent.
+//
+// When attaching the process terminal, the signals raised by its control keys
+// outside of raw mode (eg. Ctrl-C while a command runs) are forwarded as well,
+// and do not affect the local process.
+//
+// The variables of rlterm.EnvVariables set in the environment of the terminal
+// (if its control implements rlterm.Environ) are sent first, so that the
+// server renders for its capabilities.
+func Attach(ctx context.Context, carrier rlterm.Carrier, t *rlterm.Terminal) error {
+	if t == nil {
+		t = rlterm.Local()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	send := func(event rlterm.Event) {
+		if err := carrier.Send(ctx, event); err != nil {
+			cancel()
+		}
+	}
+
+	if env := terminalEnv(t.Control); len(env) > 0 {
+		send(rlterm.Event{Type: rlterm.EventEnv, Env: env})
+	}
+
+	cols, rows := t.Control.Size()
+	send(rlterm.Event{Type: rlterm.EventResize, Cols: cols, Rows: rows})
+
+	defer t.Control.OnResize(func(cols, rows int) {
+		send(rlterm.Event{Type: rlterm.EventResize, Cols: cols, Rows: rows})
+	})()
+
+	if t.In == os.Stdin {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, forwardedSignals...)
+
+		defer signal.Stop(signals)
+
+		go forwardSignals(ctx, signals, t, send)
+	}
+
+	go func() {
+		buf := make([]byte, 1024)
+
+		for {
+			read, err := t.In.Read(buf)
+			if read > 0 {
+				send(rlterm.Event{Type: rlterm.EventData, Data: append([]byte(nil), buf[:read]...)})
+			}
+
+			if err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	restore := func() {}
+	defer func() { restore() }()
+
+	for {
+		event, err := carrier.Recv(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		switch event.Type {
+		case rlterm.EventData:
+			if _, err := t.Out.Write(event.Data); err != nil {
+				return err
+			}
+		case rlterm.EventRawEnter:
+			restore()
+
+			if restore, err = t.Control.MakeRaw(); err != nil {
+				restore = func() {}
+			}
+		case rlterm.EventRawExit:
+			restore()
+			restore = func() {}
+		case rlterm.EventError:
+			return errors.New(event.Message)
+		case rlterm.EventClose:
+			return nil
+		}
+	}
+}
+
+// terminalEnv returns the variables describing the terminal set in its environment.
+func terminalEnv(control rlterm.Control) map[string]string {
+	environ, ok := control.(rlterm.Environ)
+	if !ok {
+		return nil
+	}
+
+	env := make(map[string]string)
+
+	for _, key := range rlterm.EnvVariables {
+		if value := environ.Getenv(key); value != "" {
+			env[key] = value
+		}
+	}
+
+	return env
+}
+
+// forwardSignals sends the signals of the process terminal to the server,
+// and its size when it changes, since the local control does not watch it.
+func forwardSignals(ctx context.Context, signals <-chan os.Signal, t *rlterm.Terminal, send func(rlterm.Event)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			if isResizeSignal(sig) {
+				cols, rows := t.Control.Size()
+				send(rlterm.Event{Type: rlterm.EventResize, Cols: cols, Rows: rows})
+
+				continue
+			}
+
+			if name := signalName(sig); name != "" {
+				send(rlterm.Event{Type: rlterm.EventSignal, Message: name})
+			}
+		}
+	}
+}
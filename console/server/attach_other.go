@@ -0,0 +1,24 @@
+//go:build !unix
+
+package server
+
+import (
+	"os"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// forwardedSignals are those Attach() catches on the process terminal.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+func isResizeSignal(os.Signal) bool {
+	return false
+}
+
+func signalName(sig os.Signal) string {
+	if sig == os.Interrupt {
+		return rlterm.SignalInterrupt
+	}
+
+	return ""
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package server
+
+import (
+	"os"
+	"syscall"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// forwardedSignals are those Attach() catches on the process terminal.
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTSTP,
+	syscall.SIGWINCH,
+}
+
+func isResizeSignal(sig os.Signal) bool {
+	return sig == syscall.SIGWINCH
+}
+
+func signalName(sig os.Signal) string {
+	switch sig {
+	case syscall.SIGINT:
+		return rlterm.SignalInterrupt
+	case syscall.SIGQUIT:
+		return rlterm.SignalQuit
+	case syscall.SIGTSTP:
+		return rlterm.SignalSuspend
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// NewStreamCarrier returns a terminal carrier exchanging events over a byte
+// stream (a Unix or TCP connection, a pipe...), one JSON object per line.
+// Closing the carrier closes the stream.
+func NewStreamCarrier(conn io.ReadWriteCloser) rlterm.Carrier {
+	return &streamCarrier{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		decoder: json.NewDecoder(bufio.NewReader(conn)),
+	}
+}
+
+type streamCarrier struct {
+	conn    io.ReadWriteCloser
+	send    sync.Mutex
+	encoder *json.Encoder
+	recv    sync.Mutex
+	decoder *json.Decoder
+}
+
+func (c *streamCarrier) Send(ctx context.Context, event rlterm.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.send.Lock()
+	defer c.send.Unlock()
+
+	return c.encoder.Encode(event)
+}
+
+func (c *streamCarrier) Recv(ctx context.Context) (event rlterm.Event, err error) {
+	if err = ctx.Err(); err != nil {
+		return event, err
+	}
+
+	c.recv.Lock()
+	defer c.recv.Unlock()
+
+	err = c.decoder.Decode(&event)
+
+	return event, err
+}
+
+func (c *streamCarrier) Close() error {
+	return c.conn.Close()
+}
+
+// NewWebSocketCarrier returns a terminal carrier exchanging
+// events over a WebSocket, one JSON text message per event.
+// Closing the carrier closes the WebSocket.
+func NewWebSocketCarrier(ws *websocket.Conn) rlterm.Carrier {
+	return &webSocketCarrier{ws: ws}
+}
+
+type webSocketCarrier struct {
+	ws   *websocket.Conn
+	send sync.Mutex
+	recv sync.Mutex
+}
+
+func (c *webSocketCarrier) Send(ctx context.Context, event rlterm.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.send.Lock()
+	defer c.send.Unlock()
+
+	return websocket.JSON.Send(c.ws, event)
+}
+
+func (c *webSocketCarrier) Recv(ctx context.Context) (event rlterm.Event, err error) {
+	if err = ctx.Err(); err != nil {
+		return event, err
+	}
+
+	c.recv.Lock()
+	defer c.recv.Unlock()
+
+	err = websocket.JSON.Receive(c.ws, &event)
+
+	return event, err
+}
+
+func (c *webSocketCarrier) Close() error {
+	return c.ws.Close()
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chainreactors/tui/console"
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// Client is a connection to the server, with its own terminal and console.
+type Client struct {
+	ID         int               // Unique among the clients of the server.
+	Network    string            // "unix", "tcp", "websocket", or that of a custom carrier.
+	RemoteAddr string            // Address of the client, if any.
+	Request    *http.Request     // Handshake request of WebSocket clients.
+	Connected  time.Time         // When the client connected.
+	Terminal   *rlterm.Terminal  // Terminal carried by the connection.
+	Console    *console.Console  // Console of the client, created once authenticated.
+	Values     map[string]string // Free-form data, eg. the user name set by an authentication hook.
+
+	server  *Server
+	remote  *rlterm.Remote
+	carrier rlterm.Carrier
+	cancel  context.CancelFunc
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+// Size returns the current size of the client terminal.
+func (c *Client) Size() (cols, rows int) {
+	return c.Terminal.Control.Size()
+}
+
+// Close disconnects the client: its console stops once
+// the command it is running (if any) returns.
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+	c.cancel()
+
+	err := c.remote.Close()
+
+	if closer, ok := c.carrier.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
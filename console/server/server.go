@@ -0,0 +1,372 @@
+// Package server serves consoles to several clients at once, over Unix sockets,
+// TCP or WebSockets. Each client gets its own console (menus, history, filters,
+// jobs...), usually with the same command tree as all others, built by a setup
+// function. Clients connect with Attach(), or with any program speaking the
+// event protocol of the carriers (see NewStreamCarrier and NewWebSocketCarrier).
+//
+// Commands of remote consoles must print to their cobra output (or through the
+// console Printf functions): the process stdout is not where the clients are.
+//
+// Clients do not have access to the host through their command lines (see
+// Server.HostAccess), and clients of TCP and WebSocket servers must be
+// authenticated (see Server.Authenticate).
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/chainreactors/tui/console"
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// ErrServerClosed is returned by the Serve functions once the server is closed.
+var ErrServerClosed = errors.New("console server closed")
+
+// ErrNoAuthentication is returned when serving clients over the network (anything
+// but Unix sockets, which are protected by their file permissions) without an
+// Authenticate function.
+var ErrNoAuthentication = errors.New("console server has no Authenticate function: only Unix sockets can be served")
+
+// Server accepts clients and runs a console for each of them.
+type Server struct {
+	// Name is the application name of the client consoles.
+	Name string
+
+	// Setup binds the menus, commands, prompts, etc. of a new client console.
+	// The client is disconnected if it returns an error.
+	Setup func(client *Client) error
+
+	// Authenticate, if set, is called before the console of a client is created,
+	// and disconnects the client if it returns an error. It may use the client
+	// terminal to prompt for credentials, or look at the WebSocket request.
+	// It is required to serve clients over TCP or WebSockets.
+	Authenticate func(ctx context.Context, client *Client) error
+
+	// HostAccess lets the command lines of clients run system commands and
+	// redirect output to files of the host (see console.SetHostAccess).
+	// Setup may still change it for each client.
+	HostAccess bool
+
+	// CheckOrigin reports whether a WebSocket handshake is accepted. If nil,
+	// only requests without an Origin, or from the same host, are accepted.
+	CheckOrigin func(r *http.Request) bool
+
+	// OnConnect and OnDisconnect are called when a client console starts and stops.
+	OnConnect    func(client *Client)
+	OnDisconnect func(client *Client, err error)
+
+	mutex     sync.Mutex
+	clients   map[int]*Client
+	nextID    int
+	listeners map[net.Listener]struct{}
+	closed    bool
+}
+
+// New returns a server for an application name, with a function
+// setting up the console of each client (usually, its commands).
+func New(name string, setup func(client *Client) error) *Server {
+	return &Server{
+		Name:      name,
+		Setup:     setup,
+		clients:   make(map[int]*Client),
+		listeners: make(map[net.Listener]struct{}),
+	}
+}
+
+// ListenAndServe listens on a Unix socket or a TCP address
+// (network is "unix" or "tcp") and serves clients until the
+// context is done, or until the server is closed.
+func (s *Server) ListenAndServe(ctx context.Context, network, address string) error {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve accepts connections on the listener, exchanging terminal events
+// with NewStreamCarrier. The listener is closed when Serve returns.
+// Listeners other than Unix sockets require an Authenticate function.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	if s.Authenticate == nil && listener.Addr().Network() != "unix" {
+		listener.Close()
+		return ErrNoAuthentication
+	}
+
+	if !s.track(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+
+	defer s.untrack(listener)
+
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			switch {
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case s.isClosed():
+				return ErrServerClosed
+			default:
+				return err
+			}
+		}
+
+		client := &Client{
+			Network:    listener.Addr().Network(),
+			RemoteAddr: conn.RemoteAddr().String(),
+		}
+
+		go s.ServeCarrier(ctx, NewStreamCarrier(conn), client)
+	}
+}
+
+// Handler returns an HTTP handler upgrading requests to WebSockets,
+// and serving a console over each of them. Handshakes are refused
+// if the server has no Authenticate function.
+func (s *Server) Handler() http.Handler {
+	return websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			if s.Authenticate == nil {
+				return ErrNoAuthentication
+			}
+
+			if !s.checkOrigin(r) {
+				return fmt.Errorf("origin not allowed: %s", r.Header.Get("Origin"))
+			}
+
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			request := ws.Request()
+			client := &Client{
+				Network:    "websocket",
+				RemoteAddr: request.RemoteAddr,
+				Request:    request,
+			}
+
+			s.ServeCarrier(request.Context(), NewWebSocketCarrier(ws), client)
+		},
+	}
+}
+
+// ServeCarrier runs the console of a client connected through any carrier,
+// and returns once the client is gone. The client may be nil, or only have
+// its Network and RemoteAddr set.
+func (s *Server) ServeCarrier(ctx context.Context, carrier rlterm.Carrier, client *Client) (err error) {
+	if client == nil {
+		client = &Client{}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	remote, err := rlterm.NewRemote(ctx, carrier, rlterm.RemoteOptions{IsTerminal: true})
+	if err != nil {
+		return err
+	}
+
+	client.server = s
+	client.remote = remote
+	client.carrier = carrier
+	client.cancel = cancel
+	client.Terminal = remote.Terminal
+	client.Connected = time.Now()
+
+	if client.Values == nil {
+		client.Values = make(map[string]string)
+	}
+
+	defer client.Close()
+
+	// The client going away cancels what it is running, and its console.
+	if signaler, ok := remote.Terminal.Control.(rlterm.Signaler); ok {
+		defer signaler.OnSignal(func(name string) {
+			if name == rlterm.SignalHangup {
+				cancel()
+			}
+		})()
+	}
+
+	if s.Authenticate != nil {
+		if err = s.Authenticate(ctx, client); err != nil {
+			fmt.Fprintf(client.Terminal.Err, "Authentication failed: %s\r\n", err)
+			return err
+		}
+	}
+
+	client.Console = console.NewWithTerminal(s.Name, client.Terminal)
+	client.Console.SetHostAccess(s.HostAccess)
+
+	if s.Setup != nil {
+		if err = s.Setup(client); err != nil {
+			fmt.Fprintf(client.Terminal.Err, "Error: %s\r\n", err)
+			return err
+		}
+	}
+
+	if !s.add(client) {
+		return ErrServerClosed
+	}
+
+	if s.OnConnect != nil {
+		s.OnConnect(client)
+	}
+
+	defer func() {
+		s.remove(client)
+
+		if s.OnDisconnect != nil {
+			s.OnDisconnect(client, err)
+		}
+	}()
+
+	err = client.Console.StartContext(ctx)
+	if errors.Is(err, context.Canceled) {
+		err = nil
+	}
+
+	return err
+}
+
+// Clients returns the connected clients, ordered by ID.
+func (s *Server) Clients() []*Client {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+
+	return clients
+}
+
+// Client returns a connected client by ID, or nil if there is none.
+func (s *Server) Client(id int) *Client {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.clients[id]
+}
+
+// Broadcast prints a message in the console of all connected clients,
+// above their prompt (see console.TransientPrintf).
+func (s *Server) Broadcast(msg string, args ...any) {
+	for _, client := range s.Clients() {
+		client.Console.TransientPrintf(msg, args...)
+	}
+}
+
+// Close stops all listeners and disconnects all clients.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+
+	listeners := make([]net.Listener, 0, len(s.listeners))
+	for listener := range s.listeners {
+		listeners = append(listeners, listener)
+	}
+
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mutex.Unlock()
+
+	var err error
+
+	for _, listener := range listeners {
+		if lerr := listener.Close(); lerr != nil && err == nil {
+			err = lerr
+		}
+	}
+
+	for _, client := range clients {
+		client.Close()
+	}
+
+	return err
+}
+
+func (s *Server) add(client *Client) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.nextID++
+	client.ID = s.nextID
+	s.clients[client.ID] = client
+
+	return true
+}
+
+func (s *Server) remove(client *Client) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.clients, client.ID)
+}
+
+func (s *Server) track(listener net.Listener) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.listeners[listener] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrack(listener net.Listener) {
+	s.mutex.Lock()
+	delete(s.listeners, listener)
+	s.mutex.Unlock()
+
+	listener.Close()
+}
+
+func (s *Server) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.closed
+}
+
+func (s *Server) checkOrigin(r *http.Request) bool {
+	if s.CheckOrigin != nil {
+		return s.CheckOrigin(r)
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+
+	return err == nil && parsed.Host == r.Host
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+	"github.com/spf13/cobra"
+)
+
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buf.String()
+}
+
+// terminalOutput answers cursor position queries, like terminals do:
+// the shell waits for them, and otherwise takes typed keys as the reply.
+type terminalOutput struct {
+	syncBuffer
+	replies io.Writer
+}
+
+func (o *terminalOutput) Write(p []byte) (int, error) {
+	for i := bytes.Count(p, []byte("\x1b[6n")); i > 0; i-- {
+		go o.replies.Write([]byte("\x1b[1;1R"))
+	}
+
+	return o.syncBuffer.Write(p)
+}
+
+type testClient struct {
+	input   *io.PipeWriter
+	output  *terminalOutput
+	control *rlterm.StreamControl
+	done    chan error
+}
+
+func attachClient(t *testing.T, ctx context.Context, socket string) *testClient {
+	t.Helper()
+
+	carrier, err := Dial(ctx, "unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, writer := io.Pipe()
+	client := &testClient{
+		input:   writer,
+		output:  &terminalOutput{replies: writer},
+		control: rlterm.NewControl(true, 100, 30),
+		done:    make(chan error, 1),
+	}
+
+	go func() {
+		client.done <- Attach(ctx, carrier, rlterm.Stream(reader, client.output, nil, client.control))
+	}()
+
+	t.Cleanup(func() { writer.Close() })
+
+	return client
+}
+
+func (c *testClient) waitFor(t *testing.T, text string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(c.output.String(), text) {
+		if time.Now().After(deadline) {
+			t.Fatalf("client output does not contain %q:\n%q", text, c.output.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func waitClients(t *testing.T, server *Server, count int) []*Client {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Clients()) != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("server has %d clients, want %d", len(server.Clients()), count)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return server.Clients()
+}
+
+func TestServerClients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := New("test", func(client *Client) error {
+		menu := client.Console.ActiveMenu()
+		menu.SetCommands(func() *cobra.Command {
+			root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+			root.AddCommand(&cobra.Command{
+				Use: "whoami",
+				Run: func(cmd *cobra.Command, _ []string) {
+					fmt.Fprintf(cmd.OutOrStdout(), "client-%d %s\n", client.ID, client.Values["user"])
+				},
+			})
+			root.AddCommand(&cobra.Command{
+				Use: "size",
+				Run: func(cmd *cobra.Command, _ []string) {
+					cols, rows := client.Size()
+					fmt.Fprintf(cmd.OutOrStdout(), "size=%dx%d\n", cols, rows)
+				},
+			})
+			return root
+		})
+		return nil
+	})
+	server.Authenticate = func(_ context.Context, client *Client) error {
+		if client.Network != "unix" {
+			return errors.New("unexpected network")
+		}
+		client.Values["user"] = "operator"
+		return nil
+	}
+
+	socket := filepath.Join(t.TempDir(), "console.sock")
+
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe(ctx, "unix", socket) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, err := os.Stat(socket); err != nil; _, err = os.Stat(socket) {
+		if time.Now().After(deadline) {
+			t.Fatal("server is not listening")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	first := attachClient(t, ctx, socket)
+	waitClients(t, server, 1)
+	second := attachClient(t, ctx, socket)
+	clients := waitClients(t, server, 2)
+
+	// Each client runs commands in its own console.
+	fmt.Fprint(first.input, "whoami\r")
+	first.waitFor(t, fmt.Sprintf("client-%d operator", clients[0].ID))
+
+	fmt.Fprint(second.input, "size\r")
+	second.waitFor(t, "size=100x30")
+
+	if strings.Contains(second.output.String(), "client-") {
+		t.Fatalf("second client got the output of the first one:\n%q", second.output.String())
+	}
+
+	// Resize events reach the console of the client.
+	second.control.SetSize(120, 40)
+	fmt.Fprint(second.input, "size\r")
+	second.waitFor(t, "size=120x40")
+
+	server.Broadcast("maintenance in %d minutes", 5)
+	first.waitFor(t, "maintenance in 5 minutes")
+	second.waitFor(t, "maintenance in 5 minutes")
+
+	// Disconnecting a client leaves the other one alone.
+	clients[0].Close()
+
+	select {
+	case err := <-first.done:
+		if err != nil {
+			t.Fatalf("Attach() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client was not disconnected")
+	}
+
+	waitClients(t, server, 1)
+
+	server.Close()
+
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("ListenAndServe() error = %v, want %v", err, ErrServerClosed)
+	}
+
+	waitClients(t, server, 0)
+}
+
+func TestServerAuthenticate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := New("test", nil)
+	server.Authenticate = func(context.Context, *Client) error {
+		return errors.New("access denied")
+	}
+
+	serverSide, clientSide := netPipe(t)
+	go server.ServeCarrier(ctx, NewStreamCarrier(serverSide), &Client{Network: "pipe"})
+
+	input, _ := io.Pipe()
+	output := &syncBuffer{}
+
+	err := Attach(ctx, NewStreamCarrier(clientSide), rlterm.Stream(input, output, nil, nil))
+	if err != nil {
+		t.Fatalf("Attach() error = %v", err)
+	}
+
+	if !strings.Contains(output.String(), "Authentication failed: access denied") {
+		t.Fatalf("client output = %q", output.String())
+	}
+
+	if clients := server.Clients(); len(clients) != 0 {
+		t.Fatalf("server has %d clients, want none", len(clients))
+	}
+}
+
+func netPipe(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	return server, client
+}
+
+func TestServerRequiresAuthentication(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := New("test", nil)
+
+	if err := server.Serve(context.Background(), listener); !errors.Is(err, ErrNoAuthentication) {
+		t.Fatalf("Serve() error = %v, want %v", err, ErrNoAuthentication)
+	}
+
+	if _, err := net.Dial("tcp", listener.Addr().String()); err == nil {
+		t.Fatal("listener still accepts connections")
+	}
+}
@@ -0,0 +1,202 @@
+package console
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// terminalSignal is a signal raised by the console terminal, not by the OS.
+type terminalSignal string
+
+func (s terminalSignal) String() string { return string(s) }
+func (s terminalSignal) Signal()        {}
+
+// detachSignal is raised by Ctrl-Z on terminals not attached to the process,
+// and detaches the foreground command like SIGTSTP does for local ones.
+const detachSignal = terminalSignal("suspended")
+
+// Control characters that the line discipline of a local terminal turns into
+// signals while a command runs. Remote terminals deliver them as plain input.
+const (
+	charInterrupt = 0x03 // Ctrl-C
+	charQuit      = 0x1c // Ctrl-\
+	charSuspend   = 0x1a // Ctrl-Z
+)
+
+// monitorSignals returns a channel on which are sent the signals meant for the
+// command about to run, and a function to stop monitoring once it is done.
+// Consoles running on the process stdin are interrupted by OS signals. Others
+// (remote terminals, streams) only listen to their own terminal, so that each
+// console of a process can be interrupted independently.
+func (c *Console) monitorSignals() (<-chan os.Signal, func()) {
+	if c.input != nil {
+		return c.input.watch(c.terminal.Control)
+	}
+
+	sigchan := make(chan os.Signal, 1)
+
+	signal.Notify(
+		sigchan,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT,
+		// syscall.SIGKILL,
+	)
+
+	if len(detachSignals) > 0 {
+		signal.Notify(sigchan, detachSignals...)
+	}
+
+	return sigchan, func() { signal.Stop(sigchan) }
+}
+
+// inputMonitor sits between a terminal input stream and the shell reading it.
+// It reads the stream continuously, and while a command is being watched, it
+// removes interrupt characters from the input and sends their signals instead.
+// Everything else is kept for the shell, which reads it once the command is done.
+type inputMonitor struct {
+	src     io.Reader
+	start   sync.Once
+	mutex   sync.Mutex
+	ready   *sync.Cond
+	pending []byte
+	err     error
+	signals chan os.Signal // Non-nil while a command is watched.
+}
+
+func newInputMonitor(src io.Reader) *inputMonitor {
+	monitor := &inputMonitor{src: src}
+	monitor.ready = sync.NewCond(&monitor.mutex)
+
+	return monitor
+}
+
+// Read implements io.Reader for the shell.
+func (m *inputMonitor) Read(p []byte) (int, error) {
+	m.start.Do(func() { go m.pump() })
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for len(m.pending) == 0 && m.err == nil {
+		m.ready.Wait()
+	}
+
+	if len(m.pending) == 0 {
+		return 0, m.err
+	}
+
+	read := copy(p, m.pending)
+	m.pending = m.pending[read:]
+
+	return read, nil
+}
+
+// watch starts sending the signals read on the input, and those delivered
+// by the terminal control (if it is a Signaler), until stop is called.
+func (m *inputMonitor) watch(control rlterm.Control) (<-chan os.Signal, func()) {
+	m.start.Do(func() { go m.pump() })
+
+	signals := make(chan os.Signal, 1)
+
+	m.mutex.Lock()
+	m.signals = signals
+	m.mutex.Unlock()
+
+	unregister := func() {}
+	if signaler, ok := control.(rlterm.Signaler); ok {
+		unregister = signaler.OnSignal(func(name string) {
+			if sig := namedSignal(name); sig != nil {
+				notify(signals, sig)
+			}
+		})
+	}
+
+	stop := func() {
+		unregister()
+
+		m.mutex.Lock()
+		m.signals = nil
+		m.mutex.Unlock()
+	}
+
+	return signals, stop
+}
+
+func (m *inputMonitor) pump() {
+	buf := make([]byte, 1024)
+
+	for {
+		read, err := m.src.Read(buf)
+
+		m.mutex.Lock()
+
+		for _, char := range buf[:read] {
+			if m.signals != nil {
+				if sig := controlSignal(char); sig != nil {
+					notify(m.signals, sig)
+					continue
+				}
+			}
+
+			m.pending = append(m.pending, char)
+		}
+
+		if err != nil {
+			m.err = err
+		}
+
+		m.ready.Broadcast()
+		m.mutex.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// notify sends a signal without blocking: if one is already
+// pending for the command, there is no point in stacking them.
+func notify(signals chan os.Signal, sig os.Signal) {
+	select {
+	case signals <- sig:
+	default:
+	}
+}
+
+// controlSignal returns the signal raised by a control character, if any.
+func controlSignal(char byte) os.Signal {
+	switch char {
+	case charInterrupt:
+		return os.Interrupt
+	case charQuit:
+		return syscall.SIGQUIT
+	case charSuspend:
+		return detachSignal
+	default:
+		return nil
+	}
+}
+
+// namedSignal returns the signal forwarded by a terminal carrier, if known.
+func namedSignal(name string) os.Signal {
+	switch name {
+	case rlterm.SignalInterrupt:
+		return os.Interrupt
+	case rlterm.SignalQuit:
+		return syscall.SIGQUIT
+	case rlterm.SignalTerminate:
+		return syscall.SIGTERM
+	case rlterm.SignalHangup:
+		return syscall.SIGHUP
+	case rlterm.SignalSuspend:
+		return detachSignal
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package console
+
+import "os"
+
+// detachSignals detach the command running in the foreground into a background
+// job. There is no job control signal here: use Console.DetachForeground().
+var detachSignals []os.Signal
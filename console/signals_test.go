@@ -0,0 +1,170 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+	"github.com/spf13/cobra"
+)
+
+type streamConsole struct {
+	console *Console
+	menu    *Menu
+	control *rlterm.StreamControl
+	input   *io.PipeWriter
+	started chan struct{}
+	causes  chan error
+}
+
+func newStreamConsole(t *testing.T) *streamConsole {
+	t.Helper()
+
+	reader, writer := io.Pipe()
+	t.Cleanup(func() { writer.Close() })
+
+	sc := &streamConsole{
+		control: rlterm.NewControl(true, 80, 24),
+		input:   writer,
+		started: make(chan struct{}, 1),
+		causes:  make(chan error, 1),
+	}
+
+	sc.console = NewWithTerminal("test", rlterm.Stream(reader, io.Discard, io.Discard, sc.control))
+	sc.menu = sc.console.ActiveMenu()
+
+	sc.menu.SetCommands(func() *cobra.Command {
+		root := &cobra.Command{Use: "root", SilenceErrors: true, SilenceUsage: true}
+		root.AddCommand(&cobra.Command{
+			Use: "block",
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				sc.started <- struct{}{}
+				<-cmd.Context().Done()
+				sc.causes <- context.Cause(cmd.Context())
+				return nil
+			},
+		})
+		return root
+	})
+
+	return sc
+}
+
+// run runs the blocking command, calls interrupt once it started,
+// and returns the cause with which the command context was canceled.
+func (sc *streamConsole) run(t *testing.T, interrupt func()) error {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() { done <- sc.menu.RunCommandLine(context.Background(), "block") }()
+
+	select {
+	case <-sc.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not start")
+	}
+
+	interrupt()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunCommandLine error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command was not interrupted")
+	}
+
+	return <-sc.causes
+}
+
+func TestTerminalInterrupt(t *testing.T) {
+	sc := newStreamConsole(t)
+
+	handled := make(chan struct{}, 1)
+	sc.menu.AddInterrupt(errors.New(os.Interrupt.String()), func(*Console) {
+		handled <- struct{}{}
+	})
+
+	// Keys typed while the command runs are kept for the shell.
+	cause := sc.run(t, func() { sc.input.Write([]byte("ab\x03c")) })
+	if cause == nil || cause.Error() != os.Interrupt.String() {
+		t.Fatalf("command canceled with %v, want %q", cause, os.Interrupt.String())
+	}
+
+	select {
+	case <-handled:
+	default:
+		t.Fatal("interrupt handler was not called")
+	}
+
+	buf := make([]byte, 8)
+	if read, _ := sc.console.terminal.In.Read(buf); string(buf[:read]) != "abc" {
+		t.Fatalf("input after command = %q, want %q", buf[:read], "abc")
+	}
+
+	// Outside of commands, Ctrl-C is left to the shell.
+	go sc.input.Write([]byte{charInterrupt})
+
+	if read, _ := sc.console.terminal.In.Read(buf); string(buf[:read]) != "\x03" {
+		t.Fatalf("input while reading = %q, want Ctrl-C", buf[:read])
+	}
+}
+
+func TestTerminalCarrierSignal(t *testing.T) {
+	sc := newStreamConsole(t)
+	other := newStreamConsole(t)
+
+	cause := sc.run(t, func() { sc.control.Signal(rlterm.SignalTerminate) })
+	if cause == nil || cause.Error() != "terminated" {
+		t.Fatalf("command canceled with %v, want %q", cause, "terminated")
+	}
+
+	// Signals of one console terminal don't reach the others.
+	cause = other.run(t, func() {
+		sc.control.Signal(rlterm.SignalInterrupt)
+		sc.input.Write([]byte{charQuit})
+
+		select {
+		case <-other.causes:
+			t.Fatal("command interrupted by the terminal of another console")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		other.control.Signal(rlterm.SignalQuit)
+	})
+	if cause == nil || cause.Error() != "quit" {
+		t.Fatalf("command canceled with %v, want %q", cause, "quit")
+	}
+}
+
+func TestTerminalDetach(t *testing.T) {
+	sc := newStreamConsole(t)
+
+	done := make(chan error, 1)
+	go func() { done <- sc.menu.RunCommandLine(context.Background(), "block") }()
+
+	<-sc.started
+	sc.input.Write([]byte{charSuspend})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Ctrl-Z did not detach the command")
+	}
+
+	jobs := sc.console.Jobs()
+	if len(jobs) != 1 || jobs[0].Status() != JobRunning {
+		t.Fatalf("jobs = %v, want one running job", jobs)
+	}
+
+	jobs[0].Cancel()
+
+	if cause := <-sc.causes; !errors.Is(cause, ErrJobCanceled) {
+		t.Fatalf("job command canceled with %v, want %v", cause, ErrJobCanceled)
+	}
+}
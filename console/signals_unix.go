@@ -0,0 +1,12 @@
+//go:build unix
+
+package console
+
+import (
+	"os"
+	"syscall"
+)
+
+// detachSignals detach the command running in the foreground into a
+// background job. SIGTSTP is what the terminal sends on Ctrl-Z.
+var detachSignals = []os.Signal{syscall.SIGTSTP}
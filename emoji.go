@@ -1,5 +1,7 @@
 package tui
 
+import "github.com/chainreactors/tui/term"
+
 const (
 	Rocket      = "🚀"
 	Package     = "📦"
@@ -26,3 +28,40 @@ const (
 	Ocean     = "🌊"
 	HotSpring = "♨️"
 )
+
+// emojiFallbacks 为无法显示 emoji 的终端提供纯文本替代
+var emojiFallbacks = map[string]string{
+	Rocket:      ">>",
+	Package:     "[#]",
+	Snowboarder: "~",
+	Question:    "?",
+
+	PointRight: "->",
+	PointLeft:  "<-",
+	PointDown:  "v",
+	PointUp:    "^",
+
+	Walking: ".",
+	Running: "..",
+
+	Monster: "[!]",
+	Dog:     "*",
+	Snake:   "~",
+	Shark:   "^",
+
+	Zap:       "!",
+	Cloud:     "~",
+	Fire:      "*",
+	Sparkles:  "*",
+	Ocean:     "~",
+	HotSpring: "~",
+}
+
+// Emoji returns e when the terminal can draw emoji, otherwise a plain-text
+// stand-in (or "" for emoji without one).
+func Emoji(e string) string {
+	if term.CurrentCapabilities().Emoji {
+		return e
+	}
+	return emojiFallbacks[e]
+}
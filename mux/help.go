@@ -1,13 +1,10 @@
 package mux
 
-import (
-	"github.com/charmbracelet/lipgloss"
-)
-
-var helpContent = func() string {
-	h := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
-	d := lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
-	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+func helpContent() string {
+	st := currentStyles()
+	h := st.title
+	d := st.text
+	dim := st.dim
 
 	return h.Render("Navigation") + "\n" +
 		d.Render("  n / p      ") + dim.Render("next / prev tab") + "\n" +
@@ -28,4 +25,4 @@ var helpContent = func() string {
 		d.Render("  q          ") + dim.Render("quit multiplexer") + "\n" +
 		"\n" +
 		dim.Render("  Press any key to close")
-}()
+}
@@ -3,6 +3,7 @@ package mux
 import (
 	"strings"
 
+	"github.com/chainreactors/tui/term"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -310,13 +311,13 @@ func (n *LayoutNode) findNode(id int) *LayoutNode {	if n.IsLeaf() {
 
 // renderVerticalSep returns a vertical separator bar of the given height.
 func renderVerticalSep(height int) string {
-	return renderVerticalSepColor(height, "8")
+	return renderVerticalSepColor(height, currentStyles().palette.Muted)
 }
 
-func renderVerticalSepColor(height int, color string) string {
+func renderVerticalSepColor(height int, color term.ThemeColor) string {
 	sep := lipgloss.NewStyle().
 		Foreground(lipgloss.Color(color)).
-		Render("│")
+		Render(term.Glyph("│", "|"))
 	lines := make([]string, height)
 	for i := range lines {
 		lines[i] = sep
@@ -326,7 +327,6 @@ func renderVerticalSepColor(height int, color string) string {
 
 // renderHorizontalSep returns a horizontal separator line of the given width.
 func renderHorizontalSep(width int) string {
-	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("8")).
-		Render(strings.Repeat("─", width))
+	return currentStyles().dim.
+		Render(strings.Repeat(term.Glyph("─", "-"), width))
 }
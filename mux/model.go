@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/chainreactors/tui/term"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -109,6 +110,9 @@ func New(opts ...Option) *Mux {
 	for _, opt := range opts {
 		opt(m)
 	}
+	// Probe before the program owns the input.
+	term.ProbeTerminal()
+	applyTheme()
 	return m
 }
 
@@ -231,7 +235,7 @@ func (m *Mux) View() string {
 	// Render overlay on top if active.
 	switch m.overlayMode {
 	case overlayHelp:
-		view = renderOverlay(view, "Mux Keybindings", helpContent, m.width, m.height)
+		view = renderOverlay(view, "Mux Keybindings", helpContent(), m.width, m.height)
 	case overlaySessionPicker, overlayPaneList:
 		if m.picker != nil {
 			pickerContent := m.picker.Render(m.width - 10)
@@ -598,11 +602,12 @@ func (m *Mux) closeAll() {
 func (m *Mux) openSessionPicker() {
 	var items []PickerItem
 	for _, s := range m.sidebarState.Sessions {
-		icon := "●"
-		color := "2"
+		palette := currentStyles().palette
+		icon := term.Glyph("●", "*")
+		color := string(palette.Success)
 		if !s.Alive {
-			icon = "○"
-			color = "8"
+			icon = term.Glyph("○", "o")
+			color = string(palette.Muted)
 		}
 		items = append(items, PickerItem{
 			ID:    s.ID,
@@ -634,11 +639,12 @@ func (m *Mux) openPaneList() {
 	var items []PickerItem
 	for i, tab := range m.tabs {
 		for _, p := range tab.Panes() {
-			icon := "◆"
-			color := "6"
+			palette := currentStyles().palette
+			icon := term.Glyph("◆", "#")
+			color := string(palette.Accent)
 			if p.IsDead() {
-				icon = "✗"
-				color = "8"
+				icon = term.Glyph("✗", "x")
+				color = string(palette.Muted)
 			}
 			desc := ""
 			if i == m.activeTab && p.ID() == m.focusedID {
@@ -6,23 +6,10 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	overlayBorder = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("6")).
-			Padding(1, 2)
-
-	overlayTitle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("6"))
-
-	overlayDim = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8"))
-)
-
 // renderOverlay renders a centered floating panel over the given background.
 func renderOverlay(bg string, title string, content string, bgWidth, bgHeight int) string {
-	panel := overlayBorder.Render(overlayTitle.Render(title) + "\n" + content)
+	st := currentStyles()
+	panel := st.border.Render(st.title.Render(title) + "\n" + content)
 
 	panelW := lipgloss.Width(panel)
 	panelH := lipgloss.Height(panel)
@@ -94,10 +94,9 @@ func (p *PickerState) HandleKey(keyStr string) (consumed bool, close bool) {
 
 // Render produces the picker content (without the overlay frame — that's added by renderOverlay).
 func (p *PickerState) Render(maxWidth int) string {
-	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-	highlight := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("0")).
-		Background(lipgloss.Color("6"))
+	st := currentStyles()
+	dim := st.dim
+	highlight := st.selected
 
 	var lines []string
 
@@ -4,36 +4,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/chainreactors/tui/term"
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	tabStyle = lipgloss.NewStyle().
-			Padding(0, 1)
-
-	activeTabStyle = lipgloss.NewStyle().
-			Padding(0, 1).
-			Bold(true).
-			Foreground(lipgloss.Color("0")).
-			Background(lipgloss.Color("6"))
-
-	deadTabStyle = lipgloss.NewStyle().
-			Padding(0, 1).
-			Foreground(lipgloss.Color("8")).
-			Strikethrough(true)
-
-	prefixIndicator = lipgloss.NewStyle().
-			Padding(0, 1).
-			Bold(true).
-			Foreground(lipgloss.Color("3")).
-			Render("PREFIX")
-
-	helpHint = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("8"))
-)
-
 // renderStatusBar produces the bottom status bar showing tabs and mode info.
 func renderStatusBar(tabs []*LayoutNode, activeTab int, focusedID int, prefixMode bool, mouseEnabled bool, width int) string {
+	st := currentStyles()
 	var parts []string
 
 	for i, tab := range tabs {
@@ -47,11 +24,11 @@ func renderStatusBar(tabs []*LayoutNode, activeTab int, focusedID int, prefixMod
 
 		switch {
 		case i == activeTab:
-			parts = append(parts, activeTabStyle.Render(name))
+			parts = append(parts, st.activeTab.Render(name))
 		case allDead(panes):
-			parts = append(parts, deadTabStyle.Render(name))
+			parts = append(parts, st.deadTab.Render(name))
 		default:
-			parts = append(parts, tabStyle.Render(name))
+			parts = append(parts, st.tab.Render(name))
 		}
 	}
 
@@ -61,9 +38,9 @@ func renderStatusBar(tabs []*LayoutNode, activeTab int, focusedID int, prefixMod
 	if !mouseEnabled {
 		hint = "mouse:off  Ctrl+B ? help"
 	}
-	right := helpHint.Render(hint)
+	right := st.dim.Render(hint)
 	if prefixMode {
-		right = prefixIndicator
+		right = st.prefix.Render("PREFIX")
 	}
 
 	gap := width - lipgloss.Width(left) - lipgloss.Width(right)
@@ -92,26 +69,27 @@ func allDead(panes []*TermPane) bool {
 //	◈  listeners
 //	⇌  pipelines
 func renderSidebar(tabs []*LayoutNode, activeTab int, focusedID int, state SidebarState, width, height int) string {
-	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-	cyan := lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
-	green := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
-	yellow := lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
-	purple := lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+	st := currentStyles()
+	dim := st.dim
+	cyan := st.title
+	green := st.success
+	yellow := st.warning
+	purple := st.special
 
 	var lines []string
 
 	// Title + starship-style status in one compact block
-	title := cyan.Render(" ◆ IoM")
+	title := cyan.Render(" " + term.Glyph("◆", "#") + " IoM")
 	lines = append(lines, title)
 
 	// Status line: ◆ 3/5  ◈ 2  ⇌ 1
 	status := fmt.Sprintf(" %s %s  %s %s  %s %s",
-		green.Render("◆"), green.Render(fmt.Sprintf("%d/%d", state.SessionAlive, state.SessionTotal)),
-		yellow.Render("◈"), yellow.Render(fmt.Sprintf("%d", state.ListenerCount)),
-		purple.Render("⇌"), purple.Render(fmt.Sprintf("%d", state.PipelineCount)),
+		green.Render(term.Glyph("◆", "#")), green.Render(fmt.Sprintf("%d/%d", state.SessionAlive, state.SessionTotal)),
+		yellow.Render(term.Glyph("◈", "@")), yellow.Render(fmt.Sprintf("%d", state.ListenerCount)),
+		purple.Render(term.Glyph("⇌", "=")), purple.Render(fmt.Sprintf("%d", state.PipelineCount)),
 	)
 	lines = append(lines, status)
-	lines = append(lines, dim.Render(strings.Repeat("─", width)))
+	lines = append(lines, dim.Render(strings.Repeat(term.Glyph("─", "-"), width)))
 
 	// Console section header
 	lines = append(lines, lipgloss.NewStyle().Bold(true).Width(width).Render("  Consoles"))
@@ -122,19 +100,17 @@ func renderSidebar(tabs []*LayoutNode, activeTab int, focusedID int, state Sideb
 		for _, p := range panes {
 			prefix := "  "
 			if p.ID() == focusedID {
-				prefix = "► "
+				prefix = term.Glyph("► ", "> ")
 			}
 
 			name := p.Name()
 			if p.IsDead() {
-				name += " ✗"
+				name += " " + term.Glyph("✗", "x")
 			}
 
 			style := lipgloss.NewStyle().Width(width)
 			if i == activeTab && p.ID() == focusedID {
-				style = style.
-					Foreground(lipgloss.Color("0")).
-					Background(lipgloss.Color("6"))
+				style = st.selected.Width(width)
 			}
 			lines = append(lines, style.Render(prefix+name))
 		}
@@ -142,14 +118,14 @@ func renderSidebar(tabs []*LayoutNode, activeTab int, focusedID int, state Sideb
 
 	// Session list
 	if len(state.Sessions) > 0 {
-		lines = append(lines, dim.Render(strings.Repeat("─", width)))
+		lines = append(lines, dim.Render(strings.Repeat(term.Glyph("─", "-"), width)))
 		lines = append(lines, lipgloss.NewStyle().Bold(true).Width(width).Render("  Sessions"))
 		for _, s := range state.Sessions {
-			indicator := green.Render("●")
+			indicator := green.Render(term.Glyph("●", "*"))
 			age := green.Render(s.LastSeen)
 			if !s.Alive {
-				indicator = dim.Render("○")
-				age = dim.Render("✗")
+				indicator = dim.Render(term.Glyph("○", "o"))
+				age = dim.Render(term.Glyph("✗", "x"))
 			}
 			// Truncate name to fit sidebar
 			name := s.Name
@@ -158,7 +134,7 @@ func renderSidebar(tabs []*LayoutNode, activeTab int, focusedID int, state Sideb
 				maxName = 4
 			}
 			if len(name) > maxName {
-				name = name[:maxName-1] + "…"
+				name = name[:maxName-1] + term.Glyph("…", "~")
 			}
 			entry := fmt.Sprintf(" %s %-*s %s %s", indicator, maxName, name, dim.Render(s.OS), age)
 			lines = append(lines, entry)
@@ -171,7 +147,7 @@ func renderSidebar(tabs []*LayoutNode, activeTab int, focusedID int, state Sideb
 	}
 
 	// Keybinding hints at bottom.
-	lines = append(lines, dim.Render(strings.Repeat("─", width)))
+	lines = append(lines, dim.Render(strings.Repeat(term.Glyph("─", "-"), width)))
 	hints := dim.Width(width).Render("c:new s:sess ?:help")
 	lines = append(lines, hints)
 
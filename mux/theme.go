@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"sync"
+
+	"github.com/chainreactors/tui/term"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// muxStyles holds every style the mux renders with, derived from the shared
+// term theme so the status bar, sidebar and overlays follow the same palette
+// as the rest of the UI.
+type muxStyles struct {
+	palette term.Palette
+
+	tab       lipgloss.Style
+	activeTab lipgloss.Style
+	deadTab   lipgloss.Style
+	prefix    lipgloss.Style
+	dim       lipgloss.Style
+	text      lipgloss.Style
+	title     lipgloss.Style
+	success   lipgloss.Style
+	warning   lipgloss.Style
+	special   lipgloss.Style
+	selected  lipgloss.Style
+	border    lipgloss.Style
+}
+
+var (
+	stylesMu sync.RWMutex
+	styles   = newMuxStyles(term.CurrentPalette())
+)
+
+// asciiBorder is used on terminals that cannot draw box-drawing runes.
+var asciiBorder = lipgloss.Border{
+	Top: "-", Bottom: "-", Left: "|", Right: "|",
+	TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+	MiddleLeft: "+", MiddleRight: "+", Middle: "+", MiddleTop: "+", MiddleBottom: "+",
+}
+
+func init() {
+	term.OnThemeChange(applyTheme)
+}
+
+// applyTheme rebuilds the mux styles from the current term palette. New calls
+// it so the background is detected before the program takes over stdin.
+func applyTheme() {
+	s := newMuxStyles(term.CurrentPalette())
+	stylesMu.Lock()
+	styles = s
+	stylesMu.Unlock()
+}
+
+func currentStyles() muxStyles {
+	stylesMu.RLock()
+	defer stylesMu.RUnlock()
+	return styles
+}
+
+func newMuxStyles(p term.Palette) muxStyles {
+	border := lipgloss.RoundedBorder()
+	if !term.CurrentCapabilities().Unicode {
+		border = asciiBorder
+	}
+	fg := func(c term.ThemeColor) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+	}
+	return muxStyles{
+		palette: p,
+		tab:     lipgloss.NewStyle().Padding(0, 1),
+		activeTab: lipgloss.NewStyle().
+			Padding(0, 1).
+			Bold(true).
+			Foreground(lipgloss.Color(p.AccentText)).
+			Background(lipgloss.Color(p.Accent)),
+		deadTab: fg(p.Muted).
+			Padding(0, 1).
+			Strikethrough(true),
+		prefix: fg(p.Warning).
+			Padding(0, 1).
+			Bold(true),
+		dim:     fg(p.Muted),
+		text:    fg(p.Text),
+		title:   fg(p.Accent).Bold(true),
+		success: fg(p.Success),
+		warning: fg(p.Warning),
+		special: fg(p.Special),
+		selected: lipgloss.NewStyle().
+			Foreground(lipgloss.Color(p.AccentText)).
+			Background(lipgloss.Color(p.Accent)),
+		border: lipgloss.NewStyle().
+			Border(border).
+			BorderForeground(lipgloss.Color(p.Accent)).
+			Padding(1, 2),
+	}
+}
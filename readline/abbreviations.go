@@ -0,0 +1,195 @@
+package readline
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/chainreactors/tui/readline/inputrc"
+)
+
+// Abbreviations is a table of words expanded in the input line when they are followed
+// by a space, or end the line when it is accepted, like fish abbreviations. Unlike aliases
+// resolved when running commands, the expanded command can be read and edited before
+// it runs. Undoing right after an expansion reverts it.
+//
+// Only words in command position are expanded: at the beginning of the line, or after
+// a |, ;, & or an opening parenthesis, outside of quotes. In an expansion, the abbreviation-cursor option
+// (%| by default) marks the position of the cursor once expanded.
+//
+// Abbreviations can be defined in code, or read from files in the inputrc syntax,
+// where they are written like macros: "gco": "git checkout". Other binds are ignored.
+type Abbreviations struct {
+	mutex sync.RWMutex
+	table map[string]string
+}
+
+// Set defines an abbreviation, which is a single word.
+func (a *Abbreviations) Set(word, expansion string) error {
+	if word == "" || strings.IndexFunc(word, unicode.IsSpace) != -1 {
+		return fmt.Errorf("invalid abbreviation: %q", word)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.table == nil {
+		a.table = make(map[string]string)
+	}
+
+	a.table[word] = expansion
+
+	return nil
+}
+
+// Delete removes an abbreviation, and returns false if it was not defined.
+func (a *Abbreviations) Delete(word string) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	_, found := a.table[word]
+	delete(a.table, word)
+
+	return found
+}
+
+// Get returns the expansion of an abbreviation.
+func (a *Abbreviations) Get(word string) (expansion string, found bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	expansion, found = a.table[word]
+
+	return expansion, found
+}
+
+// List returns a copy of all abbreviations and their expansions.
+func (a *Abbreviations) List() map[string]string {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	list := make(map[string]string, len(a.table))
+	for word, expansion := range a.table {
+		list[word] = expansion
+	}
+
+	return list
+}
+
+// Parse reads abbreviations written in the inputrc syntax ("gco": "git checkout").
+// Conditional constructs and includes are supported, and other statements ignored.
+func (a *Abbreviations) Parse(r io.Reader, opts ...inputrc.Option) error {
+	return inputrc.Parse(r, &abbreviationsHandler{a}, opts...)
+}
+
+// ReadFile reads abbreviations from a file written in the inputrc syntax.
+func (a *Abbreviations) ReadFile(name string, opts ...inputrc.Option) error {
+	return inputrc.ParseFile(name, &abbreviationsHandler{a}, opts...)
+}
+
+// abbreviationsHandler defines the key sequences bound in an inputrc file as abbreviations.
+type abbreviationsHandler struct {
+	abbreviations *Abbreviations
+}
+
+func (h *abbreviationsHandler) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+func (h *abbreviationsHandler) Do(string, string) error              { return nil }
+func (h *abbreviationsHandler) Set(string, interface{}) error        { return nil }
+func (h *abbreviationsHandler) Get(string) interface{}               { return nil }
+
+// Bind defines macros as abbreviations, and ignores binds to commands.
+func (h *abbreviationsHandler) Bind(_, word, expansion string, macro bool) error {
+	if !macro {
+		return nil
+	}
+
+	return h.abbreviations.Set(word, expansion)
+}
+
+// expandAbbreviation expands the word ending at some position in the line if it is
+// an abbreviation, when a space has just been inserted after it or when the line is
+// accepted (the last word). The line before the expansion is saved for undo.
+func (rl *Shell) expandAbbreviation(end int, spaced bool) {
+	line := *rl.line
+
+	if end < 0 || end > len(line) || (end < len(line) && !unicode.IsSpace(line[end])) {
+		return
+	}
+
+	start := end
+	for start > 0 && !unicode.IsSpace(line[start-1]) {
+		start--
+	}
+
+	if start == end || !commandPosition(line, start) {
+		return
+	}
+
+	expansion, found := rl.Abbreviations.Get(string(line[start:end]))
+	if !found {
+		return
+	}
+
+	// Save the line as typed: the expanded one is saved once the command is done.
+	rl.History.Reset()
+	rl.History.Save()
+
+	before, after, placed := expansion, "", false
+	if marker := rl.Config.GetString("abbreviation-cursor"); marker != "" {
+		before, after, placed = strings.Cut(expansion, marker)
+	}
+
+	// The cursor is placed in the expansion, instead of after the space.
+	if placed && spaced {
+		end++
+	}
+
+	expanded := []rune(before + after)
+	cpos := rl.cursor.Pos()
+
+	switch {
+	case placed:
+		cpos = start + len([]rune(before))
+	case cpos >= end:
+		cpos += len(expanded) - (end - start)
+	case cpos > start:
+		cpos = start + len(expanded)
+	}
+
+	rl.line.Cut(start, end)
+	rl.line.Insert(start, expanded...)
+	rl.cursor.Set(cpos)
+}
+
+// commandPosition returns true if the word at some position in the
+// line is the first one of the line, or of a command in a pipeline,
+// a list of commands or a subshell. Quoted and escaped separators
+// are part of words, and words in quotes are never commands.
+func commandPosition(line []rune, pos int) bool {
+	var quote rune
+
+	escaped, command := false, true
+
+	for _, r := range line[:pos] {
+		switch {
+		case escaped:
+			escaped, command = false, false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote, command = r, false
+		case unicode.IsSpace(r):
+		default:
+			command = strings.ContainsRune("|;&(", r)
+		}
+	}
+
+	return quote == 0 && !escaped && command
+}
@@ -0,0 +1,81 @@
+package readline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+func TestAbbreviations(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"space", "gco main\r", "git checkout main"},
+		{"accept", "ls | gco\r", "ls | git checkout"},
+		{"argument", "echo gco \r", "echo gco "},
+		{"unknown", "gcox \r", "gcox "},
+		{"cursor", "gcm fix\r", `git commit -m "fix"`},
+		{"undo", "gco \x1f\r", "gco "},
+		{"quoted separator", "echo \"a | gco \"\r", `echo "a | gco "`},
+		{"after quotes", "echo 'a' ; gco \r", "echo 'a' ; git checkout "},
+		{"escaped separator", "echo \\; gco \r", `echo \; gco `},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			terminal := rlterm.Stream(strings.NewReader(test.input), &out, &out, rlterm.NewControl(false, 80, 24))
+
+			rl := NewShellWithTerminal(terminal)
+			rl.Abbreviations.Set("gco", "git checkout")
+			rl.Abbreviations.Set("gcm", `git commit -m "%|"`)
+
+			line, err := rl.Readline()
+			if err != nil {
+				t.Fatalf("Readline() error = %v", err)
+			}
+
+			if line != test.want {
+				t.Fatalf("line = %q, want %q", line, test.want)
+			}
+		})
+	}
+}
+
+func TestAbbreviationsParse(t *testing.T) {
+	var abbreviations Abbreviations
+
+	file := `
+"gco": "git checkout"
+"gl": history-search-backward
+$if mode=vi
+"gp": "git push"
+$endif
+set editing-mode vi
+`
+
+	if err := abbreviations.Parse(strings.NewReader(file)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if expansion, _ := abbreviations.Get("gco"); expansion != "git checkout" {
+		t.Fatalf("gco = %q, want it read from the file", expansion)
+	}
+
+	if _, found := abbreviations.Get("gl"); found {
+		t.Fatal("gl defined, want binds to commands ignored")
+	}
+
+	if _, found := abbreviations.Get("gp"); found {
+		t.Fatal("gp defined, want conditional constructs applied")
+	}
+
+	if err := abbreviations.Set("git co", "git checkout"); err == nil {
+		t.Fatal("Set() defined an abbreviation of several words")
+	}
+}
@@ -241,6 +241,7 @@ func (rl *Shell) commandCompletion() completion.Values {
 
 	line, cursor := rl.completer.Line()
 	comps := rl.Completer(*line, cursor.Pos())
+	comps = rl.completeAsync(comps, *line, cursor.Pos())
 
 	return comps.convert()
 }
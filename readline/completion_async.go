@@ -0,0 +1,261 @@
+package readline
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chainreactors/tui/readline/internal/color"
+	"github.com/chainreactors/tui/readline/internal/term"
+)
+
+// asyncSpinnerInterval is the delay between two frames of the loading indicator.
+const asyncSpinnerInterval = 100 * time.Millisecond
+
+var asyncSpinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// CompleteAsync returns completions generated in the background by a
+// completer, for instance one querying a remote server, so that the
+// shell keeps reading keys in the meantime. A loading indicator is
+// shown in the hint area until the completer returns, and its results
+// are merged into the displayed completions.
+//
+// The context is canceled when the results are not needed anymore:
+// because the input line has changed, or the shell has returned.
+// Asynchronous completions can be merged with others, synchronous
+// or not:
+//
+//	CompleteValues("local").Merge(CompleteAsync(remoteSessions))
+//
+// The results of completed requests can be cached for a given line
+// with the completion-cache-ttl option (in milliseconds).
+func CompleteAsync(completer func(ctx context.Context) Completions) Completions {
+	return Completions{async: []func(ctx context.Context) Completions{completer}}
+}
+
+// asyncCompletions manages the background completers of the shell:
+// the request for the current line, and the results cache.
+type asyncCompletions struct {
+	mutex   sync.Mutex
+	current *asyncRequest
+	cache   map[string]asyncResults
+}
+
+// asyncRequest is the set of background completers started for a line.
+type asyncRequest struct {
+	key     string
+	cancel  context.CancelFunc
+	pending int
+	results []Completions
+	frame   int
+}
+
+type asyncResults struct {
+	results []Completions
+	expires time.Time
+}
+
+// completeAsync starts the background completers of comps for the current line,
+// unless they are already running or their results are cached for it, and adds
+// the results already available to comps, or a loading indicator if some are not.
+func (rl *Shell) completeAsync(comps Completions, line []rune, cursor int) Completions {
+	key := strconv.Itoa(cursor) + ":" + string(line)
+	async := comps.async
+	comps.async = nil
+
+	rl.async.mutex.Lock()
+	defer rl.async.mutex.Unlock()
+
+	req := rl.async.current
+
+	if len(async) == 0 {
+		rl.async.cancel()
+		return comps
+	}
+
+	if req == nil || req.key != key {
+		rl.async.cancel()
+
+		if cached, found := rl.async.cache[key]; found && time.Now().Before(cached.expires) {
+			return mergeAsync(comps, cached.results)
+		}
+
+		req = rl.startAsync(key, async)
+	}
+
+	comps = mergeAsync(comps, req.results)
+
+	if req.pending > 0 {
+		comps.pending = true
+		comps.loading = asyncLoading(req.frame)
+	}
+
+	return comps
+}
+
+// asyncLoading returns the loading indicator of a frame of its animation.
+func asyncLoading(frame int) string {
+	return color.FgBlue + asyncSpinner[frame%len(asyncSpinner)] + color.Reset + color.Dim + " loading completions"
+}
+
+// startAsync runs background completers for a line, and the loading
+// indicator animation. The async mutex must be held by the caller.
+func (rl *Shell) startAsync(key string, completers []func(ctx context.Context) Completions) *asyncRequest {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req := &asyncRequest{
+		key:     key,
+		cancel:  cancel,
+		pending: len(completers),
+	}
+
+	rl.async.current = req
+
+	for _, completer := range completers {
+		go func(completer func(ctx context.Context) Completions) {
+			comps := completer(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			rl.async.mutex.Lock()
+			comps.async = nil
+			req.results = append(req.results, comps)
+			req.pending--
+
+			if req.pending == 0 {
+				cancel()
+				rl.async.store(req, rl.Config.GetInt("completion-cache-ttl"))
+			}
+			rl.async.mutex.Unlock()
+
+			rl.updateAsync(req, rl.Display.RefreshCompletions)
+		}(completer)
+	}
+
+	go func() {
+		ticker := time.NewTicker(asyncSpinnerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rl.async.mutex.Lock()
+				req.frame++
+				loading := asyncLoading(req.frame)
+				rl.async.mutex.Unlock()
+
+				rl.updateAsync(req, func() { rl.Display.RefreshLoading(loading) })
+			}
+		}
+	}()
+
+	return req
+}
+
+// updateAsync redisplays the completions of a request with refresh:
+// with its new results, or its loading indicator. This is only done if
+// they are still those of the line and the shell is idle, waiting for
+// keys: otherwise the results are used next time completions are
+// generated for this line.
+func (rl *Shell) updateAsync(req *asyncRequest, refresh func()) {
+	rl.loop.Lock()
+	defer rl.loop.Unlock()
+
+	rl.async.mutex.Lock()
+	current := rl.async.current == req
+	rl.async.mutex.Unlock()
+
+	if !current || !rl.idle {
+		return
+	}
+
+	restore := term.Activate(rl.Terminal.Out, rl.Terminal.Control)
+	defer restore()
+
+	refresh()
+}
+
+// cancelStaleAsync cancels the background completers started
+// for another line than the current one, if any.
+func (rl *Shell) cancelStaleAsync() {
+	line, cursor := rl.completer.Line()
+	key := strconv.Itoa(cursor.Pos()) + ":" + string(*line)
+
+	rl.async.mutex.Lock()
+	defer rl.async.mutex.Unlock()
+
+	if rl.async.current != nil && rl.async.current.key != key {
+		rl.async.cancel()
+	}
+}
+
+// cancelAsync cancels any running background completers.
+func (rl *Shell) cancelAsync() {
+	rl.async.mutex.Lock()
+	defer rl.async.mutex.Unlock()
+
+	rl.async.cancel()
+}
+
+// cancel cancels the current request. The mutex must be held.
+func (a *asyncCompletions) cancel() {
+	if a.current != nil {
+		a.current.cancel()
+		a.current = nil
+	}
+}
+
+// store caches the results of a completed request for ttl milliseconds,
+// and drops expired ones. The mutex must be held.
+func (a *asyncCompletions) store(req *asyncRequest, ttl int) {
+	if ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	for key, cached := range a.cache {
+		if now.After(cached.expires) {
+			delete(a.cache, key)
+		}
+	}
+
+	if a.cache == nil {
+		a.cache = make(map[string]asyncResults)
+	}
+
+	a.cache[req.key] = asyncResults{
+		results: req.results,
+		expires: now.Add(time.Duration(ttl) * time.Millisecond),
+	}
+}
+
+// mergeAsync adds the results of background completers to completions,
+// leaving the values and settings of the completer ones untouched.
+func mergeAsync(comps Completions, results []Completions) Completions {
+	if len(results) == 0 {
+		return comps
+	}
+
+	merged := Completions{
+		values:   comps.values[:len(comps.values):len(comps.values)],
+		usage:    comps.usage,
+		matching: comps.matching,
+		escapes:  comps.escapes,
+		PREFIX:   comps.PREFIX,
+		SUFFIX:   comps.SUFFIX,
+	}
+
+	merged.merge(comps)
+
+	for _, other := range results {
+		merged.values = append(merged.values, other.values...)
+		merged.merge(other)
+	}
+
+	return merged
+}
@@ -0,0 +1,239 @@
+package readline
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainreactors/tui/readline/internal/display"
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+func newAsyncTestShell(t *testing.T, completer func(ctx context.Context, line string) Completions) *Shell {
+	t.Helper()
+
+	var output bytes.Buffer
+	terminal := rlterm.Stream(strings.NewReader(""), &output, &output, rlterm.NewControl(false, 80, 24))
+	rl := NewShellWithTerminal(terminal)
+
+	rl.Completer = func(line []rune, _ int) Completions {
+		return CompleteValues("local").Merge(CompleteAsync(func(ctx context.Context) Completions {
+			return completer(ctx, string(line))
+		}))
+	}
+
+	return rl
+}
+
+// completeLine returns the completions of a line, with the available asynchronous results.
+func completeLine(rl *Shell, line string) Completions {
+	rl.line.Set([]rune(line)...)
+	rl.cursor.Set(len(line))
+
+	return rl.completeAsync(rl.Completer(*rl.line, rl.cursor.Pos()), *rl.line, rl.cursor.Pos())
+}
+
+func completionValues(comps Completions) (values []string) {
+	for _, val := range comps.values {
+		values = append(values, val.Value)
+	}
+
+	return values
+}
+
+func waitAsync(t *testing.T, rl *Shell) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		rl.async.mutex.Lock()
+		done := rl.async.current == nil || rl.async.current.pending == 0
+		rl.async.mutex.Unlock()
+
+		if done {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("asynchronous completions did not finish")
+}
+
+func TestCompleteAsync(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	rl := newAsyncTestShell(t, func(_ context.Context, line string) Completions {
+		calls.Add(1)
+		<-release
+		return CompleteValues("remote-" + line)
+	})
+
+	comps := completeLine(rl, "s")
+	if got := completionValues(comps); len(got) != 1 || got[0] != "local" {
+		t.Fatalf("pending completions = %q, want only the local ones", got)
+	}
+	if !strings.Contains(comps.loading, "loading completions") {
+		t.Fatalf("pending completions loading = %q, want a loading indicator", comps.loading)
+	}
+
+	close(release)
+	waitAsync(t, rl)
+
+	comps = completeLine(rl, "s")
+	if got := strings.Join(completionValues(comps), ","); got != "local,remote-s" {
+		t.Fatalf("completions = %q, want local,remote-s", got)
+	}
+	if !comps.messages.IsEmpty() {
+		t.Fatalf("done completions messages = %q", comps.messages.Get())
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("asynchronous completer called %d times for the same line, want 1", calls.Load())
+	}
+}
+
+func TestCompleteAsyncCancel(t *testing.T) {
+	canceled := make(chan string, 2)
+
+	rl := newAsyncTestShell(t, func(ctx context.Context, line string) Completions {
+		<-ctx.Done()
+		canceled <- line
+		return Completions{}
+	})
+
+	completeLine(rl, "s")
+	completeLine(rl, "se")
+
+	select {
+	case line := <-canceled:
+		if line != "s" {
+			t.Fatalf("canceled request of line %q, want s", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stale request not canceled when the line changed")
+	}
+
+	rl.line.Set([]rune("sessions")...)
+	rl.cursor.Set(8)
+	rl.cancelStaleAsync()
+
+	select {
+	case line := <-canceled:
+		if line != "se" {
+			t.Fatalf("canceled request of line %q, want se", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stale request not canceled after a line edit")
+	}
+}
+
+func TestCompleteAsyncCache(t *testing.T) {
+	var calls atomic.Int32
+
+	rl := newAsyncTestShell(t, func(_ context.Context, line string) Completions {
+		calls.Add(1)
+		return CompleteValues("remote-" + line)
+	})
+
+	rl.Config.Set("completion-cache-ttl", 60000)
+
+	completeLine(rl, "a")
+	waitAsync(t, rl)
+	completeLine(rl, "b")
+	waitAsync(t, rl)
+
+	comps := completeLine(rl, "a")
+	if got := strings.Join(completionValues(comps), ","); got != "local,remote-a" {
+		t.Fatalf("cached completions = %q, want local,remote-a", got)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("asynchronous completer called %d times, want 2 (a and b)", calls.Load())
+	}
+
+	rl.Config.Set("completion-cache-ttl", 0)
+	rl.async.cache = nil
+
+	completeLine(rl, "b")
+	waitAsync(t, rl)
+
+	if calls.Load() != 3 {
+		t.Fatalf("asynchronous completer called %d times without cache, want 3", calls.Load())
+	}
+}
+
+// asyncOutput is written to by background completers while the test reads it.
+type asyncOutput struct {
+	mutex sync.Mutex
+	out   bytes.Buffer
+}
+
+func (o *asyncOutput) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.out.Write(p)
+}
+
+func (o *asyncOutput) String() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.out.String()
+}
+
+func TestCompleteAsyncUpdates(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	var output asyncOutput
+	terminal := rlterm.Stream(strings.NewReader(""), &output, &output, rlterm.NewControl(false, 80, 24))
+	rl := NewShellWithTerminal(terminal)
+	rl.Config.Set("autocomplete", true)
+	rl.Completer = func(line []rune, _ int) Completions {
+		calls.Add(1)
+		return CompleteValues("sync").Merge(CompleteAsync(func(context.Context) Completions {
+			<-release
+			return CompleteValues("sessions")
+		}))
+	}
+	rl.line.Set([]rune("s")...)
+	rl.cursor.Set(1)
+	display.Init(rl.Display, nil, nil)
+
+	rl.loop.Lock()
+	rl.idle = true
+	rl.loop.Unlock()
+
+	rl.Refresh()
+
+	// The loading indicator is animated without generating the completions again.
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), asyncSpinner[2]) {
+		if time.Now().After(deadline) {
+			t.Fatal("loading indicator not animated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("completer called %d times while loading, want 1", calls.Load())
+	}
+
+	// The results of the background completer are displayed once they arrive.
+	close(release)
+	waitAsync(t, rl)
+
+	for !strings.Contains(output.String(), "sessions") {
+		if time.Now().After(deadline) {
+			t.Fatal("asynchronous completions not displayed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("completer called %d times, want 2 (start and results)", calls.Load())
+	}
+}
@@ -0,0 +1,73 @@
+package readline
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+func readPreview(t *testing.T, width int, position string) (output string, previewed []string) {
+	t.Helper()
+
+	var out bytes.Buffer
+	terminal := rlterm.Stream(strings.NewReader("\x1b=\t\r"), &out, &out, rlterm.NewControl(false, width, 24))
+	rl := NewShellWithTerminal(terminal)
+	_ = rl.Config.Set("completion-preview", position)
+
+	rl.Completer = func(_ []rune, _ int) Completions {
+		return CompleteValues("session-1", "session-2").Preview(func(value string, width, height int) string {
+			previewed = append(previewed, value)
+			return "details of " + value + "\n" + strings.Repeat("x", width+10)
+		})
+	}
+
+	if _, err := rl.Readline(); err != nil && !errors.Is(err, io.EOF) {
+		t.Fatalf("Readline() error = %v", err)
+	}
+
+	return out.String(), previewed
+}
+
+func TestCompletionPreview(t *testing.T) {
+	output, previewed := readPreview(t, 80, "auto")
+
+	if !strings.Contains(output, "details of session-1") {
+		t.Fatalf("preview of the selected candidate not displayed:\n%q", output)
+	}
+	if !strings.Contains(output, "───") {
+		t.Fatalf("narrow terminal preview not displayed below the completions:\n%q", output)
+	}
+	if strings.Contains(output, strings.Repeat("x", 80)) {
+		t.Fatal("preview lines not cut to the terminal width")
+	}
+
+	for _, value := range previewed {
+		if value != "session-1" {
+			t.Fatalf("preview generated for %q, which was never selected", value)
+		}
+	}
+
+	if len(previewed) != 1 {
+		t.Fatalf("preview of session-1 generated %d times, want 1", len(previewed))
+	}
+}
+
+func TestCompletionPreviewRight(t *testing.T) {
+	output, _ := readPreview(t, 160, "auto")
+
+	if !strings.Contains(output, "\x1b[80C\x1b[2m│") {
+		t.Fatalf("wide terminal preview not displayed on the right:\n%q", output)
+	}
+}
+
+func TestCompletionPreviewOff(t *testing.T) {
+	output, previewed := readPreview(t, 80, "off")
+
+	if len(previewed) > 0 || strings.Contains(output, "details of") {
+		t.Fatal("preview displayed with completion-preview off")
+	}
+}
@@ -1,6 +1,7 @@
 package readline
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/chainreactors/tui/readline/internal/completion"
@@ -9,6 +10,14 @@ import (
 // Completion represents a completion candidate.
 type Completion = completion.Candidate
 
+// Strategies used to match completions against the word being completed.
+const (
+	MatchPrefix    = completion.MatchPrefix    // Candidates start with the word (default).
+	MatchSubstring = completion.MatchSubstring // Candidates contain the word.
+	MatchFuzzy     = completion.MatchFuzzy     // Candidates contain the word characters in order.
+	MatchCamel     = completion.MatchCamel     // Word characters start words of candidates: "gsi" for "get-session-id".
+)
+
 // Completions holds all completions candidates and their associated data,
 // including usage strings, messages, and suffix matchers for autoremoval.
 // Some of those additional settings will apply to all contained candidates,
@@ -23,6 +32,10 @@ type Completions struct {
 	listSep  map[string]string
 	pad      map[string]bool
 	escapes  map[string]bool
+	matching string
+	async    []func(ctx context.Context) Completions
+	pending  bool
+	loading  string
 
 	// Initially this will be set to the part of the current word
 	// from the beginning of the word up to the position of the cursor.
@@ -384,6 +397,38 @@ func (c Completions) PreserveEscapes(tags ...string) Completions {
 	return c
 }
 
+// Matching sets the strategy used to match these completions against the
+// word being completed, overriding the completion-matching inputrc option:
+// MatchPrefix, MatchSubstring, MatchFuzzy or MatchCamel. Except with prefix
+// matching, candidates are ranked from the best to the worst match (unless
+// NoSort is used), and their matched characters are highlighted.
+//
+//	CompleteValues(sessionIDs...).Matching(readline.MatchFuzzy)
+func (c Completions) Matching(mode string) Completions {
+	c.matching = mode
+	return c
+}
+
+// Preview sets a function returning the content of a preview pane, displayed
+// with the completions when one of these candidates is selected: file heads,
+// session details, help text, etc. It is only called for the candidate being
+// selected, with the columns and rows available to the pane, and its content
+// (which can be colored) is cut to fit in them.
+//
+// The pane is displayed on the right of the completions, or below them in
+// narrow terminals, depending on the completion-preview inputrc option.
+//
+//	CompleteValues(sessionIDs...).Preview(func(id string, width, height int) string {
+//		return sessions[id].Details()
+//	})
+func (c Completions) Preview(preview func(value string, width, height int) string) Completions {
+	for index := range c.values {
+		c.values[index].SetPreview(preview)
+	}
+
+	return c
+}
+
 // Merge merges Completions (existing values are overwritten)
 //
 //	a := CompleteValues("A", "B").Invoke(c)
@@ -424,9 +469,31 @@ func (c *Completions) merge(other Completions) {
 		c.usage = other.usage
 	}
 
+	if other.matching != "" {
+		c.matching = other.matching
+	}
+
+	c.async = append(c.async, other.async...)
+
 	c.noSpace.Merge(other.noSpace)
 	c.messages.Merge(other.messages)
 
+	if c.listLong == nil {
+		c.listLong = make(map[string]bool)
+	}
+
+	if c.noSort == nil {
+		c.noSort = make(map[string]bool)
+	}
+
+	if c.listSep == nil {
+		c.listSep = make(map[string]string)
+	}
+
+	if c.pad == nil {
+		c.pad = make(map[string]bool)
+	}
+
 	for tag := range other.listLong {
 		if _, found := c.listLong[tag]; !found {
 			c.listLong[tag] = true
@@ -463,6 +530,9 @@ func (c *Completions) convert() completion.Values {
 	comps.ListSep = c.listSep
 	comps.Pad = c.pad
 	comps.Escapes = c.escapes
+	comps.Matching = c.matching
+	comps.Pending = c.pending
+	comps.Loading = c.loading
 
 	comps.PREFIX = c.PREFIX
 	comps.SUFFIX = c.SUFFIX
@@ -450,6 +450,11 @@ func (rl *Shell) selfInsert() {
 	rl.cursor.InsertAt(quoted...)
 	rl.cursor.Move(-1 * len(quoted))
 	rl.cursor.Move(length)
+
+	// Expand the abbreviation followed by the space just inserted, if any.
+	if key[0] == inputrc.Space && !searching && !isearch {
+		rl.expandAbbreviation(rl.cursor.Pos()-1, true)
+	}
 }
 
 func (rl *Shell) bracketedPasteBegin() {
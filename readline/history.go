@@ -21,6 +21,10 @@ type History = history.Source
 // NewHistoryFromFile creates a new command history source writing to and reading
 // from a file. The caller should bind the history source returned from this call
 // to the readline instance, with shell.History.Add().
+// The file can be shared by several shells: lines are appended while holding a lock
+// on it, and those written by other shells are reloaded at each prompt, as specified
+// by the history-share inputrc option ("on", "local" to list them before the lines
+// of this shell, or "off"). The history-size option limits the lines kept in it.
 var NewHistoryFromFile = history.NewSourceFromFile
 
 // NewInMemoryHistory creates a new in-memory command history source.
@@ -28,6 +32,34 @@ var NewHistoryFromFile = history.NewSourceFromFile
 // to the readline instance, with shell.History.Add().
 var NewInMemoryHistory = history.NewInMemoryHistory
 
+// HistoryStore is a history source recording, along with command lines, the time,
+// duration and exit status of their commands, the menu, context (session, target...)
+// and working directory they ran in. Its entries can be filtered, deduplicated, and
+// imported from or exported to bash and zsh history files. Views of the store, like
+// the entries of a given menu, are history sources as well.
+type HistoryStore = history.Store
+
+// HistoryEntry is a command line recorded in a history store, with its context.
+type HistoryEntry = history.Entry
+
+// HistoryFilter selects history entries by context, content or date.
+type HistoryFilter = history.Filter
+
+// NewHistoryStore creates a new history store, with the entries of a file to which
+// new ones are appended (as JSON lines), or only kept in memory if file is empty.
+// Files of NewHistoryFromFile sources can be opened as stores.
+var NewHistoryStore = history.NewStore
+
+// Exit status of history entries whose command has not
+// finished, or whose result is not known (imported ones).
+const HistoryStatusUnknown = history.StatusUnknown
+
+// Formats of shell history files, to import and export history store entries.
+const (
+	HistoryFormatBash = history.FormatBash
+	HistoryFormatZsh  = history.FormatZsh
+)
+
 // historyCommands returns all history commands.
 // Under each comment are gathered all commands related to the comment's
 // subject. When there are two subgroups separated by an empty line, the
@@ -71,6 +103,7 @@ func (rl *Shell) historyCommands() commands {
 		"save-line":                          rl.saveLine,
 		"history-source-next":                rl.historySourceNext,
 		"history-source-prev":                rl.historySourcePrev,
+		"history-browse":                     rl.historyBrowse,
 		"autosuggest-accept":                 rl.autosuggestAccept,
 		"autosuggest-execute":                rl.autosuggestExecute,
 		"autosuggest-enable":                 rl.autosuggestEnable,
@@ -650,6 +683,9 @@ func (rl *Shell) acceptLineWith(infer, hold bool) {
 	// Use the correct buffer for the rest of the function.
 	rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
 
+	// The last word of the line might be an abbreviation.
+	rl.expandAbbreviation(rl.line.Len(), false)
+
 	// Without multiline support, we always return the line.
 	if rl.AcceptMultiline == nil {
 		rl.Macros.StopRecord(rl.Keys.Caller()...)
@@ -0,0 +1,426 @@
+package readline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+
+	"github.com/chainreactors/tui/readline/inputrc"
+	"github.com/chainreactors/tui/readline/internal/color"
+	"github.com/chainreactors/tui/readline/internal/completion"
+	"github.com/chainreactors/tui/readline/internal/core"
+	"github.com/chainreactors/tui/readline/internal/history"
+	"github.com/chainreactors/tui/readline/internal/term"
+)
+
+const (
+	browserPrompt     = "history> "
+	browserTimeFormat = "2006-01-02 15:04"
+	browserHeaderRows = 2
+)
+
+// Actions resulting from a key pressed in the history browser.
+const (
+	browserContinue = iota
+	browserAccept
+	browserCancel
+)
+
+// historyBrowser is a full-screen list of the lines of all history sources,
+// fuzzy-filtered with a query, from which lines are inserted or deleted.
+type historyBrowser struct {
+	lines    []history.SourceLine // All lines, from the newest to the oldest.
+	matches  []browserMatch       // Lines matching the query, from the best match.
+	query    []rune
+	selected []history.SourceLine // Lines selected, in the order of selection.
+	pos      int                  // Index of the current match.
+	offset   int                  // Index of the first match displayed.
+	deleting bool                 // A deletion is waiting for confirmation.
+	status   string               // Message displayed in the status line.
+}
+
+// browserMatch is a history line matching the query, with the matched characters.
+type browserMatch struct {
+	line      history.SourceLine
+	positions []int
+	score     int
+}
+
+// Open a full-screen browser of the lines of all history sources, fuzzy-filtered
+// as you type, with their time and source. Tab selects several lines, Enter
+// inserts them in place of the input line (or the current one if none is
+// selected), and C-d deletes them from their sources, when these allow it.
+// Escape, C-g and C-c quit the browser, leaving the input line untouched.
+func (rl *Shell) historyBrowse() {
+	browser := &historyBrowser{
+		lines: rl.History.Lines(),
+		query: []rune(string(*rl.line)),
+	}
+
+	if len(browser.lines) == 0 {
+		rl.History.SkipSave()
+		rl.Hint.SetTemporary(fmt.Sprintf("%s%s%s %s", color.Dim, color.FgRed, "No command history", color.Reset))
+
+		return
+	}
+
+	browser.filter()
+
+	term.Print(term.AltScreenEnter)
+	defer term.Print(term.AltScreenExit)
+
+	for {
+		browser.render()
+
+		input, eof := core.ReadInput(rl.Keys, rl.Config)
+		if eof {
+			rl.History.SkipSave()
+			return
+		}
+
+		for len(input) > 0 {
+			var key string
+			key, input = nextBrowserKey(input)
+
+			switch browser.handle(rl, key) {
+			case browserAccept:
+				core.UnreadInput(rl.Keys, input)
+
+				if lines := browser.accepted(); len(lines) > 0 {
+					rl.line.Set([]rune(strings.Join(lines, "\n"))...)
+					rl.cursor.Set(rl.line.Len())
+				}
+
+				return
+
+			case browserCancel:
+				core.UnreadInput(rl.Keys, input)
+				rl.History.SkipSave()
+
+				return
+			}
+		}
+	}
+}
+
+// handle processes a key pressed in the browser.
+func (b *historyBrowser) handle(rl *Shell, key string) int {
+	if b.deleting {
+		b.deleting = false
+		b.status = ""
+
+		if key == "y" || key == "Y" {
+			b.delete(rl)
+		}
+
+		return browserContinue
+	}
+
+	b.status = ""
+
+	switch key {
+	case "\r", "\n":
+		return browserAccept
+	case string(inputrc.Esc), "\x07", "\x03":
+		return browserCancel
+	case "\x1b[A", "\x1bOA", "\x10":
+		b.move(-1)
+	case "\x1b[B", "\x1bOB", "\x0e":
+		b.move(1)
+	case "\x1b[5~":
+		b.move(-b.listRows())
+	case "\x1b[6~":
+		b.move(b.listRows())
+	case "\t":
+		b.toggle()
+		b.move(1)
+	case "\x1b[Z":
+		b.toggle()
+		b.move(-1)
+	case "\x04", "\x1b[3~":
+		b.confirmDelete(rl)
+	case "\x7f", "\x08":
+		if len(b.query) > 0 {
+			b.query = b.query[:len(b.query)-1]
+			b.filter()
+		}
+	case "\x15":
+		b.query = nil
+		b.filter()
+	default:
+		char, _ := utf8.DecodeRuneInString(key)
+		if len(key) > 0 && unicode.IsPrint(char) {
+			b.query = append(b.query, []rune(key)...)
+			b.filter()
+		}
+	}
+
+	return browserContinue
+}
+
+// filter matches all lines against the query, the best matches first,
+// and the most recent lines first among equally good matches.
+func (b *historyBrowser) filter() {
+	query := string(b.query)
+	ignoreCase := strings.ToLower(query) == query
+
+	b.matches = b.matches[:0]
+
+	for _, line := range b.lines {
+		score, positions, ok := completion.Match(completion.MatchFuzzy, query, line.Line, ignoreCase)
+		if query == "" || ok {
+			b.matches = append(b.matches, browserMatch{line: line, positions: positions, score: score})
+		}
+	}
+
+	sort.SliceStable(b.matches, func(i, j int) bool {
+		return b.matches[i].score > b.matches[j].score
+	})
+
+	b.pos, b.offset = 0, 0
+}
+
+// move moves the current line up or down the list, scrolling it if needed.
+func (b *historyBrowser) move(lines int) {
+	b.pos += lines
+
+	if b.pos >= len(b.matches) {
+		b.pos = len(b.matches) - 1
+	}
+
+	if b.pos < 0 {
+		b.pos = 0
+	}
+
+	rows := b.listRows()
+
+	if b.pos < b.offset {
+		b.offset = b.pos
+	} else if b.pos >= b.offset+rows {
+		b.offset = b.pos - rows + 1
+	}
+}
+
+// toggle selects or unselects the current line.
+func (b *historyBrowser) toggle() {
+	if b.pos >= len(b.matches) {
+		return
+	}
+
+	line := b.matches[b.pos].line
+
+	for i, selected := range b.selected {
+		if selected == line {
+			b.selected = append(b.selected[:i], b.selected[i+1:]...)
+			return
+		}
+	}
+
+	b.selected = append(b.selected, line)
+}
+
+// isSelected returns true if the line is selected.
+func (b *historyBrowser) isSelected(line history.SourceLine) bool {
+	for _, selected := range b.selected {
+		if selected == line {
+			return true
+		}
+	}
+
+	return false
+}
+
+// targets returns the selected lines, or the current one if none is selected.
+func (b *historyBrowser) targets() []history.SourceLine {
+	if len(b.selected) > 0 {
+		return b.selected
+	}
+
+	if b.pos < len(b.matches) {
+		return []history.SourceLine{b.matches[b.pos].line}
+	}
+
+	return nil
+}
+
+// accepted returns the lines to insert in the input line.
+func (b *historyBrowser) accepted() (lines []string) {
+	for _, line := range b.targets() {
+		lines = append(lines, line.Line)
+	}
+
+	return lines
+}
+
+// confirmDelete asks for confirmation before deleting the target lines,
+// unless some of them belong to sources which are read-only.
+func (b *historyBrowser) confirmDelete(rl *Shell) {
+	targets := b.targets()
+	if len(targets) == 0 {
+		return
+	}
+
+	for _, line := range targets {
+		if !rl.History.Deletable(line.Source) {
+			b.status = color.FgRed + fmt.Sprintf("Cannot delete lines from %s (read-only)", line.Source) + color.Reset
+			return
+		}
+	}
+
+	b.deleting = true
+	b.status = color.FgYellow + fmt.Sprintf("Delete %d line(s) from history? (y/n)", len(targets)) + color.Reset
+}
+
+// delete deletes the target lines from their sources, and lists the remaining ones.
+func (b *historyBrowser) delete(rl *Shell) {
+	targets := b.targets()
+	pos := b.pos
+
+	if err := rl.History.DeleteLines(targets...); err != nil {
+		b.status = color.FgRed + "Deletion error: " + err.Error() + color.Reset
+	} else {
+		b.status = color.FgGreen + fmt.Sprintf("Deleted %d line(s)", len(targets)) + color.Reset
+	}
+
+	b.lines = rl.History.Lines()
+	b.selected = nil
+	b.filter()
+	b.move(pos)
+}
+
+// listRows returns the number of lines that can be listed on the screen.
+func (b *historyBrowser) listRows() int {
+	rows := term.GetLength() - browserHeaderRows
+	if rows < 1 {
+		rows = 1
+	}
+
+	return rows
+}
+
+// render draws the query, the status line and the matching history lines.
+func (b *historyBrowser) render() {
+	width := term.GetWidth()
+
+	var screen strings.Builder
+
+	screen.WriteString(term.HideCursor + term.CursorTopLeft)
+
+	// Query and status.
+	screen.WriteString(color.Bold + browserPrompt + color.Reset + string(b.query) + term.ClearLineAfter + term.NewlineReturn)
+
+	status := fmt.Sprintf("%d/%d", len(b.matches), len(b.lines))
+	if len(b.selected) > 0 {
+		status += fmt.Sprintf(" (%d selected)", len(b.selected))
+	}
+
+	if b.status == "" {
+		status += "  Tab: select  Enter: insert  C-d: delete  Esc: quit"
+	}
+
+	screen.WriteString(color.Dim + status + color.Reset + " " + b.status + term.ClearLineAfter)
+
+	// History lines.
+	rows := b.listRows()
+
+	for i := b.offset; i < len(b.matches) && i < b.offset+rows; i++ {
+		screen.WriteString(term.NewlineReturn + b.renderLine(b.matches[i], i == b.pos, width) + term.ClearLineAfter)
+	}
+
+	screen.WriteString(term.ClearScreenBelow)
+
+	// Put the cursor back at the end of the query.
+	queryWidth := uniseg.StringWidth(browserPrompt + string(b.query))
+	screen.WriteString(fmt.Sprintf("\x1b[1;%dH", queryWidth+1) + term.ShowCursor)
+
+	term.Print(screen.String())
+}
+
+// renderLine renders a history line with its time and source, highlighting
+// the characters matching the query, and cut to fit in the terminal width.
+func (b *historyBrowser) renderLine(match browserMatch, current bool, width int) string {
+	marker := "  "
+	if current {
+		marker = color.Bold + "> " + color.Reset
+	}
+
+	mark := " "
+	if b.isSelected(match.line) {
+		mark = color.FgGreen + "*" + color.Reset
+	}
+
+	stamp := strings.Repeat(" ", len(browserTimeFormat))
+	if !match.line.Time.IsZero() {
+		stamp = match.line.Time.Local().Format(browserTimeFormat)
+	}
+
+	source := "[" + match.line.Source + "]"
+	prefix := marker + mark + " " + color.Dim + stamp + " " + source + color.Reset + " "
+	available := width - 4 - len(browserTimeFormat) - 1 - uniseg.StringWidth(source) - 1
+
+	matched := make(map[int]bool, len(match.positions))
+	for _, pos := range match.positions {
+		matched[pos] = true
+	}
+
+	style := ""
+	if current {
+		style = color.Bold
+	}
+
+	var line strings.Builder
+
+	line.WriteString(style)
+
+	for i, char := range []rune(match.line.Line) {
+		display := string(char)
+		if char == '\n' {
+			display = "↵"
+		}
+
+		available -= uniseg.StringWidth(display)
+		if available < 0 {
+			break
+		}
+
+		if matched[i] {
+			line.WriteString(color.FgBlue + color.Bold + display + color.Reset + style)
+		} else {
+			line.WriteString(display)
+		}
+	}
+
+	return prefix + line.String() + color.Reset
+}
+
+// nextBrowserKey splits the first key off the input: a character,
+// an escape sequence (arrow keys, etc) or a lone escape.
+func nextBrowserKey(input []byte) (key string, rest []byte) {
+	if rune(input[0]) != inputrc.Esc || len(input) == 1 {
+		_, size := utf8.DecodeRune(input)
+		return string(input[:size]), input[size:]
+	}
+
+	switch input[1] {
+	case '[':
+		// Control sequences end with a byte in the @ to ~ range.
+		for i := 2; i < len(input); i++ {
+			if input[i] >= 0x40 && input[i] <= 0x7e {
+				return string(input[:i+1]), input[i+1:]
+			}
+		}
+
+		return string(input), nil
+
+	case 'O':
+		if len(input) > 2 {
+			return string(input[:3]), input[3:]
+		}
+	}
+
+	return string(input[:1]), input[1:]
+}
@@ -0,0 +1,85 @@
+package readline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+func browseHistory(t *testing.T, input string, sources map[string]History) (line, output string) {
+	t.Helper()
+
+	var out bytes.Buffer
+	terminal := rlterm.Stream(strings.NewReader(input), &out, &out, rlterm.NewControl(false, 80, 24))
+	rl := NewShellWithTerminal(terminal)
+
+	for _, name := range []string{"main", "implant"} {
+		if source := sources[name]; source != nil {
+			rl.History.Add(name, source)
+		}
+	}
+
+	line, _ = rl.Readline()
+
+	return line, out.String()
+}
+
+func TestHistoryBrowse(t *testing.T) {
+	main := NewInMemoryHistory()
+	main.Write("sessions -l")
+	main.Write("use 1")
+
+	implant := NewInMemoryHistory()
+	implant.Write("sysinfo")
+
+	line, output := browseHistory(t, "\x1bhsesl\r\r", map[string]History{"main": main, "implant": implant})
+
+	if line != "sessions -l" {
+		t.Fatalf("line = %q, want the fuzzy match inserted", line)
+	}
+
+	if !strings.Contains(output, "[implant]") || !strings.Contains(output, "\x1b[?1049h") {
+		t.Fatalf("browser not displayed with the sources:\n%q", output)
+	}
+}
+
+func TestHistoryBrowseSelect(t *testing.T) {
+	main := NewInMemoryHistory()
+	main.Write("sessions -l")
+	main.Write("use 1")
+
+	// Select the two lines, the newest first.
+	line, _ := browseHistory(t, "\x1bh\t\t\r\r", map[string]History{"main": main})
+
+	if line != "use 1\nsessions -l" {
+		t.Fatalf("line = %q, want both selected lines", line)
+	}
+}
+
+func TestHistoryBrowseDelete(t *testing.T) {
+	store, _ := NewHistoryStore("")
+	store.Write("sessions -l")
+	store.Write("use 1")
+
+	// Delete the newest line, then quit the browser.
+	line, _ := browseHistory(t, "\x1bh\x04y\x07\r", map[string]History{"main": store})
+
+	if line != "" {
+		t.Fatalf("line = %q, want the input line untouched", line)
+	}
+
+	if got := storeLines(store); got != "sessions -l" {
+		t.Fatalf("store lines = %q after deletion", got)
+	}
+}
+
+func storeLines(store *HistoryStore) string {
+	var lines []string
+	for _, entry := range store.Entries(HistoryFilter{}) {
+		lines = append(lines, entry.Line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,127 @@
+package inputrc
+
+import (
+	"strconv"
+	"unicode"
+)
+
+// Modifiers of keys, as encoded in the CSI u sequences of extended keyboard
+// protocols (kitty keyboard protocol, xterm modifyOtherKeys).
+const (
+	ModShift   = 1 << iota // Shift
+	ModMeta                // Alt/Meta
+	ModControl             // Control
+)
+
+// ExtendedKey returns the CSI u sequence reporting a key pressed with modifiers, as
+// sent by terminals using the kitty keyboard protocol: \e[97;6u for Control-Shift-a.
+func ExtendedKey(code rune, mods int) string {
+	seq := string(Esc) + "[" + strconv.Itoa(int(code))
+
+	if mods != 0 {
+		seq += ";" + strconv.Itoa(mods+1)
+	}
+
+	return seq + "u"
+}
+
+// LegacyKey returns the sequence sent by legacy terminals for a key pressed with
+// modifiers. Modifiers which cannot be encoded (like Shift with Control, or Control
+// with keys other than letters) are dropped, in which case exact is false.
+func LegacyKey(code rune, mods int) (seq string, exact bool) {
+	shift, control := mods&ModShift != 0, mods&ModControl != 0
+	exact = mods&^(ModShift|ModMeta|ModControl) == 0
+
+	switch {
+	case control && (code == Space || code == '@'):
+		seq = string(rune(0))
+		exact = exact && !shift
+	case control && code == '?':
+		seq = string(Delete)
+		exact = exact && !shift
+	case control && code < unicode.MaxASCII && (unicode.IsLetter(code) || (code >= '[' && code <= '_')):
+		seq = string(Encontrol(code))
+		exact = exact && !shift
+	case shift && code == Tab:
+		seq = string(Esc) + "[Z"
+		exact = exact && !control
+	case shift && unicode.IsLower(code):
+		seq = string(unicode.ToUpper(code))
+		exact = exact && !control
+	default:
+		seq = string(code)
+		exact = exact && !control && !shift
+	}
+
+	if mods&ModMeta != 0 {
+		seq = string(Esc) + seq
+	}
+
+	return seq, exact
+}
+
+// EncodeKey returns the sequence of a key pressed with modifiers: its legacy
+// encoding if all modifiers can be encoded, otherwise its CSI u sequence,
+// which terminals only send when using an extended keyboard protocol.
+func EncodeKey(code rune, mods int) string {
+	if seq, exact := LegacyKey(code, mods); exact {
+		return seq
+	}
+
+	return ExtendedKey(code, mods)
+}
+
+// decodeModifiers decodes an escaped key with a chain of modifiers, like \C-\S-a
+// or \C-\r, if it has Shift or a named key (\r, \t, \e or \d): those keys cannot
+// always be encoded with legacy sequences, and are encoded with EncodeKey.
+func decodeModifiers(r []rune, i, end int) (seq string, length int, ok bool) {
+	var mods int
+
+	pos := i
+
+	for grab(r, pos, end) == '\\' && grab(r, pos+2, end) == '-' {
+		switch grab(r, pos+1, end) {
+		case 'C':
+			mods |= ModControl
+		case 'M':
+			mods |= ModMeta
+		case 'S':
+			mods |= ModShift
+		default:
+			return "", 0, false
+		}
+
+		pos += 3
+	}
+
+	if mods == 0 || pos >= end {
+		return "", 0, false
+	}
+
+	code, named := r[pos], false
+
+	if code == '\\' {
+		switch grab(r, pos+1, end) {
+		case 'r':
+			code, named = Return, true
+		case 't':
+			code, named = Tab, true
+		case 'e':
+			code, named = Esc, true
+		case 'd':
+			code, named = Delete, true
+		}
+	}
+
+	if named {
+		pos += 2
+	} else {
+		pos++
+	}
+
+	if !named && mods&ModShift == 0 {
+		return "", 0, false
+	}
+
+	return EncodeKey(code, mods), pos - i, true
+}
@@ -538,7 +538,7 @@ func decodeKey(seq []rune, pos, end int) (string, int, error) {
 	}
 
 	val := strings.ToLower(string(seq[start:pos]))
-	meta, control := false, false
+	meta, control, shift := false, false, false
 
 	for idx := strings.Index(val, "-"); idx != -1; idx = strings.Index(val, "-") {
 		switch val[:idx] {
@@ -546,6 +546,8 @@ func decodeKey(seq []rune, pos, end int) (string, int, error) {
 			control = true
 		case "meta", "m":
 			meta = true
+		case "shift", "s":
+			shift = true
 		default:
 			return "", idx, ErrUnknownModifier
 		}
@@ -579,6 +581,25 @@ func decodeKey(seq []rune, pos, end int) (string, int, error) {
 		char, _ = utf8.DecodeRuneInString(val)
 	}
 
+	// Keys only distinguished by extended keyboard protocols
+	if shift || (control && (char == Return || char == Tab || char == Esc || char == Delete)) {
+		var mods int
+
+		if shift {
+			mods |= ModShift
+		}
+
+		if meta {
+			mods |= ModMeta
+		}
+
+		if control {
+			mods |= ModControl
+		}
+
+		return EncodeKey(char, mods), pos, nil
+	}
+
 	switch {
 	case control && meta:
 		return string([]rune{Esc, Encontrol(char)}), pos, nil
@@ -628,6 +649,14 @@ func unescapeRunes(r []rune, i, end int) string {
 
 	for ; i < end; i++ {
 		if char0 = r[i]; char0 == '\\' {
+			// \S- shift prefix, or modifiers of \r, \t, \e and \d
+			if key, length, ok := decodeModifiers(r, i, end); ok {
+				seq = append(seq, []rune(key)...)
+				i += length - 1
+
+				continue
+			}
+
 			char1, char2, char3, char4, char5 = grab(r, i+1, end), grab(r, i+2, end), grab(r, i+3, end), grab(r, i+4, end), grab(r, i+5, end)
 
 			switch {
@@ -18,8 +18,12 @@ type Candidate struct {
 	// completions, comma-separated completions, etc.
 	noSpace SuffixMatcher
 
+	// A function returning the content of the preview pane when the candidate is selected.
+	preview Previewer
+
 	displayLen int // Real length of the displayed candidate, that is not counting escaped sequences.
 	descLen    int
+	score      int // How well the candidate matches the prefix, when not matching prefixes only.
 }
 
 // Values is used internally to hold all completion candidates and their associated data.
@@ -33,6 +37,9 @@ type Values struct {
 	ListSep  map[string]string
 	Pad      map[string]bool
 	Escapes  map[string]bool
+	Matching string // Matching strategy overriding the completion-matching option.
+	Pending  bool   // Some candidates are still being generated asynchronously.
+	Loading  string // Indicator shown in the hint while candidates are pending.
 
 	// Initially this will be set to the part of the current word
 	// from the beginning of the word up to the position of the cursor.
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/chainreactors/tui/readline/internal/color"
+	"github.com/chainreactors/tui/readline/internal/strutil"
 	"github.com/chainreactors/tui/readline/internal/term"
 )
 
@@ -21,6 +22,10 @@ func Display(eng *Engine, maxRows int) {
 	// little more time. The engine itself is responsible for
 	// deleting those lists when it deems them useless.
 	if eng.Matches() == 0 || eng.skipDisplay {
+		for _, group := range eng.groups {
+			group.cells = nil
+		}
+
 		term.Print(term.ClearLineAfter)
 		return
 	}
@@ -32,8 +37,10 @@ func Display(eng *Engine, maxRows int) {
 		completions += eng.renderCompletions(group)
 	}
 
-	// Crop the completions so that it fits within our terminal
-	completions, eng.usedY = eng.cropCompletions(completions, maxRows)
+	// Crop the completions so that it fits within our terminal,
+	// along with the preview of the selected candidate, if any.
+	completions, eng.usedY = eng.cropCompletions(completions, eng.previewMenuRows(completions, maxRows))
+	completions, eng.usedY = eng.displayPreview(completions, eng.usedY, maxRows)
 
 	if completions != "" {
 		term.Print(completions)
@@ -60,7 +67,11 @@ func (e *Engine) renderCompletions(grp *group) string {
 		builder.WriteString(tag + term.ClearLineAfter + term.NewlineReturn)
 	}
 
+	grp.cells = make([][]int, len(grp.rows))
+
 	for rowIndex, row := range grp.rows {
+		var width int
+
 		for columnIndex := range grp.columnsWidth {
 			var value Candidate
 
@@ -77,6 +88,7 @@ func (e *Engine) renderCompletions(grp *group) string {
 			display := e.highlightDisplay(grp, value, padding, columnIndex, isSelected)
 
 			builder.WriteString(display)
+			width += strutil.RealLength(display)
 
 			// Add description if no aliases, or if done with them.
 			onLast := columnIndex == len(grp.columnsWidth)-1
@@ -90,7 +102,10 @@ func (e *Engine) renderCompletions(grp *group) string {
 				descPad := grp.getPad(value, columnIndex, true)
 				desc := e.highlightDesc(grp, value, descPad, rowIndex, columnIndex, isSelected)
 				builder.WriteString(desc)
+				width += strutil.RealLength(desc)
 			}
+
+			grp.cells[rowIndex] = append(grp.cells[rowIndex], width)
 		}
 
 		// We're done for this line.
@@ -125,18 +140,61 @@ func (e *Engine) highlightDisplay(grp *group, val Candidate, pad, col int, selec
 			candidate += color.Reset
 		}
 	} else {
-		// Highlight the prefix if any and configured for it.
-		if e.config.GetBool("colored-completion-prefix") && e.prefix != "" {
+		candidate = style + e.highlightMatch(candidate, style) + color.Reset
+	}
+
+	return candidate + padded
+}
+
+// highlightMatch highlights the characters of the candidate matching the prefix:
+// with prefix matching, only if configured for it, otherwise always.
+func (e *Engine) highlightMatch(candidate, style string) string {
+	if e.prefix == "" {
+		return candidate
+	}
+
+	if e.matching == MatchPrefix {
+		if e.config.GetBool("colored-completion-prefix") {
 			if prefixMatch, err := regexp.Compile("^" + e.prefix); err == nil {
 				prefixColored := color.Bold + color.FgBlue + e.prefix + color.BoldReset + color.FgDefault + style
 				candidate = prefixMatch.ReplaceAllString(candidate, prefixColored)
 			}
 		}
 
-		candidate = style + candidate + color.Reset
+		return candidate
 	}
 
-	return candidate + padded
+	// Candidates displayed with their own escape sequences are left alone.
+	if color.Strip(candidate) != candidate {
+		return candidate
+	}
+
+	_, positions, ok := Match(e.matching, e.prefix, candidate, e.config.GetBool("completion-ignore-case"))
+	if !ok {
+		return candidate
+	}
+
+	matched := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		matched[pos] = true
+	}
+
+	var builder strings.Builder
+
+	runes := []rune(candidate)
+	for i, r := range runes {
+		if matched[i] && (i == 0 || !matched[i-1]) {
+			builder.WriteString(color.Bold + color.FgBlue)
+		}
+
+		builder.WriteRune(r)
+
+		if matched[i] && (i == len(runes)-1 || !matched[i+1]) {
+			builder.WriteString(color.BoldReset + color.FgDefault + style)
+		}
+	}
+
+	return builder.String()
 }
 
 func (e *Engine) highlightDesc(grp *group, val Candidate, pad, row, col int, selected bool) (desc string) {
@@ -169,6 +227,47 @@ func (e *Engine) highlightDesc(grp *group, val Candidate, pad, row, col int, sel
 	return compDescStyle + desc + color.Reset + padded
 }
 
+// candidateAt returns the group and coordinates of the candidate displayed at some
+// row and column of the completion menu, or a nil group if there is none there.
+func (e *Engine) candidateAt(row, column int) (grp *group, posY, posX int) {
+	line := row + e.menuOffset
+
+	for _, group := range e.groups {
+		if len(group.rows) == 0 {
+			continue
+		}
+
+		if group.tag != "" {
+			line--
+		}
+
+		if line < 0 {
+			return nil, -1, -1
+		}
+
+		if line >= len(group.cells) {
+			line -= len(group.cells)
+			continue
+		}
+
+		for posX, end := range group.cells[line] {
+			if column >= end {
+				continue
+			}
+
+			if posX >= len(group.rows[line]) || group.rows[line][posX].Display == "" {
+				return nil, -1, -1
+			}
+
+			return group, line, posX
+		}
+
+		return nil, -1, -1
+	}
+
+	return nil, -1, -1
+}
+
 // cropCompletions - When the user cycles through a completion list longer
 // than the console MaxTabCompleterRows value, we crop the completions string
 // so that "global" cycling (across all groups) is printed correctly.
@@ -181,12 +280,14 @@ func (e *Engine) cropCompletions(comps string, maxRows int) (cropped string, use
 
 	// If absPos < MaxTabCompleterRows, cut below MaxTabCompleterRows and return
 	if absPos < maxRows-1 {
+		e.menuOffset = 0
 		return e.cutCompletionsBelow(scanner, maxRows)
 	}
 
 	// If absolute > MaxTabCompleterRows, cut above and below and return
 	//      -> This includes de facto when we tabCompletionReverse
 	if absPos >= maxRows-1 {
+		e.menuOffset = absPos - maxRows + 2
 		return e.cutCompletionsAboveBelow(scanner, maxRows, absPos)
 	}
 
@@ -31,9 +31,16 @@ type Engine struct {
 	sm          SuffixMatcher // The suffix matcher is kept for removal after actually inserting the candidate.
 	selected    Candidate     // The currently selected item, not yet a real part of the input line.
 	prefix      string        // The current tab completion prefix against which to build candidates
+	matching    string        // The strategy used to match candidates against the prefix.
+	previewPos  string        // Where the preview of the selected candidate is displayed, if any.
+	preview     previewCache  // The preview of the last selected candidate.
+	pending     bool          // Some candidates are still being generated, so none is unique.
+	loading     string        // Indicator shown in the hint while candidates are pending.
+	hintText    string        // Hint of the completions, without the loading indicator.
 	suffix      string        // The current word suffix
 	inserted    []rune        // The selected candidate (inserted in line) without prefix or suffix.
 	usedY       int           // Comprehensive size offset (terminal rows) of the currently built completions.
+	menuOffset  int           // Number of rows of completions cropped above the displayed ones.
 	auto        bool          // Is the engine autocompleting ?
 	autoForce   bool          // Special autocompletion mode (isearch-style)
 	skipDisplay bool          // Don't display completions if there are some.
@@ -86,7 +93,7 @@ func (e *Engine) Generate(completions Values) {
 	// Incremental search is a special case, because the user may
 	// want to keep searching for another match, so we don't drop
 	// the completion list and exit the incremental search mode.
-	if e.hasUniqueCandidate() && e.keymap.Local() != keymap.Isearch {
+	if e.hasUniqueCandidate() && !e.pending && e.keymap.Local() != keymap.Isearch {
 		e.acceptCandidate()
 		e.ClearMenu(true)
 	}
@@ -112,6 +119,47 @@ func (e *Engine) GenerateCached() {
 	e.GenerateWith(e.cached)
 }
 
+// Regenerate recomputes the completions when new candidates are available (like
+// late asynchronous ones), unless a candidate is currently selected, and returns
+// true if it did. Completions requested with a menu are displayed with one.
+func (e *Engine) Regenerate() bool {
+	if e.IsInserting() {
+		return false
+	}
+
+	completer := e.cached
+	if completer == nil && e.auto {
+		completer = e.autoCompleter
+	}
+
+	if completer == nil {
+		return false
+	}
+
+	e.hint.Reset()
+	e.prepare(completer())
+
+	if !e.auto && !e.AutoCompleting() && !e.noCompletions() {
+		e.keymap.SetLocal(keymap.MenuSelect)
+	}
+
+	return true
+}
+
+// SetLoading replaces the loading indicator shown in the hint while candidates
+// are pending, without generating the completions again, and returns true if
+// some are pending (and the hint must be redisplayed).
+func (e *Engine) SetLoading(indicator string) bool {
+	if !e.pending || e.keymap.Local() == keymap.Isearch {
+		return false
+	}
+
+	e.loading = indicator
+	e.showHint()
+
+	return true
+}
+
 // SkipDisplay avoids printing completions below the
 // input line, but still enables cycling through them.
 func (e *Engine) SkipDisplay() {
@@ -161,6 +209,32 @@ func (e *Engine) Select(row, column int) {
 	}
 }
 
+// SelectAt selects the candidate displayed at some row and column of the
+// completion menu (like when clicking it), and updates the inserted candidate
+// in the input line. It returns false if there is no candidate there.
+func (e *Engine) SelectAt(row, column int) bool {
+	grp, posY, posX := e.candidateAt(row, column)
+	if grp == nil {
+		return false
+	}
+
+	// Ensure the completion keymaps are set.
+	e.adjustSelectKeymap()
+
+	if len(e.selected.Value) > 0 {
+		e.cancelCompletedLine()
+	}
+
+	for _, g := range e.groups {
+		g.isCurrent = g == grp
+	}
+
+	grp.posX, grp.posY = posX, posY
+	e.refreshLine()
+
+	return true
+}
+
 // SelectTag allows to select the first value of the next tag (next=true),
 // or the last value of the previous tag (next=false).
 func (e *Engine) SelectTag(next bool) {
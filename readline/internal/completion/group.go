@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/chainreactors/tui/readline/internal/color"
-	"github.com/chainreactors/tui/readline/internal/term"
 )
 
 // group is used to structure different types of completions with different
@@ -29,6 +28,7 @@ type group struct {
 	longestDesc       int           // Used to know how much descriptions can use when there are aliases.
 	maxDescAllowed    int           // Maximum ALLOWED description width.
 	termWidth         int           // Term size queried at beginning of computes by the engine.
+	cells             [][]int       // End column of each candidate (with its description) in displayed rows.
 
 	// Selectors (position/bounds) management
 	posX int
@@ -45,16 +45,21 @@ func (e *Engine) newCompletionGroup(comps Values, tag string, vals RawValues, de
 		posX:         -1,
 		posY:         -1,
 		columnsWidth: []int{0},
-		termWidth:    term.GetWidth(),
+		termWidth:    e.menuWidth(),
 		longestDesc:  longest(descriptions, true),
 	}
 
 	// Initialize all options for the group.
 	grp.initOptions(e, &comps, tag, vals)
 
-	// Global actions to take on all values.
+	// Global actions to take on all values: candidates
+	// matched by score are ranked, best matches first.
 	if !grp.noSort {
 		sort.Stable(vals)
+
+		if e.matching != MatchPrefix && e.prefix != "" {
+			vals.RankMatches()
+		}
 	}
 
 	// Initial processing of our assigned values:
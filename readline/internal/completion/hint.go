@@ -29,16 +29,31 @@ func (e *Engine) hintCompletions(comps Values) {
 	}
 
 	// If we don't have any completions, and no messages, let's say it.
-	if e.Matches() == 0 && hint == color.Dim+term.NewlineReturn && !e.auto {
+	if e.Matches() == 0 && hint == color.Dim+term.NewlineReturn && !e.auto && !e.pending {
 		hint = e.hintNoMatches()
 	}
 
-	hint = strings.TrimSuffix(hint, term.NewlineReturn)
+	e.hintText = strings.TrimSuffix(hint, term.NewlineReturn)
+	e.showHint()
+}
+
+// showHint adds the hint of the completions to the shell, followed
+// by the loading indicator while some candidates are pending.
+func (e *Engine) showHint() {
+	hint := e.hintText
+
+	if e.pending && e.loading != "" {
+		if hint != "" {
+			hint += term.NewlineReturn
+		}
+
+		hint += e.loading
+	}
+
 	if hint == "" {
 		return
 	}
 
-	// Add the hint to the shell.
 	e.hint.Set(hint + color.Reset)
 }
 
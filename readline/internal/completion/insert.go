@@ -103,7 +103,7 @@ func (e *Engine) refreshLine() {
 	// Incremental search is a special case, because the user may
 	// want to keep searching for another match, so we don't drop
 	// the completion list and exit the incremental search mode.
-	if e.hasUniqueCandidate() && e.keymap.Local() != keymap.Isearch {
+	if e.hasUniqueCandidate() && !e.pending && e.keymap.Local() != keymap.Isearch {
 		e.acceptCandidate()
 		e.ResetForce()
 	} else {
@@ -0,0 +1,321 @@
+package completion
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Matching strategies of candidates against the completion prefix,
+// selected with the completion-matching inputrc option, or per
+// completions with the Values.Matching field.
+const (
+	MatchPrefix    = "prefix"    // Candidates start with the prefix.
+	MatchSubstring = "substring" // Candidates contain the prefix.
+	MatchFuzzy     = "fuzzy"     // Candidates contain the prefix characters in order, scored like fzf.
+	MatchCamel     = "camel"     // Prefix characters start (or continue) words: "gsi" matches "get-session-id".
+)
+
+// Fuzzy scoring parameters, borrowed from fzf: matches get points,
+// more when they start a word or follow the previous match, and
+// gaps between matches cost some.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+	bonusBoundary     = scoreMatch / 2
+	bonusCamel        = bonusBoundary - 1
+	bonusConsecutive  = -(scoreGapStart + scoreGapExtension)
+	bonusFirstChar    = 2
+)
+
+// matchMode returns the matching strategy to use, prefix matching if unknown.
+func matchMode(mode string) string {
+	switch mode {
+	case MatchSubstring, MatchFuzzy, MatchCamel:
+		return mode
+	default:
+		return MatchPrefix
+	}
+}
+
+// Match returns whether value matches pattern with the given strategy, along
+// with a score (higher is better), and the indexes of the matched runes.
+func Match(mode, pattern, value string, ignoreCase bool) (score int, positions []int, ok bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	text, pat := []rune(value), []rune(pattern)
+	if ignoreCase {
+		text, pat = []rune(strings.ToLower(value)), []rune(strings.ToLower(pattern))
+	}
+
+	// Lowering case might change the number of runes.
+	if len(text) != utf8.RuneCountInString(value) {
+		text = []rune(value)
+	}
+
+	switch matchMode(mode) {
+	case MatchSubstring:
+		return matchSubstring(pat, text, []rune(value))
+	case MatchFuzzy:
+		return matchFuzzy(pat, text, []rune(value))
+	case MatchCamel:
+		return matchCamel(pat, text, []rune(value))
+	default:
+		if !hasRunePrefix(text, pat) {
+			return 0, nil, false
+		}
+
+		return 0, runeRange(0, len(pat)), true
+	}
+}
+
+// FilterMatch filters values matching the prefix with the given strategy.
+// Except with prefix matching, the candidates are scored, and RankMatches
+// sorts them from the best to the worst match.
+func (c RawValues) FilterMatch(mode, prefix string, ignoreCase bool) RawValues {
+	mode = matchMode(mode)
+
+	if mode == MatchPrefix {
+		return c.FilterPrefix(prefix, !ignoreCase)
+	}
+
+	if prefix == "" {
+		return c
+	}
+
+	filtered := make(RawValues, 0)
+
+	for _, raw := range c {
+		score, _, ok := Match(mode, prefix, raw.Value, ignoreCase)
+		if !ok {
+			continue
+		}
+
+		raw.score = score
+		filtered = append(filtered, raw)
+	}
+
+	return filtered
+}
+
+// RankMatches sorts scored values from the best to the worst match, shorter
+// values first when equal. The existing order is kept in case of ties.
+func (c RawValues) RankMatches() {
+	sort.SliceStable(c, func(i, j int) bool {
+		if c[i].score != c[j].score {
+			return c[i].score > c[j].score
+		}
+
+		return len(c[i].Value) < len(c[j].Value)
+	})
+}
+
+// matchSubstring scores substring matches higher when they start a word.
+func matchSubstring(pat, text, orig []rune) (score int, positions []int, ok bool) {
+	start := runeIndex(text, pat, 0)
+
+	// Prefer a later occurrence starting a word to the first one.
+	for next := start; next >= 0; next = runeIndex(text, pat, next+1) {
+		if wordBonus(orig, next) > 0 {
+			start = next
+			break
+		}
+	}
+
+	if start < 0 {
+		return 0, nil, false
+	}
+
+	score = scoreMatch*len(pat) + wordBonus(orig, start)*bonusFirstChar
+	score += bonusConsecutive * (len(pat) - 1)
+
+	if start > 0 {
+		score += scoreGapStart
+	}
+
+	return score, runeRange(start, len(pat)), true
+}
+
+// matchFuzzy finds the pattern runes in order, choosing the positions with the
+// best score: matches get points, more at word starts or when following the
+// previous one, and gaps between matches cost some (like fzf does).
+func matchFuzzy(pat, text, orig []rune) (score int, positions []int, ok bool) {
+	const none = -1 << 30
+
+	// best[i][j] is the best score of the pattern up to i, with
+	// pattern rune i matched at j (from[i][j] is where i-1 was).
+	best := make([][]int, len(pat))
+	from := make([][]int, len(pat))
+
+	for i := range pat {
+		best[i] = make([]int, len(text))
+		from[i] = make([]int, len(text))
+
+		// Best score of i-1 matched before j-1, with the gap up to j.
+		gap, gapFrom := none, -1
+
+		for j := range text {
+			best[i][j] = none
+
+			if i > 0 && j > 1 {
+				if gap != none {
+					gap += scoreGapExtension
+				}
+
+				if prev := best[i-1][j-2]; prev != none && prev+scoreGapStart > gap {
+					gap, gapFrom = prev+scoreGapStart, j-2
+				}
+			}
+
+			if text[j] != pat[i] {
+				continue
+			}
+
+			bonus := wordBonus(orig, j)
+
+			switch {
+			case i == 0:
+				best[i][j] = scoreMatch + bonus*bonusFirstChar
+				if j > 0 {
+					best[i][j] += scoreGapStart
+				}
+
+				continue
+
+			case j > 0 && best[i-1][j-1] != none:
+				consecutive := bonus
+				if consecutive < bonusConsecutive {
+					consecutive = bonusConsecutive
+				}
+
+				best[i][j], from[i][j] = best[i-1][j-1]+scoreMatch+consecutive, j-1
+			}
+
+			if gap != none && gap+scoreMatch+bonus > best[i][j] {
+				best[i][j], from[i][j] = gap+scoreMatch+bonus, gapFrom
+			}
+		}
+	}
+
+	last := len(pat) - 1
+	end := -1
+
+	for j := range text {
+		if best[last][j] != none && (end < 0 || best[last][j] > best[last][end]) {
+			end = j
+		}
+	}
+
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions = make([]int, len(pat))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+
+	return best[last][end], positions, true
+}
+
+// matchCamel matches pattern runes at the start of words in the value,
+// or right after the previous matched rune (to match word parts).
+func matchCamel(pat, text, orig []rune) (score int, positions []int, ok bool) {
+	positions = make([]int, 0, len(pat))
+
+	var search func(pidx, from int) bool
+
+	search = func(pidx, from int) bool {
+		if pidx == len(pat) {
+			return true
+		}
+
+		for i := from; i < len(text); i++ {
+			continued := pidx > 0 && i == positions[pidx-1]+1
+			if text[i] != pat[pidx] || (!continued && wordBonus(orig, i) == 0) {
+				continue
+			}
+
+			positions = append(positions, i)
+			if search(pidx+1, i+1) {
+				return true
+			}
+
+			positions = positions[:pidx]
+		}
+
+		return false
+	}
+
+	if !search(0, 0) {
+		return 0, nil, false
+	}
+
+	for i, pos := range positions {
+		score += scoreMatch + wordBonus(orig, pos)
+		if i > 0 && pos == positions[i-1]+1 {
+			score += bonusConsecutive
+		}
+	}
+
+	return score, positions, true
+}
+
+// wordBonus returns the bonus of a match at the start of a word: after
+// a separator, a lower-to-upper case change, or at the start of the value.
+func wordBonus(text []rune, pos int) int {
+	if pos == 0 {
+		return bonusBoundary
+	}
+
+	prev, cur := text[pos-1], text[pos]
+
+	switch {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	default:
+		return 0
+	}
+}
+
+func hasRunePrefix(text, prefix []rune) bool {
+	if len(prefix) > len(text) {
+		return false
+	}
+
+	for i, r := range prefix {
+		if text[i] != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+func runeIndex(text, pat []rune, from int) int {
+	for i := from; i+len(pat) <= len(text); i++ {
+		if hasRunePrefix(text[i:], pat) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func runeRange(start, length int) []int {
+	positions := make([]int, length)
+	for i := range positions {
+		positions[i] = start + i
+	}
+
+	return positions
+}
@@ -0,0 +1,64 @@
+package completion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		mode, pattern, value string
+		ignoreCase           bool
+		want                 bool
+		positions            []int
+	}{
+		{MatchPrefix, "ses", "session", false, true, []int{0, 1, 2}},
+		{MatchPrefix, "Ses", "session", false, false, nil},
+		{MatchPrefix, "Ses", "session", true, true, []int{0, 1, 2}},
+		{MatchSubstring, "sion", "session", false, true, []int{3, 4, 5, 6}},
+		{MatchSubstring, "id", "side-id", false, true, []int{5, 6}},
+		{MatchSubstring, "xyz", "session", false, false, nil},
+		{MatchFuzzy, "ssn", "session", false, true, []int{0, 2, 6}},
+		{MatchFuzzy, "gsi", "get-session-id", false, true, []int{0, 4, 12}},
+		{MatchFuzzy, "sz", "session", false, false, nil},
+		{MatchCamel, "gsi", "get-session-id", false, true, []int{0, 4, 12}},
+		{MatchCamel, "gSI", "getSessionID", false, true, []int{0, 3, 10}},
+		{MatchCamel, "gesi", "get-session-id", false, true, []int{0, 1, 4, 12}},
+		{MatchCamel, "ssn", "session", false, false, nil},
+	}
+
+	for _, tt := range tests {
+		_, positions, ok := Match(tt.mode, tt.pattern, tt.value, tt.ignoreCase)
+		if ok != tt.want {
+			t.Errorf("Match(%s, %q, %q) = %v, want %v", tt.mode, tt.pattern, tt.value, ok, tt.want)
+			continue
+		}
+
+		if ok && !reflect.DeepEqual(positions, tt.positions) {
+			t.Errorf("Match(%s, %q, %q) positions = %v, want %v", tt.mode, tt.pattern, tt.value, positions, tt.positions)
+		}
+	}
+}
+
+func TestRankMatches(t *testing.T) {
+	values := RawValues{
+		{Value: "list-sessions"},
+		{Value: "sessions"},
+		{Value: "use-session"},
+		{Value: "kill"},
+		{Value: "show-session-info"},
+	}
+
+	matched := values.FilterMatch(MatchFuzzy, "sess", false)
+	matched.RankMatches()
+
+	var got []string
+	for _, val := range matched {
+		got = append(got, val.Value)
+	}
+
+	want := []string{"sessions", "use-session", "list-sessions", "show-session-info"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ranked matches = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,271 @@
+package completion
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rivo/uniseg"
+
+	"github.com/chainreactors/tui/readline/internal/color"
+	"github.com/chainreactors/tui/readline/internal/term"
+)
+
+// Positions of the preview pane of the selected candidate,
+// selected with the completion-preview inputrc option.
+const (
+	PreviewAuto   = "auto"   // On the right if the terminal is wide enough, below otherwise.
+	PreviewRight  = "right"  // On the right of the completions.
+	PreviewBottom = "bottom" // Below the completions.
+	PreviewOff    = "off"    // No preview.
+)
+
+const (
+	previewMinSideWidth = 120 // Terminal width needed for a right pane with auto.
+	previewMinRows      = 2   // Rows needed for a bottom pane (border included).
+)
+
+// Previewer returns the content to display in the preview pane for
+// a candidate value, fitting (ideally) in width columns and height rows.
+type Previewer func(value string, width, height int) string
+
+// SetPreview sets the function returning the content of the
+// preview pane, displayed when the candidate is selected.
+func (c *Candidate) SetPreview(preview Previewer) {
+	c.preview = preview
+}
+
+// previewCache holds the preview of the last selected candidate.
+type previewCache struct {
+	value  string
+	width  int
+	height int
+	lines  []string
+}
+
+// previewPosition returns where to display previews of the candidates,
+// or an empty string if none of them has a previewer or previews are off.
+func (e *Engine) previewPosition(values RawValues) string {
+	e.preview = previewCache{}
+
+	previewed := false
+
+	for _, val := range values {
+		if val.preview != nil {
+			previewed = true
+			break
+		}
+	}
+
+	if !previewed {
+		return ""
+	}
+
+	switch position := e.config.GetString("completion-preview"); position {
+	case PreviewRight, PreviewBottom:
+		return position
+	case PreviewOff:
+		return ""
+	default:
+		if term.GetWidth() >= previewMinSideWidth {
+			return PreviewRight
+		}
+
+		return PreviewBottom
+	}
+}
+
+// menuWidth returns the number of columns available to the completions,
+// narrowed when the preview pane is displayed on their right.
+func (e *Engine) menuWidth() int {
+	width := term.GetWidth()
+
+	if e.previewPos == PreviewRight {
+		return width - width/2
+	}
+
+	return width
+}
+
+// previewLines returns the preview of the selected candidate, if it has one,
+// as lines of at most width columns and height rows. The previewer is only
+// called again if the candidate or the available size has changed.
+func (e *Engine) previewLines(width, height int) []string {
+	if e.selected.preview == nil || width < 1 || height < 1 {
+		return nil
+	}
+
+	cache := e.preview
+	if cache.value == e.selected.Value && cache.width == width && cache.height == height {
+		return cache.lines
+	}
+
+	content := e.selected.preview(e.selected.Value, width, height)
+	content = strings.TrimRight(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	lines := strings.Split(content, "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	for i, line := range lines {
+		lines[i] = trimPreviewLine(strings.ReplaceAll(line, "\t", "    "), width)
+	}
+
+	e.preview = previewCache{
+		value:  e.selected.Value,
+		width:  width,
+		height: height,
+		lines:  lines,
+	}
+
+	return lines
+}
+
+// displayPreview adds the preview pane of the selected candidate to the
+// cropped completions, which use menuRows rows, within maxRows rows.
+// It returns the completions and the rows used, like cropCompletions.
+func (e *Engine) displayPreview(comps string, menuRows, maxRows int) (string, int) {
+	switch e.previewPos {
+	case PreviewRight:
+		return e.previewRight(comps, menuRows, maxRows)
+	case PreviewBottom:
+		return e.previewBottom(comps, menuRows, maxRows)
+	default:
+		return comps, menuRows
+	}
+}
+
+// previewRight prints the preview next to the completions, which
+// have been generated to fit in the left part of the terminal.
+func (e *Engine) previewRight(comps string, usedY, maxRows int) (string, int) {
+	menuWidth := e.menuWidth()
+	border := color.Dim + "│" + color.Reset + " "
+
+	preview := e.previewLines(term.GetWidth()-menuWidth-3, maxRows)
+	if len(preview) == 0 {
+		return comps, usedY
+	}
+
+	menu := strings.Split(comps, term.NewlineReturn)
+
+	rows := len(menu)
+	if len(preview) > rows {
+		rows = len(preview)
+	}
+
+	var builder strings.Builder
+
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			builder.WriteString(term.NewlineReturn)
+		}
+
+		if row < len(menu) {
+			builder.WriteString(menu[row])
+		}
+
+		builder.WriteString(term.ClearLineAfter + "\r")
+		builder.WriteString("\x1b[" + strconv.Itoa(menuWidth) + "C" + border)
+
+		if row < len(preview) {
+			builder.WriteString(preview[row] + color.Reset)
+		}
+	}
+
+	return builder.String(), rows - 1
+}
+
+// previewBottom prints the preview below the completions, separated by a
+// border, in the rows left by them (completions are cropped to leave some).
+func (e *Engine) previewBottom(comps string, usedY, maxRows int) (string, int) {
+	width := term.GetWidth() - 1
+
+	preview := e.previewLines(width, maxRows-(usedY+1)-1)
+	if len(preview) == 0 {
+		return comps, usedY
+	}
+
+	border := color.Dim + strings.Repeat("─", width) + color.Reset
+
+	comps += term.NewlineReturn + border + term.ClearLineAfter
+
+	for _, line := range preview {
+		comps += term.NewlineReturn + line + color.Reset + term.ClearLineAfter
+	}
+
+	return comps, usedY + 1 + len(preview)
+}
+
+// previewMenuRows returns the number of rows to which completions must be
+// cropped, so that a bottom preview pane has some room left below them.
+func (e *Engine) previewMenuRows(comps string, maxRows int) int {
+	if e.previewPos != PreviewBottom || e.selected.preview == nil {
+		return maxRows
+	}
+
+	rows := maxRows / previewMinRows
+	if lines := strings.Count(comps, term.NewlineReturn); lines < rows {
+		rows = lines + 1
+	}
+
+	if maxRows-rows < previewMinRows {
+		return maxRows
+	}
+
+	return rows
+}
+
+// trimPreviewLine cuts a line to width columns, keeping its escape sequences.
+func trimPreviewLine(line string, width int) string {
+	if uniseg.StringWidth(color.Strip(line)) <= width {
+		return line
+	}
+
+	var builder strings.Builder
+
+	used := 0
+	state := -1
+	rest := line
+
+	for len(rest) > 0 {
+		if rest[0] == '\x1b' {
+			seq := escapeSequence(rest)
+			builder.WriteString(seq)
+			rest = rest[len(seq):]
+
+			continue
+		}
+
+		var cluster string
+		var cols int
+
+		cluster, rest, cols, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if used+cols > width {
+			break
+		}
+
+		builder.WriteString(cluster)
+		used += cols
+	}
+
+	return builder.String()
+}
+
+// escapeSequence returns the escape sequence at the start of a string.
+func escapeSequence(str string) string {
+	if len(str) < 2 || str[1] != '[' {
+		return str[:1]
+	}
+
+	for i := 2; i < len(str); i++ {
+		if str[i] >= 0x40 && str[i] <= 0x7e {
+			return str[:i+1]
+		}
+	}
+
+	return str
+}
@@ -26,6 +26,8 @@ var sanitizer = strings.NewReplacer(
 func (e *Engine) prepare(completions Values) {
 	e.prefix = ""
 	e.groups = make([]*group, 0)
+	e.pending = completions.Pending
+	e.loading = completions.Loading
 
 	e.setPrefix(completions)
 	e.setSuffix(completions)
@@ -46,14 +48,26 @@ func (e *Engine) generate(completions Values) {
 
 	// Apply the prefix to the completions, and filter out any
 	// completions that don't match, optionally ignoring case.
-	matchCase := e.config.GetBool("completion-ignore-case")
-	completions.values = completions.values.FilterPrefix(e.prefix, !matchCase)
+	ignoreCase := e.config.GetBool("completion-ignore-case")
+	e.matching = e.matchingMode(completions)
+	completions.values = completions.values.FilterMatch(e.matching, e.prefix, ignoreCase)
+	e.previewPos = e.previewPosition(completions.values)
 
 	// Classify, group together and initialize completions.
 	completions.values.EachTag(e.generateGroup(completions))
 	e.justifyGroups(completions)
 }
 
+// matchingMode returns the strategy used to match candidates against the prefix:
+// the one requested by the completions, or the completion-matching option.
+func (e *Engine) matchingMode(completions Values) string {
+	if completions.Matching != "" {
+		return matchMode(completions.Matching)
+	}
+
+	return matchMode(e.config.GetString("completion-matching"))
+}
+
 func (e *Engine) setPrefix(completions Values) {
 	switch completions.PREFIX {
 	case "":
@@ -391,6 +405,7 @@ func (e *Engine) resetValues(comps, cached bool) {
 	if comps {
 		e.usedY = 0
 		e.groups = make([]*group, 0)
+		e.pending = false
 	}
 
 	// Drop the completion generation function.
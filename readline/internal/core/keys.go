@@ -65,6 +65,11 @@ func WaitAvailableKeys(keys *Keys, cfg *inputrc.Config) {
 	keys.cfg = cfg
 
 	if len(keys.buf) > 0 && !keys.mustWait {
+		// Keys might have been read while querying the cursor position.
+		keys.mutex.Lock()
+		keys.buf = keys.decodeExtendedKeys(keys.buf)
+		keys.mutex.Unlock()
+
 		return
 	}
 
@@ -104,6 +109,8 @@ func WaitAvailableKeys(keys *Keys, cfg *inputrc.Config) {
 			continue
 
 		default:
+			keyBuf = keys.decodeExtendedKeys(keyBuf)
+
 			// When convert-meta is on, any meta-prefixed bind should
 			// be stripped and replaced with an escape meta instead.
 			if keys.cfg != nil && keys.cfg.GetBool("convert-meta") {
@@ -119,6 +126,42 @@ func WaitAvailableKeys(keys *Keys, cfg *inputrc.Config) {
 	}
 }
 
+// ReadInput returns all the keys available in the stack, waiting for new ones to be
+// read from stdin if there are none. This is used by commands processing their own
+// input, like full-screen widgets. It returns eof if no more keys can be read.
+func ReadInput(keys *Keys, cfg *inputrc.Config) (input []byte, eof bool) {
+	if len(keys.macroKeys) > 0 {
+		input = []byte(string(keys.macroKeys))
+		keys.macroKeys = nil
+
+		return input, false
+	}
+
+	WaitAvailableKeys(keys, cfg)
+
+	keys.mutex.Lock()
+	defer keys.mutex.Unlock()
+
+	input = keys.buf
+	keys.buf = nil
+
+	return input, len(input) == 0 && keys.eof
+}
+
+// UnreadInput puts keys back on top of the stack, so that they
+// are dispatched as usual once the current command is done.
+func UnreadInput(keys *Keys, input []byte) {
+	if len(input) == 0 {
+		return
+	}
+
+	keys.mutex.Lock()
+	defer keys.mutex.Unlock()
+
+	keys.buf = append(append([]byte(nil), input...), keys.buf...)
+	keys.mustWait = false
+}
+
 // PeekKey returns the first key in the stack, without removing it.
 func PeekKey(keys *Keys) (key byte, empty bool) {
 	switch {
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/chainreactors/tui/readline/inputrc"
+)
+
+// Keys sent by terminals using extended keyboard protocols: the kitty keyboard
+// protocol (CSI code[:alternates] ; modifiers[:event] u) and xterm modifyOtherKeys
+// (CSI 27 ; modifiers ; code ~).
+var (
+	rxKittyKey     = regexp.MustCompile(`\x1b\[([0-9]+)(?::[0-9]*)*(?:;([0-9]*)(?::([0-9]+))?)?(?:;[0-9:]*)?u`)
+	rxOtherKeysKey = regexp.MustCompile(`\x1b\[27;([0-9]+);([0-9]+)~`)
+)
+
+const (
+	keyEventRelease = 3        // Kitty event type of key releases.
+	keyModsLocks    = 64 | 128 // Caps lock and num lock modifiers.
+	keyPrivateStart = 0xe000   // Kitty functional keys (F13, keypad, media...)
+	keyPrivateEnd   = 0xf8ff   // are encoded in the private use area.
+)
+
+// decodeExtendedKeys decodes the keys sent by terminals using an extended keyboard
+// protocol. Keys bound with their extended sequence (like \C-\S-a or \C-\r) are
+// normalized to it, others are replaced with their legacy sequence (without the
+// modifiers it cannot encode), so that all other binds still work. Key releases
+// and functional keys of the private use area are dropped.
+func (k *Keys) decodeExtendedKeys(keys []byte) []byte {
+	if !rxKittyKey.Match(keys) && !rxOtherKeysKey.Match(keys) {
+		return keys
+	}
+
+	keys = rxKittyKey.ReplaceAllFunc(keys, func(seq []byte) []byte {
+		match := rxKittyKey.FindSubmatch(seq)
+		if len(match[3]) > 0 && atoi(match[3]) == keyEventRelease {
+			return nil
+		}
+
+		return k.extendedKey(atoi(match[1]), atoi(match[2]))
+	})
+
+	return rxOtherKeysKey.ReplaceAllFunc(keys, func(seq []byte) []byte {
+		match := rxOtherKeysKey.FindSubmatch(seq)
+		return k.extendedKey(atoi(match[2]), atoi(match[1]))
+	})
+}
+
+// extendedKey returns the sequence used for a key with encoded modifiers (1 + flags).
+func (k *Keys) extendedKey(code, encoded int) []byte {
+	if code > unicode.MaxRune || (code >= keyPrivateStart && code <= keyPrivateEnd) {
+		return nil
+	}
+
+	mods := 0
+	if encoded > 0 {
+		mods = (encoded - 1) &^ keyModsLocks
+	}
+
+	key := rune(code)
+
+	// Terminals might report Shift with the shifted key.
+	if mods&inputrc.ModShift != 0 && unicode.IsUpper(key) {
+		key = unicode.ToLower(key)
+	}
+
+	extended := inputrc.ExtendedKey(key, mods)
+	if !k.bound(extended) {
+		extended, _ = inputrc.LegacyKey(key, mods&(inputrc.ModShift|inputrc.ModMeta|inputrc.ModControl))
+	}
+
+	return []byte(extended)
+}
+
+// bound returns true if a sequence is used by a bind in any keymap.
+func (k *Keys) bound(seq string) bool {
+	if k.cfg == nil {
+		return false
+	}
+
+	for _, binds := range k.cfg.Binds {
+		for bind := range binds {
+			if strings.Contains(bind, seq) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func atoi(number []byte) int {
+	value, _ := strconv.Atoi(string(number))
+	return value
+}
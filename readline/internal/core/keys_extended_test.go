@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/chainreactors/tui/readline/inputrc"
+)
+
+func TestDecodeExtendedKeys(t *testing.T) {
+	cfg := inputrc.NewDefaultConfig()
+	cfg.Bind("emacs", inputrc.Unescape(`\C-\S-a`), "end-of-line", false)
+	cfg.Bind("emacs", inputrc.Unescape(`\C-\r`), "accept-line", false)
+
+	keys := &Keys{cfg: cfg}
+
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{name: "Legacy keys", keys: "ab\x1b[A", want: "ab\x1b[A"},
+		{name: "Bound Control-Shift", keys: "\x1b[97;6u", want: "\x1b[97;6u"},
+		{name: "Shifted key reported", keys: "\x1b[65;6u", want: "\x1b[97;6u"},
+		{name: "Bound Control-Enter", keys: "\x1b[13;5u", want: "\x1b[13;5u"},
+		{name: "Unbound Control-Shift", keys: "\x1b[98;6u", want: "\x02"},
+		{name: "Control-I", keys: "\x1b[105;5u", want: "\t"},
+		{name: "Escape", keys: "\x1b[27u", want: "\x1b"},
+		{name: "Alt", keys: "\x1b[102;3u", want: "\x1bf"},
+		{name: "Caps lock", keys: "\x1b[97;69u", want: "\x01"},
+		{name: "Key release", keys: "x\x1b[97;5:3u", want: "x"},
+		{name: "Key repeat", keys: "\x1b[97;5:2u", want: "\x01"},
+		{name: "Private use area", keys: "\x1b[57399u", want: ""},
+		{name: "modifyOtherKeys", keys: "\x1b[27;6;97~\x1b[27;5;13~", want: "\x1b[97;6u\x1b[13;5u"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := string(keys.decodeExtendedKeys([]byte(test.keys))); got != test.want {
+				t.Errorf("decodeExtendedKeys(%q) = %q, want %q", test.keys, got, test.want)
+			}
+		})
+	}
+}
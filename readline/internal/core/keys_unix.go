@@ -30,10 +30,25 @@ func (k *Keys) GetCursorPos() (x, y int) {
 
 	deadline := time.Now().Add(cursorPosTimeout)
 
+	// Keys read while waiting for a response that never came (incomplete
+	// escape sequences, like those of extended keyboard protocols) are user input.
+	defer func() {
+		if len(pending) > 0 && !rxRcvCursorPos.Match(pending) {
+			k.mutex.Lock()
+			k.buf = append(k.buf, pending...)
+			k.mutex.Unlock()
+		}
+	}()
+
+	// The main loop may be waiting for keys in another goroutine.
+	k.mutex.RLock()
+	cursorReplies := k.cursor
+	k.mutex.RUnlock()
+
 drain:
 	for {
 		select {
-		case <-k.cursor:
+		case <-cursorReplies:
 		default:
 			break drain
 		}
@@ -48,14 +63,14 @@ drain:
 	// Everything else is passed back as user input.
 	for {
 		switch {
-		case k.waiting, k.reading:
+		case k.IsWaiting(), k.IsReading():
 			remaining := time.Until(deadline)
 			if remaining <= 0 {
 				return -1, -1
 			}
 
 			select {
-			case cursor = <-k.cursor:
+			case cursor = <-cursorReplies:
 			case <-time.After(remaining):
 				return -1, -1
 			}
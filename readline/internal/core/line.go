@@ -341,6 +341,56 @@ func CoordinatesLine(l *Line, indent int) (int, int) {
 	return usedX, usedY
 }
 
+// PositionAt returns the position in the line of the character displayed at the given
+// coordinates, which are computed like with CoordinatesLine: x is the terminal column,
+// and y the terminal row relative to the first one of the line. Coordinates after the
+// end of a line (or in the middle of a wide character) return the closest position
+// before them, those before it its first position, and rows before or after the line
+// its beginning or end.
+func PositionAt(l *Line, indent, x, y int) int {
+	if y < 0 {
+		return 0
+	}
+
+	width := term.GetWidth()
+	row, used, first, found := 0, indent, -1, -1
+
+	for pos := 0; pos <= l.Len(); pos++ {
+		posX, posY := used%width, row+used/width
+
+		if posY > y {
+			break
+		}
+
+		if posY == y && first == -1 {
+			first = pos
+		}
+
+		if posY == y && posX <= x {
+			found = pos
+		}
+
+		if pos == l.Len() {
+			break
+		}
+
+		if (*l)[pos] == inputrc.Newline {
+			row, used = posY+1, indent
+		} else {
+			used += strutil.RealLength(string((*l)[pos]))
+		}
+	}
+
+	switch {
+	case found != -1:
+		return found
+	case first != -1:
+		return first
+	default:
+		return l.Len()
+	}
+}
+
 // Lines returns the number of real lines in the input buffer.
 // If there are no newlines, the result is 0, otherwise it's
 // the number of newlines - 1.
@@ -2,6 +2,7 @@ package core
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/chainreactors/tui/readline/internal/term"
@@ -1103,3 +1104,35 @@ func TestCoordinatesLine(t *testing.T) {
 		})
 	}
 }
+
+func TestPositionAt(t *testing.T) {
+	indent := 2
+	multiline := Line("git log\nshow 世界 x")
+	wrapped := Line(strings.Repeat("a", 100))
+
+	tests := []struct {
+		name string
+		l    *Line
+		x, y int
+		want int
+	}{
+		{name: "Character", l: &multiline, x: 6, y: 0, want: 4},
+		{name: "Prompt", l: &multiline, x: 0, y: 0, want: 0},
+		{name: "After end of line", l: &multiline, x: 40, y: 0, want: 7},
+		{name: "Wide character", l: &multiline, x: 9, y: 1, want: 14},
+		{name: "Middle of wide character", l: &multiline, x: 8, y: 1, want: 13},
+		{name: "After end of buffer", l: &multiline, x: 50, y: 1, want: 17},
+		{name: "Row before line", l: &multiline, x: 5, y: -1, want: 0},
+		{name: "Row after line", l: &multiline, x: 5, y: 5, want: 17},
+		{name: "Wrapped line start", l: &wrapped, x: 0, y: 1, want: 78},
+		{name: "Wrapped line", l: &wrapped, x: 5, y: 1, want: 83},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := PositionAt(test.l, indent, test.x, test.y); got != test.want {
+				t.Errorf("PositionAt() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
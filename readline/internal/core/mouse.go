@@ -0,0 +1,67 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Mouse buttons reported in mouse events.
+const (
+	MouseLeft      = 0
+	MouseMiddle    = 1
+	MouseRight     = 2
+	MouseWheelUp   = 64
+	MouseWheelDown = 65
+)
+
+const (
+	mouseModifiers = 4 | 8 | 16 // Shift, Meta and Control.
+	mouseMotion    = 32
+)
+
+// Mouse events sent by terminals in SGR mouse mode (1006): CSI < button ; x ; y M,
+// or m when the button is released.
+var (
+	rxMouseEvent   = regexp.MustCompile(`^\x1b\[<([0-9]+);([0-9]+);([0-9]+)([Mm])`)
+	rxMousePartial = regexp.MustCompile(`^\x1b\[<[0-9;]*$`)
+)
+
+// MouseEvent is a mouse button press, release or motion reported by the terminal.
+type MouseEvent struct {
+	Button  int  // Button, like MouseLeft or MouseWheelUp (without modifiers).
+	X, Y    int  // Terminal column and row, starting at 1.
+	Motion  bool // The mouse moved while the button is pressed.
+	Release bool // The button has been released.
+}
+
+// ReadMouse pops a mouse event if it is the next input in the key stack. If the key
+// stack only has the beginning of a mouse event, read is true and the event is nil:
+// more keys must be read from stdin before trying again.
+func ReadMouse(keys *Keys) (event *MouseEvent, read bool) {
+	keys.mutex.Lock()
+	defer keys.mutex.Unlock()
+
+	if rxMousePartial.Match(keys.buf) {
+		keys.mustWait = true
+		return nil, true
+	}
+
+	match := rxMouseEvent.FindSubmatch(keys.buf)
+	if match == nil {
+		return nil, false
+	}
+
+	keys.buf = keys.buf[len(match[0]):]
+
+	button, _ := strconv.Atoi(string(match[1]))
+	event = &MouseEvent{
+		Button:  button &^ (mouseModifiers | mouseMotion),
+		Motion:  button&mouseMotion != 0,
+		Release: match[4][0] == 'm',
+	}
+
+	event.X, _ = strconv.Atoi(string(match[2]))
+	event.Y, _ = strconv.Atoi(string(match[3]))
+
+	return event, true
+}
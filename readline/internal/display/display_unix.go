@@ -14,11 +14,13 @@ func WatchResize(eng *Engine) chan<- bool {
 	done := make(chan bool, 1)
 	output := term.Output()
 	control := term.CurrentControl()
+	// Terminal controls (eg. remote ones) notify resizes from their own goroutine:
+	// only redisplay while the shell is idle, waiting for keys, so that the terminal
+	// reply to the cursor position query reaches it through the waiting read.
 	unregister := term.OnResize(func(_, _ int) {
-		if eng.keys != nil && !eng.keys.IsReading() && !eng.keys.IsWaiting() {
+		if eng.keys != nil && !eng.keys.IsReading() && eng.keys.IsWaiting() {
 			restore := term.Activate(output, control)
-			eng.completer.GenerateCached()
-			eng.Refresh()
+			eng.refreshResized()
 			restore()
 		}
 	})
@@ -30,8 +32,7 @@ func WatchResize(eng *Engine) chan<- bool {
 			case <-resizeChannel:
 				if eng.keys != nil && !eng.keys.IsReading() && !eng.keys.IsWaiting() {
 					restore := term.Activate(output, control)
-					eng.completer.GenerateCached()
-					eng.Refresh()
+					eng.refreshResized()
 					restore()
 				}
 			case <-done:
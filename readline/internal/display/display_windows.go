@@ -17,8 +17,7 @@ func WatchResize(eng *Engine) chan<- bool {
 	control := term.CurrentControl()
 	unregister := term.OnResize(func(_, _ int) {
 		restore := term.Activate(output, control)
-		eng.completer.GenerateCached()
-		eng.Refresh()
+		eng.refreshResized()
 		restore()
 	})
 
@@ -56,8 +55,7 @@ func WatchResize(eng *Engine) chan<- bool {
 				// }
 				//
 				restore := term.Activate(output, control)
-				eng.completer.GenerateCached()
-				eng.Refresh()
+				eng.refreshResized()
 				restore()
 			case <-done:
 				return
@@ -2,6 +2,7 @@ package display
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/chainreactors/tui/readline/inputrc"
 	"github.com/chainreactors/tui/readline/internal/color"
@@ -23,6 +24,7 @@ var (
 type Engine struct {
 	// Operating parameters
 	highlighter    func(line []rune) string
+	hinter         func(line []rune, cursor int) string
 	startCols      int
 	startRows      int
 	lineCol        int
@@ -31,6 +33,7 @@ type Engine struct {
 	cursorCol      int
 	hintRows       int
 	compRows       int
+	lineTop        int // Terminal row of the input line, accounting for scrolling.
 	primaryPrinted bool
 
 	// UI components
@@ -45,6 +48,9 @@ type Engine struct {
 	hint             *ui.Hint
 	completer        *completion.Engine
 	opts             *inputrc.Config
+
+	// Resize events are redisplayed from other goroutines.
+	mutex sync.Mutex
 }
 
 // NewEngine is a required constructor for the display engine.
@@ -61,10 +67,11 @@ func NewEngine(k *core.Keys, s *core.Selection, h *history.Sources, p *ui.Prompt
 }
 
 // Init computes some base coordinates needed before displaying the line and helpers.
-// The shell syntax highlighter is also provided here, since any consumer library will
-// have bound it after instantiating a new shell instance.
-func Init(e *Engine, highlighter func([]rune) string) {
+// The shell syntax highlighter and hinter are also provided here, since any consumer
+// library will have bound them after instantiating a new shell instance.
+func Init(e *Engine, highlighter func([]rune) string, hinter func([]rune, int) string) {
 	e.highlighter = highlighter
+	e.hinter = hinter
 }
 
 // SetInlineSuggestion sets the inline suggestion to display after the cursor.
@@ -97,6 +104,9 @@ func (e *Engine) inlineSuggestionApplies(currentLine string) bool {
 // Refresh recomputes and redisplays the entire readline interface, except
 // the first lines of the primary prompt when the latter is a multiline one.
 func (e *Engine) Refresh() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
 	e.refresh(true)
 }
 
@@ -104,9 +114,46 @@ func (e *Engine) Refresh() {
 // generating a new autocomplete menu. External status/footer updates use this
 // path so an empty prompt cannot flash the full command list.
 func (e *Engine) RefreshWithoutAutocomplete() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
 	e.refresh(false)
 }
 
+// RefreshCompletions regenerates the completions with the candidates available
+// now (like late asynchronous ones), and redisplays the interface if they were.
+// It is called by background completers, concurrently with resize watchers.
+func (e *Engine) RefreshCompletions() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.completer.Regenerate() {
+		e.refresh(false)
+	}
+}
+
+// RefreshLoading redisplays the interface with a new loading indicator
+// for the pending completions, if any, without regenerating them.
+func (e *Engine) RefreshLoading(indicator string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.completer.SetLoading(indicator) {
+		e.refresh(false)
+	}
+}
+
+// refreshResized regenerates the completions for the new terminal size, and
+// redisplays the interface. It is called by resize watchers, concurrently with
+// the shell main loop.
+func (e *Engine) refreshResized() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.completer.GenerateCached()
+	e.refresh(true)
+}
+
 func (e *Engine) refresh(runAutocomplete bool) {
 	term.Print(term.HideCursor)
 
@@ -132,6 +179,11 @@ func (e *Engine) refresh(runAutocomplete bool) {
 	// prompt end (thus indentation), cursor positions, etc.
 	e.computeCoordinates(true)
 
+	// The contextual hint depends on the line and on the cursor position.
+	if e.hinter != nil {
+		e.hint.SetContext(e.hinter(*e.line, e.cursor.Pos()))
+	}
+
 	// Print the line, and any of the secondary and right prompts.
 	e.displayLine()
 	e.displayMultilinePrompts()
@@ -142,6 +194,8 @@ func (e *Engine) refresh(runAutocomplete bool) {
 	e.cursorHintToLineStart()
 	e.lineStartToCursorPos()
 	term.Print(term.ShowCursor)
+
+	e.computeLineTop()
 }
 
 // PrintPrimaryPrompt redraws the primary prompt.
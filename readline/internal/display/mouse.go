@@ -0,0 +1,53 @@
+package display
+
+import (
+	"github.com/chainreactors/tui/readline/internal/core"
+	"github.com/chainreactors/tui/readline/internal/term"
+)
+
+// LinePosition returns the position in the input line of the character displayed
+// at the given terminal coordinates (starting at 1), as reported by mouse events.
+// It returns false if the coordinates are not on the input line, or if its position
+// in the terminal is unknown (the terminal did not answer cursor position queries).
+func (e *Engine) LinePosition(x, y int) (pos int, ok bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	row := y - e.lineTop
+	if e.lineTop < 1 || row < 0 || row > e.lineRows {
+		return -1, false
+	}
+
+	return core.PositionAt(e.line, e.startCols, x-1, row), true
+}
+
+// CompletionPosition returns the row and column in the completion menu of the given
+// terminal coordinates (starting at 1), or false if they are not below the hints.
+func (e *Engine) CompletionPosition(x, y int) (row, col int, ok bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	row = y - (e.lineTop + e.lineRows + 1 + e.hintRows)
+	if e.lineTop < 1 || e.completer.Matches() == 0 || row < 0 || row > e.compRows {
+		return -1, -1, false
+	}
+
+	return row, x - 1, true
+}
+
+// computeLineTop computes the terminal row of the input line once the interface
+// has been displayed: when its helpers do not fit below the line, the terminal
+// scrolled and the line moved up.
+func (e *Engine) computeLineTop() {
+	if e.startRows < 1 {
+		e.lineTop = -1
+		return
+	}
+
+	e.lineTop = e.startRows
+
+	bottom := e.startRows + e.lineRows + 1 + e.hintRows + e.compRows
+	if overflow := bottom - term.GetLength(); overflow > 0 {
+		e.lineTop -= overflow
+	}
+}
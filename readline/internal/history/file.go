@@ -2,24 +2,28 @@ package history
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"os"
+	"io"
 	"strings"
 	"time"
 )
 
 var (
 	errOpenHistoryFile = errors.New("failed to open history file")
+	errLockHistoryFile = errors.New("failed to lock history file")
 	errNegativeIndex   = errors.New("cannot use a negative index when requesting historic commands")
 	errOutOfRangeIndex = errors.New("index requested greater than number of items in history")
 )
 
-// fileHistory provides a history source based on a file.
+// fileHistory provides a history source based on a file,
+// which can be shared with other processes writing to it.
 type fileHistory struct {
-	file  string
+	file  sharedFile
 	lines []Item
+	mode  string // Sharing mode of the file.
+	own   int    // Number of lines written by this process, last when sharing in local mode.
 }
 
 // Item is the structure of an individual item in the History.list slice.
@@ -30,38 +34,52 @@ type Item struct {
 }
 
 // NewSourceFromFile returns a new history source writing to and reading from a file.
+// The file can be shared by several processes: lines are written while holding a
+// lock on it, and those written by other processes are reloaded at each prompt
+// (as specified by the history-share inputrc option).
 func NewSourceFromFile(file string) (Source, error) {
-	var err error
+	hist := &fileHistory{
+		file: sharedFile{name: file},
+		mode: ShareOn,
+	}
 
-	hist := new(fileHistory)
-	hist.file = file
-	hist.lines, err = openHist(file)
+	data, _, err := hist.file.read()
+	hist.lines = readItems(bytes.NewReader(data))
 
 	return hist, err
 }
 
-func openHist(filename string) (list []Item, err error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return list, fmt.Errorf("%w: %s", errOpenHistoryFile, err.Error())
-	}
+// readItems reads the items of a history file, ignoring invalid lines.
+func readItems(reader io.Reader) (list []Item) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20)
 
-	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		var item Item
+		var item legacyItem
 
 		err := json.Unmarshal(scanner.Bytes(), &item)
 		if err != nil || len(item.Block) == 0 {
 			continue
 		}
 
-		item.Index = len(list)
-		list = append(list, item)
+		list = append(list, Item{Index: len(list), DateTime: item.DateTime, Block: item.Block})
 	}
 
-	file.Close()
+	return list
+}
+
+// writeItems returns the lines of a history file with the given items.
+func writeItems(items []Item) (data []byte, err error) {
+	for _, item := range items {
+		line, err := json.Marshal(legacyItem{DateTime: item.DateTime, Block: item.Block})
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(append(data, line...), '\n')
+	}
 
-	return list, nil
+	return data, nil
 }
 
 // Write item to history file.
@@ -71,38 +89,35 @@ func (h *fileHistory) Write(s string) (int, error) {
 		return 0, nil
 	}
 
-	item := Item{
-		DateTime: time.Now(),
-		Block:    block,
-		Index:    len(h.lines),
+	unlock, err := h.file.lock()
+	if err != nil {
+		return h.Len(), err
 	}
+	defer unlock()
 
-	if len(h.lines) == 0 || h.lines[len(h.lines)-1].Block != block {
-		h.lines = append(h.lines, item)
+	if err := h.sync(); err != nil {
+		return h.Len(), err
 	}
 
-	line := struct {
-		DateTime time.Time `json:"datetime"`
-		Block    string    `json:"block"`
-	}{
-		Block:    block,
-		DateTime: item.DateTime,
+	if len(h.lines) > 0 && h.lines[len(h.lines)-1].Block == block {
+		return h.Len(), nil
 	}
 
-	data, err := json.Marshal(line)
-	if err != nil {
-		return h.Len(), err
+	item := Item{
+		DateTime: time.Now(),
+		Block:    block,
+		Index:    len(h.lines),
 	}
 
-	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	data, err := writeItems([]Item{item})
 	if err != nil {
-		return 0, fmt.Errorf("%w: %s", errOpenHistoryFile, err.Error())
+		return h.Len(), err
 	}
 
-	_, err = f.Write(append(data, '\n'))
-	f.Close()
+	h.lines = append(h.lines, item)
+	h.own++
 
-	return h.Len(), err
+	return h.Len(), h.file.append(data)
 }
 
 // GetLine returns a specific line from the history file.
@@ -127,3 +142,128 @@ func (h *fileHistory) Len() int {
 func (h *fileHistory) Dump() interface{} {
 	return h.lines
 }
+
+// Delete removes an item from history, and rewrites the history file without it.
+func (h *fileHistory) Delete(pos int) error {
+	if pos < 0 {
+		return errNegativeIndex
+	}
+
+	if pos >= len(h.lines) {
+		return errOutOfRangeIndex
+	}
+
+	deleted := h.lines[pos]
+
+	unlock, err := h.file.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	h.lines = h.remove(h.lines, deleted, true)
+
+	return h.file.rewrite(func(data []byte) ([]byte, error) {
+		return writeItems(h.remove(readItems(bytes.NewReader(data)), deleted, false))
+	})
+}
+
+// Reload reads the lines written to the history file by other processes.
+func (h *fileHistory) Reload(mode string) error {
+	h.mode = mode
+
+	return h.sync()
+}
+
+// Trim deletes the oldest items, in the history and its file, so that no more than size remain.
+func (h *fileHistory) Trim(size int) error {
+	if size < 0 || len(h.lines) <= size {
+		return nil
+	}
+
+	unlock, err := h.file.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	if len(h.lines) > size {
+		h.own = min(h.own, size)
+		h.lines = h.reindex(h.lines[len(h.lines)-size:])
+	}
+
+	return h.file.rewrite(func(data []byte) ([]byte, error) {
+		items := readItems(bytes.NewReader(data))
+		if len(items) > size {
+			items = items[len(items)-size:]
+		}
+
+		return writeItems(items)
+	})
+}
+
+// sync reads the items written to the file by other processes since the last
+// read and adds them to the history, as specified by the sharing mode. The
+// history is read again from scratch if the file has been replaced.
+func (h *fileHistory) sync() error {
+	data, reset, err := h.file.read()
+	if err != nil || (len(data) == 0 && !reset) {
+		return err
+	}
+
+	items := readItems(bytes.NewReader(data))
+
+	switch {
+	case h.mode == ShareOff:
+		return nil
+
+	case reset:
+		h.lines, h.own = items, 0
+
+	case h.mode == ShareLocal:
+		pos := len(h.lines) - h.own
+		h.lines = append(h.lines[:pos], append(items, h.lines[pos:]...)...)
+
+	default:
+		h.lines, h.own = append(h.lines, items...), 0
+	}
+
+	h.lines = h.reindex(h.lines)
+
+	return nil
+}
+
+// remove removes the last occurrence of an item from a list.
+// If own is true, the list is the history and its own lines are counted.
+func (h *fileHistory) remove(items []Item, deleted Item, own bool) []Item {
+	for i := len(items) - 1; i >= 0; i-- {
+		if !items[i].DateTime.Equal(deleted.DateTime) || items[i].Block != deleted.Block {
+			continue
+		}
+
+		if own && i >= len(items)-h.own {
+			h.own--
+		}
+
+		return h.reindex(append(items[:i], items[i+1:]...))
+	}
+
+	return items
+}
+
+// reindex updates the index of all items.
+func (h *fileHistory) reindex(items []Item) []Item {
+	for i := range items {
+		items[i].Index = i
+	}
+
+	return items
+}
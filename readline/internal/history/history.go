@@ -24,6 +24,19 @@ type Source interface {
 	Dump() interface{}
 }
 
+// Deleter is implemented by history sources whose lines can be deleted.
+type Deleter interface {
+	// Delete removes the historic line at the given line number.
+	Delete(int) error
+}
+
+// Trimmer is implemented by history sources whose oldest lines can be deleted,
+// so that they keep no more than the number of lines allowed (history-size).
+type Trimmer interface {
+	// Trim deletes the oldest lines, so that no more than size remain.
+	Trim(size int) error
+}
+
 // memory is an in memory history.
 // One such history is bound to the readline shell by default.
 type memory struct {
@@ -59,3 +72,27 @@ func (h *memory) Len() int {
 func (h *memory) Dump() interface{} {
 	return h.items
 }
+
+// Delete removes a line from history.
+func (h *memory) Delete(i int) error {
+	if i < 0 {
+		return errNegativeIndex
+	}
+
+	if i >= len(h.items) {
+		return errOutOfRangeIndex
+	}
+
+	h.items = append(h.items[:i], h.items[i+1:]...)
+
+	return nil
+}
+
+// Trim deletes the oldest lines, so that no more than size remain.
+func (h *memory) Trim(size int) error {
+	if size >= 0 && len(h.items) > size {
+		h.items = append([]string(nil), h.items[len(h.items)-size:]...)
+	}
+
+	return nil
+}
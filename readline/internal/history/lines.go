@@ -0,0 +1,115 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var errReadOnlySource = errors.New("history source is read-only")
+
+// SourceLine is a line of a history source, as listed across all sources.
+type SourceLine struct {
+	Line   string
+	Source string    // Name of the history source.
+	Index  int       // Line number in the source.
+	Time   time.Time // Time the line was written, if recorded by the source.
+}
+
+// Lines returns the lines of all history sources, from the newest to the oldest.
+// If all sources record the time of their lines, these are ordered by time,
+// otherwise the lines of each source are listed in turn.
+func (h *Sources) Lines() []SourceLine {
+	var lines []SourceLine
+
+	timed := true
+
+	for _, name := range h.names {
+		source := h.list[name]
+		if source == nil {
+			continue
+		}
+
+		times := sourceTimes(source)
+		if len(times) == 0 && source.Len() > 0 {
+			timed = false
+		}
+
+		for i := source.Len() - 1; i >= 0; i-- {
+			line, err := source.GetLine(i)
+			if err != nil || line == "" {
+				continue
+			}
+
+			listed := SourceLine{Line: line, Source: name, Index: i}
+			if i < len(times) {
+				listed.Time = times[i]
+			}
+
+			lines = append(lines, listed)
+		}
+	}
+
+	if timed && len(h.names) > 1 {
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].Time.After(lines[j].Time)
+		})
+	}
+
+	return lines
+}
+
+// Deletable returns true if lines can be deleted from the named history source.
+func (h *Sources) Deletable(name string) bool {
+	_, deletable := h.list[name].(Deleter)
+	return deletable
+}
+
+// DeleteLines deletes lines from their history sources. All lines
+// from sources which are read-only are left untouched.
+func (h *Sources) DeleteLines(lines ...SourceLine) error {
+	// Delete the last lines first, so that line numbers remain valid.
+	sorted := append([]SourceLine(nil), lines...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Index > sorted[j].Index
+	})
+
+	var errs []error
+
+	for _, line := range sorted {
+		source, deletable := h.list[line.Source].(Deleter)
+		if !deletable {
+			errs = append(errs, fmt.Errorf("%w: %s", errReadOnlySource, line.Source))
+			continue
+		}
+
+		if err := source.Delete(line.Index); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Line numbers have changed: only keep the changes of the current line.
+		if changes := h.lines[line.Source]; changes != nil {
+			h.lines[line.Source] = map[int]*lineHistory{-1: changes[-1]}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sourceTimes returns the times of the lines of a source, if it records them.
+func sourceTimes(source Source) (times []time.Time) {
+	switch lines := source.Dump().(type) {
+	case []Entry:
+		for _, entry := range lines {
+			times = append(times, entry.Time)
+		}
+	case []Item:
+		for _, item := range lines {
+			times = append(times, item.DateTime)
+		}
+	}
+
+	return times
+}
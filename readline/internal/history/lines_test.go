@@ -0,0 +1,67 @@
+package history
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chainreactors/tui/readline/inputrc"
+	"github.com/chainreactors/tui/readline/internal/core"
+)
+
+func TestSourcesLines(t *testing.T) {
+	line := new(core.Line)
+	sources := NewSources(line, core.NewCursor(line), nil, inputrc.NewDefaultConfig())
+
+	file := filepath.Join(t.TempDir(), "history")
+	store, _ := NewStore(file)
+	now := time.Now()
+
+	store.Record(
+		Entry{Line: "sessions", Menu: "main", Time: now.Add(-time.Hour)},
+		Entry{Line: "ls", Menu: "implant", Time: now.Add(-time.Minute)},
+		Entry{Line: "use 1", Menu: "main", Time: now},
+	)
+
+	sources.Add("main", store.View(Filter{Menu: "main"}))
+	sources.Add("implant", store.View(Filter{Menu: "implant"}))
+
+	lines := sources.Lines()
+
+	var got []string
+	for _, line := range lines {
+		got = append(got, line.Source+": "+line.Line)
+	}
+
+	if want := []string{"main: use 1", "implant: ls", "main: sessions"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lines() = %q, want %q", got, want)
+	}
+
+	if err := sources.DeleteLines(lines[0], lines[2]); err != nil {
+		t.Fatalf("DeleteLines() error = %v", err)
+	}
+
+	reloaded, _ := NewStore(file)
+	if got := storeLines(reloaded.Entries(Filter{})); !reflect.DeepEqual(got, []string{"ls"}) {
+		t.Fatalf("store file lines after deletion = %q", got)
+	}
+
+	sources.Add("legacy", readOnly{})
+
+	if sources.Deletable("legacy") {
+		t.Fatal("source without Delete() reported as deletable")
+	}
+
+	if err := sources.DeleteLines(SourceLine{Line: "pwd", Source: "legacy"}); err == nil {
+		t.Fatal("DeleteLines() from a read-only source should fail")
+	}
+}
+
+// readOnly is a history source from which lines cannot be deleted.
+type readOnly struct{}
+
+func (readOnly) Write(string) (int, error)   { return 1, nil }
+func (readOnly) GetLine(int) (string, error) { return "pwd", nil }
+func (readOnly) Len() int                    { return 1 }
+func (readOnly) Dump() interface{}           { return nil }
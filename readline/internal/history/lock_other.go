@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package history
+
+import "os"
+
+// lockFile does nothing on systems without advisory file locks.
+func lockFile(_ *os.File) error {
+	return nil
+}
+
+// unlockFile does nothing on systems without advisory file locks.
+func unlockFile(_ *os.File) error {
+	return nil
+}
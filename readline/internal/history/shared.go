@@ -0,0 +1,178 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Modes of sharing history files with other processes (shells, consoles...),
+// selected with the history-share inputrc option.
+const (
+	ShareOn    = "on"    // Lines written by other processes are reloaded at each prompt.
+	ShareLocal = "local" // Same, but they are listed before the lines written by this process.
+	ShareOff   = "off"   // Lines written by other processes are only read at startup.
+)
+
+// Shared is implemented by history sources stored in files that several
+// processes write to. Files are only modified while holding a lock, either
+// by appending whole lines or by atomically replacing them.
+type Shared interface {
+	// Reload reads the lines written to the file by other processes,
+	// as specified by the sharing mode, which is used for writes as well.
+	Reload(mode string) error
+
+	Trimmer
+}
+
+// sharedFile is a history file read incrementally: lines appended
+// by other processes are read without reading the whole file again.
+type sharedFile struct {
+	name   string
+	offset int64       // Size of the file already read.
+	info   os.FileInfo // File already read, to detect it being replaced.
+}
+
+// lock acquires an advisory lock shared by all processes writing the file,
+// and returns a function releasing it. A lock file is used, since the history
+// file itself is replaced when rewritten.
+func (f *sharedFile) lock() (unlock func(), err error) {
+	file, err := os.OpenFile(f.name+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errOpenHistoryFile, err.Error())
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s", errLockHistoryFile, err.Error())
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+// read returns the lines appended to the file since the last read, or all of them
+// if the file has been replaced (reset is then true). Incomplete lines, still being
+// written, are read on the next call. A missing file has no lines.
+func (f *sharedFile) read() (data []byte, reset bool, err error) {
+	file, err := os.Open(f.name)
+	if errors.Is(err, os.ErrNotExist) {
+		reset = f.info != nil
+		f.offset, f.info = 0, nil
+
+		return nil, reset, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", errOpenHistoryFile, err.Error())
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if f.info == nil || !os.SameFile(f.info, info) || info.Size() < f.offset {
+		f.offset = 0
+		reset = true
+	}
+
+	f.info = info
+
+	if info.Size() == f.offset {
+		return nil, reset, nil
+	}
+
+	if _, err = file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, reset, err
+	}
+
+	data, err = io.ReadAll(io.LimitReader(file, info.Size()-f.offset))
+	if err != nil {
+		return nil, reset, err
+	}
+
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	f.offset += int64(len(data))
+
+	return data, reset, nil
+}
+
+// append appends lines to the file. The lock must be held and the file
+// read up to its end, so that the lines written are not read back.
+func (f *sharedFile) append(data []byte) error {
+	file, err := os.OpenFile(f.name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errOpenHistoryFile, err.Error())
+	}
+
+	_, err = file.Write(data)
+
+	if info, statErr := file.Stat(); err == nil && statErr == nil {
+		f.info, f.offset = info, info.Size()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// rewrite replaces the file with an edited version of all its lines.
+// The lock must be held, and the file is not read again afterwards.
+func (f *sharedFile) rewrite(edit func(data []byte) ([]byte, error)) error {
+	data, err := os.ReadFile(f.name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", errOpenHistoryFile, err.Error())
+	}
+
+	if data, err = edit(data); err != nil {
+		return err
+	}
+
+	if err := replaceFile(f.name, data); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(f.name)
+	if err != nil {
+		return err
+	}
+
+	f.info, f.offset = info, info.Size()
+
+	return nil
+}
+
+// replaceFile atomically replaces the contents of a history file,
+// so that it is never left truncated if the write is interrupted.
+func replaceFile(name string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return fmt.Errorf("%w: %s", errOpenHistoryFile, err.Error())
+	}
+
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(temp.Name(), 0o600)
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), name)
+	}
+
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,137 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func sourceLines(source Source) (lines []string) {
+	for i := 0; i < source.Len(); i++ {
+		line, _ := source.GetLine(i)
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestSharedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+
+	first, _ := NewSourceFromFile(file)
+	second, _ := NewSourceFromFile(file)
+
+	first.Write("sessions")
+	second.Write("use 1")
+
+	if err := first.(Shared).Reload(ShareOn); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := sourceLines(first); !reflect.DeepEqual(got, []string{"sessions", "use 1"}) {
+		t.Fatalf("lines after reload = %q", got)
+	}
+
+	// Lines written by other processes are listed before ours.
+	local, _ := NewSourceFromFile(file)
+	local.(Shared).Reload(ShareLocal)
+	local.Write("sysinfo")
+	first.Write("jobs")
+	local.(Shared).Reload(ShareLocal)
+
+	if got := sourceLines(local); !reflect.DeepEqual(got, []string{"sessions", "use 1", "jobs", "sysinfo"}) {
+		t.Fatalf("lines in local order = %q", got)
+	}
+
+	// Lines written by other processes are ignored.
+	second.(Shared).Reload(ShareOff)
+	first.Write("exit")
+	second.(Shared).Reload(ShareOff)
+
+	if got := sourceLines(second); !reflect.DeepEqual(got, []string{"sessions", "use 1"}) {
+		t.Fatalf("lines without sharing = %q", got)
+	}
+
+	if err := first.(Shared).Trim(2); err != nil {
+		t.Fatalf("Trim() error = %v", err)
+	}
+
+	reloaded, _ := NewSourceFromFile(file)
+	if got := sourceLines(reloaded); !reflect.DeepEqual(got, []string{"jobs", "exit"}) {
+		t.Fatalf("lines after trimming = %q", got)
+	}
+
+	// The file has been replaced: it is read again.
+	second.(Shared).Reload(ShareOn)
+
+	if got := sourceLines(second); !reflect.DeepEqual(got, []string{"jobs", "exit"}) {
+		t.Fatalf("lines after the file was trimmed = %q", got)
+	}
+}
+
+func TestSharedFileConcurrentWrites(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+
+	var wait sync.WaitGroup
+
+	for writer := 0; writer < 8; writer++ {
+		wait.Add(1)
+
+		go func(writer int) {
+			defer wait.Done()
+
+			source, _ := NewSourceFromFile(file)
+			for i := 0; i < 25; i++ {
+				source.Write(fmt.Sprintf("command %d-%d", writer, i))
+			}
+		}(writer)
+	}
+
+	wait.Wait()
+
+	source, _ := NewSourceFromFile(file)
+	if source.Len() != 200 {
+		t.Fatalf("file has %d lines, want 200", source.Len())
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+
+	first, _ := NewStore(file)
+	second, _ := NewStore(file)
+
+	first.Write("sessions")
+	second.Write("use 1")
+	first.Finish(time.Second, 1)
+
+	if err := second.Reload(ShareOn); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	entries := second.Entries(Filter{})
+	if got := storeLines(entries); !reflect.DeepEqual(got, []string{"sessions", "use 1"}) {
+		t.Fatalf("store lines after reload = %q", got)
+	}
+
+	if entries[0].Status != 1 {
+		t.Fatalf("result of a command of another process not reloaded: %+v", entries[0])
+	}
+
+	// Our own entry is still the one finished.
+	if err := second.Finish(time.Second, 0); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	if err := first.Trim(1); err != nil {
+		t.Fatalf("Trim() error = %v", err)
+	}
+
+	reloaded, _ := NewStore(file)
+	if got := reloaded.Entries(Filter{}); len(got) != 1 || got[0].Line != "use 1" || got[0].Status != 0 {
+		t.Fatalf("store entries after trimming = %+v", got)
+	}
+}
@@ -86,6 +86,10 @@ func Init(hist *Sources) {
 		hist.cpos = -1
 	}()
 
+	if !hist.infer {
+		hist.reload()
+	}
+
 	if hist.acceptHold {
 		hist.hpos = -1
 		hist.line.Set(hist.acceptLine...)
@@ -129,9 +133,7 @@ func (h *Sources) Add(name string, hist Source) {
 // AddFromFile adds a command history source from a file path.
 // The name is used when using/searching the history source.
 func (h *Sources) AddFromFile(name, file string) {
-	hist := new(fileHistory)
-	hist.file = file
-	hist.lines, _ = openHist(file)
+	hist, _ := NewSourceFromFile(file)
 
 	h.Add(name, hist)
 }
@@ -308,9 +310,11 @@ func (h *Sources) Write(infer bool) {
 			continue
 		}
 
-		// Don't write it if the history source has reached
-		// the maximum number of lines allowed (inputrc)
-		if h.maxEntries == 0 || h.maxEntries >= history.Len() {
+		trimmer, canTrim := history.(Trimmer)
+
+		// Don't write it if the history source has reached the maximum
+		// number of lines allowed (inputrc), unless it can be trimmed.
+		if !canTrim && h.maxEntries >= 0 && history.Len() >= h.maxEntries {
 			continue
 		}
 
@@ -327,6 +331,29 @@ func (h *Sources) Write(infer bool) {
 		if err != nil {
 			h.hint.Set(color.FgRed + err.Error())
 		}
+
+		if canTrim && h.maxEntries >= 0 {
+			if err := trimmer.Trim(h.maxEntries); err != nil {
+				h.hint.Set(color.FgRed + err.Error())
+			}
+		}
+	}
+}
+
+// reload reads the lines written to shared history files by other
+// processes since the last prompt, as specified by the sharing mode.
+func (h *Sources) reload() {
+	mode := h.config.GetString("history-share")
+
+	for _, history := range h.list {
+		shared, isShared := history.(Shared)
+		if !isShared {
+			continue
+		}
+
+		if err := shared.Reload(mode); err != nil {
+			h.hint.Set(color.FgRed + err.Error())
+		}
 	}
 }
 
@@ -0,0 +1,38 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chainreactors/tui/readline/inputrc"
+	"github.com/chainreactors/tui/readline/internal/core"
+	"github.com/chainreactors/tui/readline/internal/ui"
+)
+
+func TestSourcesWriteTrims(t *testing.T) {
+	config := inputrc.NewDefaultConfig()
+	config.Set("history-size", 2)
+
+	line := new(core.Line)
+	sources := NewSources(line, core.NewCursor(line), new(ui.Hint), config)
+
+	memory := NewInMemoryHistory()
+	store, _ := NewStore("")
+	sources.Add("memory", memory)
+	sources.Add("store", store)
+
+	for _, input := range []string{"one", "two", "three"} {
+		line.Set([]rune(input)...)
+		sources.Write(false)
+	}
+
+	want := []string{"two", "three"}
+
+	if got := memory.Dump(); !reflect.DeepEqual(got, want) {
+		t.Errorf("memory history = %q, want %q", got, want)
+	}
+
+	if got := storeLines(store.Entries(Filter{})); !reflect.DeepEqual(got, want) {
+		t.Errorf("store history = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,749 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusUnknown is the exit status of entries whose command has not
+// finished yet, or whose result was not recorded (like imported ones).
+const StatusUnknown = -1
+
+// Formats of the history files of other shells, to import and export entries.
+const (
+	FormatBash = "bash" // A line per command, preceded by a #timestamp comment (if HISTTIMEFORMAT is set).
+	FormatZsh  = "zsh"  // Extended history lines: ": <start>:<elapsed>;<command>".
+)
+
+var (
+	errUnknownFormat = errors.New("unknown history format")
+	errNoEntry       = errors.New("no history entry to finish")
+)
+
+var (
+	bashTimestamp = regexp.MustCompile(`^#([0-9]+)$`)
+	zshExtended   = regexp.MustCompile(`(?s)^: *([0-9]+):([0-9]+);(.*)$`)
+)
+
+// Entry is a command line recorded in a history store, with its context.
+type Entry struct {
+	Line     string        `json:"line"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Status   int           `json:"status"`
+	Menu     string        `json:"menu,omitempty"`
+	Context  string        `json:"context,omitempty"` // Session, target or other context the command ran in.
+	Dir      string        `json:"dir,omitempty"`
+}
+
+// Filter selects history entries: all fields set must match.
+type Filter struct {
+	Menu    string    // Entries written in this menu.
+	Context string    // Entries written in this context.
+	Dir     string    // Entries written in this working directory.
+	Match   string    // Entries containing this string.
+	Since   time.Time // Entries written after this time.
+	Failed  bool      // Entries whose command failed.
+	Unique  bool      // Only the last entry of each command line.
+}
+
+// Store is a history source recording, along with command lines, the time,
+// duration and exit status of their commands, and the context they ran in.
+// Its entries can be queried, imported from and exported to bash and zsh
+// history files. When bound to a file, entries are appended to it as JSON
+// lines: history files of NewSourceFromFile can be opened as stores. Like those,
+// store files can be shared by several processes (see the Shared interface).
+type Store struct {
+	mutex      sync.RWMutex
+	file       sharedFile
+	entries    []Entry
+	version    int
+	context    func() string
+	mode       string // Sharing mode of the file.
+	own        int    // Number of entries recorded by this process, last when sharing in local mode.
+	unfinished *Entry // Last entry written, until the result of its command is recorded.
+}
+
+// record is an entry as written to a store file, possibly
+// updating the entry written before with the same time and line.
+type record struct {
+	Entry
+	Amend bool `json:"amend,omitempty"`
+}
+
+// legacyItem is an entry as written by file history sources.
+type legacyItem struct {
+	DateTime time.Time `json:"datetime"`
+	Block    string    `json:"block"`
+}
+
+// NewStore returns a new history store, with the entries of a file to
+// which new ones are appended, or only kept in memory if file is empty.
+func NewStore(file string) (*Store, error) {
+	store := &Store{
+		file: sharedFile{name: file},
+		mode: ShareOn,
+	}
+
+	if file == "" {
+		return store, nil
+	}
+
+	data, _, err := store.file.read()
+	store.entries = addRecords(nil, readRecords(bytes.NewReader(data)), 0)
+
+	return store, err
+}
+
+// readRecords reads the records of a store file, ignoring invalid lines.
+func readRecords(reader io.Reader) (records []record) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20)
+
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+
+		if rec.Line == "" {
+			var item legacyItem
+			if err := json.Unmarshal(scanner.Bytes(), &item); err != nil || item.Block == "" {
+				continue
+			}
+
+			rec.Entry = Entry{Line: item.Block, Time: item.DateTime, Status: StatusUnknown}
+		}
+
+		records = append(records, rec)
+	}
+
+	return records
+}
+
+// writeRecords returns the lines of a store file with the given records.
+func writeRecords(records []record) (data []byte, err error) {
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(append(data, line...), '\n')
+	}
+
+	return data, nil
+}
+
+// addRecords inserts the entries of records in a list at the given
+// position, and applies amendments to the entries they update.
+func addRecords(entries []Entry, records []record, pos int) []Entry {
+	var added []Entry
+
+	for _, rec := range records {
+		if !rec.Amend {
+			added = append(added, rec.Entry)
+		} else if !amendEntry(added, rec.Entry) {
+			amendEntry(entries, rec.Entry)
+		}
+	}
+
+	return append(entries[:pos], append(added, entries[pos:]...)...)
+}
+
+// amendEntry replaces the last entry with the same time and line.
+func amendEntry(entries []Entry, entry Entry) bool {
+	if i := findEntry(entries, entry); i >= 0 {
+		entries[i] = entry
+		return true
+	}
+
+	return false
+}
+
+// findEntry returns the index of the last entry with the same time and line, or -1.
+func findEntry(entries []Entry, entry Entry) int {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Time.Equal(entry.Time) && entries[i].Line == entry.Line {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// SetContext sets a function returning the context (session, target, etc)
+// recorded with the lines written to the store, when not given by a view.
+func (s *Store) SetContext(context func() string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.context = context
+}
+
+// Write records a command line, with the current time and working directory.
+func (s *Store) Write(line string) (int, error) {
+	return s.write(line, Filter{})
+}
+
+func (s *Store) write(line string, view Filter) (int, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return s.Len(), nil
+	}
+
+	entry := Entry{
+		Line:    line,
+		Time:    time.Now(),
+		Status:  StatusUnknown,
+		Menu:    view.Menu,
+		Context: view.Context,
+	}
+
+	entry.Dir, _ = os.Getwd()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry.Context == "" && s.context != nil {
+		entry.Context = s.context()
+	}
+
+	err := s.record(entry)
+	s.unfinished = &entry
+
+	return len(s.entries), err
+}
+
+// Record adds entries to the store, as they are.
+func (s *Store) Record(entries ...Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.record(entries...)
+}
+
+func (s *Store) record(entries ...Entry) error {
+	return s.update(func() []record {
+		records := make([]record, 0, len(entries))
+
+		for _, entry := range entries {
+			s.entries = append(s.entries, entry)
+			records = append(records, record{Entry: entry})
+		}
+
+		s.own += len(entries)
+
+		return records
+	})
+}
+
+// Finish records the duration and exit status of the command of the last
+// entry written, unless already done (like when the line was not written
+// because it was identical to the previous one).
+func (s *Store) Finish(duration time.Duration, status int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.unfinished == nil {
+		return errNoEntry
+	}
+
+	entry := *s.unfinished
+	entry.Duration = duration
+	entry.Status = status
+	s.unfinished = nil
+
+	return s.update(func() []record {
+		amendEntry(s.entries, entry)
+		return []record{{Entry: entry, Amend: true}}
+	})
+}
+
+// Delete removes an entry from the store, and rewrites the store file without it.
+func (s *Store) Delete(pos int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if pos < 0 {
+		return errNegativeIndex
+	}
+
+	if pos >= len(s.entries) {
+		return errOutOfRangeIndex
+	}
+
+	return s.delete(s.entries[pos])
+}
+
+// Reload reads the entries written to the store file by other processes.
+func (s *Store) Reload(mode string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.mode = mode
+
+	if s.file.name == "" {
+		return nil
+	}
+
+	return s.sync()
+}
+
+// Trim deletes the oldest entries, in the store and its file, so that no more than size remain.
+func (s *Store) Trim(size int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if size < 0 || len(s.entries) <= size {
+		return nil
+	}
+
+	return s.rewrite(func(entries []Entry) []Entry {
+		if len(entries) > size {
+			entries = entries[len(entries)-size:]
+		}
+
+		return entries
+	})
+}
+
+// update reads the entries written to the store file by other processes,
+// applies a change to the entries, and appends the records of the change
+// to the file. The mutex must be held.
+func (s *Store) update(change func() []record) error {
+	defer func() { s.version++ }()
+
+	if s.file.name == "" {
+		change()
+		return nil
+	}
+
+	unlock, err := s.file.lock()
+	if err != nil {
+		change()
+		return err
+	}
+	defer unlock()
+
+	if err := s.sync(); err != nil {
+		change()
+		return err
+	}
+
+	data, err := writeRecords(change())
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	return s.file.append(data)
+}
+
+// delete removes an entry from the store and its file. The mutex must be held.
+func (s *Store) delete(deleted Entry) error {
+	return s.rewrite(func(entries []Entry) []Entry {
+		if i := findEntry(entries, deleted); i >= 0 {
+			entries = append(entries[:i], entries[i+1:]...)
+		}
+
+		return entries
+	})
+}
+
+// rewrite applies a change to the entries of the store, after reading those written
+// by other processes, and to the entries of its file, which is then replaced.
+// The mutex must be held.
+func (s *Store) rewrite(change func(entries []Entry) []Entry) error {
+	defer func() { s.version++ }()
+
+	if s.file.name == "" {
+		s.entries = change(s.entries)
+		return nil
+	}
+
+	unlock, err := s.file.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.sync(); err != nil {
+		return err
+	}
+
+	own := append([]Entry(nil), s.entries[len(s.entries)-s.own:]...)
+	s.entries = change(s.entries)
+	s.own = ownEntries(s.entries, own)
+
+	return s.file.rewrite(func(data []byte) ([]byte, error) {
+		entries := change(addRecords(nil, readRecords(bytes.NewReader(data)), 0))
+
+		records := make([]record, 0, len(entries))
+		for _, entry := range entries {
+			records = append(records, record{Entry: entry})
+		}
+
+		return writeRecords(records)
+	})
+}
+
+// ownEntries returns the number of entries at the end of a list which were recorded
+// by this process, given the ones at the end of the list before it was changed.
+func ownEntries(entries, own []Entry) (count int) {
+	for i, j := len(entries)-1, len(own)-1; i >= 0 && j >= 0; j-- {
+		if entries[i].Time.Equal(own[j].Time) && entries[i].Line == own[j].Line {
+			count++
+			i--
+		}
+	}
+
+	return count
+}
+
+// sync reads the records written to the file by other processes since the last
+// read and applies them to the entries, as specified by the sharing mode. The
+// entries are read again from scratch if the file has been replaced.
+// The mutex must be held.
+func (s *Store) sync() error {
+	data, reset, err := s.file.read()
+	if err != nil || (len(data) == 0 && !reset) {
+		return err
+	}
+
+	records := readRecords(bytes.NewReader(data))
+
+	switch {
+	case s.mode == ShareOff:
+		return nil
+
+	case reset:
+		s.entries, s.own = addRecords(nil, records, 0), 0
+
+	case s.mode == ShareLocal:
+		s.entries = addRecords(s.entries, records, len(s.entries)-s.own)
+
+	default:
+		s.entries, s.own = addRecords(s.entries, records, len(s.entries)), 0
+	}
+
+	s.version++
+
+	return nil
+}
+
+// GetLine returns the command line of an entry.
+func (s *Store) GetLine(pos int) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if pos < 0 {
+		return "", errNegativeIndex
+	}
+
+	if pos >= len(s.entries) {
+		return "", errOutOfRangeIndex
+	}
+
+	return s.entries[pos].Line, nil
+}
+
+// Len returns the number of entries in the store.
+func (s *Store) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.entries)
+}
+
+// Dump returns all entries of the store ([]Entry).
+func (s *Store) Dump() interface{} {
+	return s.Entries(Filter{})
+}
+
+// Entries returns the entries matching a filter, from the oldest to the newest.
+func (s *Store) Entries(filter Filter) []Entry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return filter.apply(s.entries)
+}
+
+// View returns a history source with the entries of the store matching a
+// filter, for instance those of a menu. The lines written to it are recorded
+// in the store with the menu and context of the filter, when they are set.
+func (s *Store) View(filter Filter) Source {
+	return &storeView{store: s, filter: filter, version: -1}
+}
+
+// Import adds the entries of a bash or zsh history file to the store,
+// and returns the number of entries imported.
+func (s *Store) Import(reader io.Reader, format string) (int, error) {
+	var entries []Entry
+	var err error
+
+	switch format {
+	case FormatBash:
+		entries, err = readBash(reader)
+	case FormatZsh:
+		entries, err = readZsh(reader)
+	default:
+		return 0, fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	return len(entries), s.Record(entries...)
+}
+
+// Export writes the entries matching a filter in the bash or zsh history format.
+func (s *Store) Export(writer io.Writer, format string, filter Filter) error {
+	var buf strings.Builder
+
+	for _, entry := range s.Entries(filter) {
+		switch format {
+		case FormatBash:
+			if !entry.Time.IsZero() {
+				fmt.Fprintf(&buf, "#%d\n", entry.Time.Unix())
+			}
+
+			buf.WriteString(entry.Line + "\n")
+
+		case FormatZsh:
+			line := strings.ReplaceAll(entry.Line, "\n", "\\\n")
+			fmt.Fprintf(&buf, ": %d:%d;%s\n", entry.Time.Unix(), int(entry.Duration.Seconds()), line)
+
+		default:
+			return fmt.Errorf("%w: %s", errUnknownFormat, format)
+		}
+	}
+
+	_, err := io.WriteString(writer, buf.String())
+
+	return err
+}
+
+// readBash reads the lines of a bash history file, with their timestamps if any.
+func readBash(reader io.Reader) (entries []Entry, err error) {
+	var stamp time.Time
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := bashTimestamp.FindStringSubmatch(line); match != nil {
+			seconds, _ := strconv.ParseInt(match[1], 10, 64)
+			stamp = time.Unix(seconds, 0)
+
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		entries = append(entries, Entry{Line: line, Time: stamp, Status: StatusUnknown})
+	}
+
+	return entries, scanner.Err()
+}
+
+// readZsh reads the lines of a zsh history file, extended or not:
+// commands spanning several lines have their newlines escaped.
+func readZsh(reader io.Reader) (entries []Entry, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			line = strings.TrimSuffix(line, "\\") + "\n" + scanner.Text()
+		}
+
+		entry := Entry{Line: line, Status: StatusUnknown}
+
+		if match := zshExtended.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.ParseInt(match[1], 10, 64)
+			elapsed, _ := strconv.ParseInt(match[2], 10, 64)
+
+			entry.Line = match[3]
+			entry.Time = time.Unix(start, 0)
+			entry.Duration = time.Duration(elapsed) * time.Second
+		}
+
+		if strings.TrimSpace(entry.Line) == "" {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// apply returns the entries matching the filter.
+func (f Filter) apply(entries []Entry) []Entry {
+	matched := make([]Entry, 0, len(entries))
+
+	for _, entry := range entries {
+		if f.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+
+	if !f.Unique {
+		return matched
+	}
+
+	// Keep the last entry of each line, in order.
+	seen := make(map[string]bool, len(matched))
+	unique := make([]Entry, 0, len(matched))
+
+	for i := len(matched) - 1; i >= 0; i-- {
+		if seen[matched[i].Line] {
+			continue
+		}
+
+		seen[matched[i].Line] = true
+		unique = append(unique, matched[i])
+	}
+
+	for i, j := 0, len(unique)-1; i < j; i, j = i+1, j-1 {
+		unique[i], unique[j] = unique[j], unique[i]
+	}
+
+	return unique
+}
+
+func (f Filter) matches(entry Entry) bool {
+	switch {
+	case f.Menu != "" && entry.Menu != f.Menu:
+		return false
+	case f.Context != "" && entry.Context != f.Context:
+		return false
+	case f.Dir != "" && entry.Dir != f.Dir:
+		return false
+	case f.Match != "" && !strings.Contains(entry.Line, f.Match):
+		return false
+	case !f.Since.IsZero() && entry.Time.Before(f.Since):
+		return false
+	case f.Failed && entry.Status <= 0:
+		return false
+	default:
+		return true
+	}
+}
+
+// storeView is a history source with the entries of a store matching a filter.
+type storeView struct {
+	store   *Store
+	filter  Filter
+	mutex   sync.Mutex
+	version int
+	lines   []string
+}
+
+// Write records a command line in the store, with the menu and context of the view.
+func (v *storeView) Write(line string) (int, error) {
+	if _, err := v.store.write(line, v.filter); err != nil {
+		return v.Len(), err
+	}
+
+	return v.Len(), nil
+}
+
+// Finish records the result of the command of the last entry written in the store.
+func (v *storeView) Finish(duration time.Duration, status int) error {
+	return v.store.Finish(duration, status)
+}
+
+// Delete removes an entry of the view from the store.
+func (v *storeView) Delete(pos int) error {
+	v.store.mutex.Lock()
+	defer v.store.mutex.Unlock()
+
+	if pos < 0 {
+		return errNegativeIndex
+	}
+
+	entries := v.filter.apply(v.store.entries)
+	if pos >= len(entries) {
+		return errOutOfRangeIndex
+	}
+
+	return v.store.delete(entries[pos])
+}
+
+// Reload reads the entries written to the store file by other processes.
+func (v *storeView) Reload(mode string) error {
+	return v.store.Reload(mode)
+}
+
+// Trim deletes the oldest entries of the store.
+func (v *storeView) Trim(size int) error {
+	return v.store.Trim(size)
+}
+
+// GetLine returns the command line of an entry of the view.
+func (v *storeView) GetLine(pos int) (string, error) {
+	lines := v.update()
+
+	if pos < 0 {
+		return "", errNegativeIndex
+	}
+
+	if pos >= len(lines) {
+		return "", errOutOfRangeIndex
+	}
+
+	return lines[pos], nil
+}
+
+// Len returns the number of entries in the view.
+func (v *storeView) Len() int {
+	return len(v.update())
+}
+
+// Dump returns the entries of the view ([]Entry).
+func (v *storeView) Dump() interface{} {
+	return v.store.Entries(v.filter)
+}
+
+// update returns the lines of the view, filtering the
+// store entries again only when they have changed.
+func (v *storeView) update() []string {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.store.mutex.RLock()
+	defer v.store.mutex.RUnlock()
+
+	if v.version == v.store.version {
+		return v.lines
+	}
+
+	entries := v.filter.apply(v.store.entries)
+
+	v.lines = make([]string, len(entries))
+	for i, entry := range entries {
+		v.lines[i] = entry.Line
+	}
+
+	v.version = v.store.version
+
+	return v.lines
+}
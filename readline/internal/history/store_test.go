@@ -0,0 +1,165 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func storeLines(entries []Entry) (lines []string) {
+	for _, entry := range entries {
+		lines = append(lines, entry.Line)
+	}
+
+	return lines
+}
+
+func TestStoreFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+
+	legacy := `{"datetime":"2024-01-02T03:04:05Z","block":"sessions -l"}` + "\n"
+	if err := os.WriteFile(file, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStore(file)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	store.SetContext(func() string { return "session-1" })
+
+	if _, err := store.Write("use 1"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if err := store.Finish(2*time.Second, 1); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	if err := store.Finish(time.Second, 0); err == nil {
+		t.Fatal("Finish() of an already finished entry should fail")
+	}
+
+	reloaded, err := NewStore(file)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+
+	entries := reloaded.Entries(Filter{})
+	if got := storeLines(entries); !reflect.DeepEqual(got, []string{"sessions -l", "use 1"}) {
+		t.Fatalf("reloaded lines = %q", got)
+	}
+
+	if entries[0].Status != StatusUnknown || entries[0].Time.Year() != 2024 {
+		t.Fatalf("legacy entry = %+v", entries[0])
+	}
+
+	last := entries[1]
+	if last.Status != 1 || last.Duration != 2*time.Second || last.Context != "session-1" || last.Dir == "" {
+		t.Fatalf("finished entry = %+v", last)
+	}
+}
+
+func TestStoreFilter(t *testing.T) {
+	store, _ := NewStore("")
+	now := time.Now()
+
+	store.Record(
+		Entry{Line: "sessions", Menu: "main", Time: now.Add(-time.Hour), Status: 0},
+		Entry{Line: "ls", Menu: "implant", Context: "s1", Dir: "/tmp", Time: now, Status: 0},
+		Entry{Line: "sessions", Menu: "main", Time: now, Status: 1},
+		Entry{Line: "pwd", Menu: "implant", Context: "s2", Time: now, Status: 0},
+	)
+
+	tests := []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{}, []string{"sessions", "ls", "sessions", "pwd"}},
+		{Filter{Unique: true}, []string{"ls", "sessions", "pwd"}},
+		{Filter{Menu: "implant"}, []string{"ls", "pwd"}},
+		{Filter{Context: "s1"}, []string{"ls"}},
+		{Filter{Dir: "/tmp"}, []string{"ls"}},
+		{Filter{Match: "ess"}, []string{"sessions", "sessions"}},
+		{Filter{Since: now.Add(-time.Minute), Menu: "main"}, []string{"sessions"}},
+		{Filter{Failed: true}, []string{"sessions"}},
+	}
+
+	for _, tt := range tests {
+		if got := storeLines(store.Entries(tt.filter)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Entries(%+v) = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+
+	view := store.View(Filter{Menu: "implant"})
+	if view.Len() != 2 {
+		t.Fatalf("view length = %d, want 2", view.Len())
+	}
+
+	view.Write("whoami")
+
+	if line, _ := view.GetLine(2); line != "whoami" || view.Len() != 3 {
+		t.Fatalf("view line 2 = %q (length %d), want whoami", line, view.Len())
+	}
+
+	if entries := store.Entries(Filter{Match: "whoami"}); len(entries) != 1 || entries[0].Menu != "implant" {
+		t.Fatalf("entry written through the view = %+v", entries)
+	}
+}
+
+func TestStoreImportExport(t *testing.T) {
+	bash := "#1700000000\nls -la\n#1700000060\necho hi\nuptime\n"
+	zsh := ": 1700000000:3;make build\n: 1700000010:0;for i in 1 2; do\\\necho $i\\\ndone\nplain\n"
+
+	store, _ := NewStore("")
+
+	if n, err := store.Import(strings.NewReader(bash), FormatBash); err != nil || n != 3 {
+		t.Fatalf("Import(bash) = %d, %v", n, err)
+	}
+
+	if n, err := store.Import(strings.NewReader(zsh), FormatZsh); err != nil || n != 3 {
+		t.Fatalf("Import(zsh) = %d, %v", n, err)
+	}
+
+	entries := store.Entries(Filter{})
+
+	want := []string{"ls -la", "echo hi", "uptime", "make build", "for i in 1 2; do\necho $i\ndone", "plain"}
+	if got := storeLines(entries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("imported lines = %q, want %q", got, want)
+	}
+
+	if entries[1].Time.Unix() != 1700000060 || entries[3].Duration != 3*time.Second {
+		t.Fatalf("imported metadata = %+v, %+v", entries[1], entries[3])
+	}
+
+	var out strings.Builder
+	if err := store.Export(&out, FormatZsh, Filter{Match: "do"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := out.String(); got != ": 1700000010:0;for i in 1 2; do\\\necho $i\\\ndone\n" {
+		t.Fatalf("Export(zsh) = %q", got)
+	}
+
+	out.Reset()
+	if err := store.Export(&out, FormatBash, Filter{Match: "ls"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := out.String(); got != "#1700000000\nls -la\n" {
+		t.Fatalf("Export(bash) = %q", got)
+	}
+
+	if _, err := store.Import(strings.NewReader(""), "fish"); err == nil {
+		t.Fatal("Import() with an unknown format should fail")
+	}
+
+	// Only entries written for commands run are finished, not imported ones.
+	if err := store.Finish(time.Second, 0); err == nil {
+		t.Fatal("Finish() amended an imported entry")
+	}
+}
@@ -13,12 +13,23 @@ import (
 // readline global options specific to this library.
 var readlineOptions = map[string]interface{}{
 	// General edition
-	"autopairs": false,
+	"autopairs":           false,
+	"abbreviation-cursor": "%|",
 
 	// Completion
 	"autocomplete":               false,
 	"completion-list-separator":  "--",
 	"completion-selection-style": "\x1b[1;30m",
+	"completion-matching":        "prefix",
+	"completion-cache-ttl":       0,
+	"completion-preview":         "auto",
+
+	// History
+	"history-share": "on",
+
+	// Keyboard & mouse
+	"keyboard-protocol": "off",
+	"enable-mouse":      false,
 
 	// Prompt & General UI
 	"transient-prompt":          false,
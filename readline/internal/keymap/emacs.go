@@ -23,6 +23,7 @@ var emacsKeys = map[string]inputrc.Bind{
 	unescape(`\M->`):     {Action: "end-of-buffer-or-history"},
 	unescape(`\M-c`):     {Action: "capitalize-word"},
 	unescape(`\M-d`):     {Action: "kill-word"},
+	unescape(`\M-h`):     {Action: "history-browse"},
 	unescape(`\M-m`):     {Action: "copy-prev-shell-word"},
 	unescape(`\M-n`):     {Action: "history-search-forward"},
 	unescape(`\M-p`):     {Action: "history-search-backward"},
@@ -17,6 +17,7 @@ var viinsKeys = map[string]inputrc.Bind{
 	unescape(`\C-Q`):   {Action: "accept-and-infer-next-history"},
 	unescape(`\C-P`):   {Action: "up-line-or-history"},
 	unescape(`\C-_`):   {Action: "undo"},
+	unescape(`\M-h`):   {Action: "history-browse"},
 	unescape(`\M-q`):   {Action: "macro-toggle-record"},
 	unescape(`\M-r`):   {Action: "vi-registers-complete"},
 	unescape(`\M-[3~`): {Action: "delete-char"},
@@ -16,8 +16,35 @@ const (
 	RestoreCursorPos = "\x1b8"
 	HideCursor       = "\x1b[?25l"
 	ShowCursor       = "\x1b[?25h"
+
+	AltScreenEnter = "\x1b[?1049h" // Switches to the alternate screen, saving the cursor.
+	AltScreenExit  = "\x1b[?1049l" // Restores the normal screen and the cursor.
+
+	KittyKeysEnable  = "\x1b[>1u"   // Pushes the kitty keyboard protocol (disambiguated keys).
+	KittyKeysDisable = "\x1b[<u"    // Pops the kitty keyboard protocol.
+	OtherKeysEnable  = "\x1b[>4;2m" // Enables xterm modifyOtherKeys (level 2).
+	OtherKeysDisable = "\x1b[>4;0m" // Disables xterm modifyOtherKeys.
+
+	MouseEnable  = "\x1b[?1002h\x1b[?1006h" // Reports mouse clicks and drags, as SGR sequences.
+	MouseDisable = "\x1b[?1006l\x1b[?1002l" // Stops reporting mouse events.
 )
 
+// KeyboardProtocol returns the sequences enabling and disabling an extended keyboard
+// protocol, as selected with the keyboard-protocol inputrc option: kitty, xterm
+// (modifyOtherKeys) or auto (both, terminals ignoring those they do not support).
+func KeyboardProtocol(mode string) (enable, disable string) {
+	switch mode {
+	case "kitty":
+		return KittyKeysEnable, KittyKeysDisable
+	case "xterm":
+		return OtherKeysEnable, OtherKeysDisable
+	case "auto":
+		return KittyKeysEnable + OtherKeysEnable, OtherKeysDisable + KittyKeysDisable
+	}
+
+	return "", ""
+}
+
 // Some core keys needed by some stuff.
 var (
 	ArrowUp    = string([]byte{27, 91, 65}) // ^[[A
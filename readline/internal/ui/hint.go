@@ -14,6 +14,7 @@ import (
 type Hint struct {
 	text       []rune
 	persistent []rune
+	context    []rune
 	cleanup    bool
 	temp       bool
 	set        bool
@@ -41,6 +42,13 @@ func (h *Hint) Persist(hint string) {
 	h.persistent = []rune(hint)
 }
 
+// SetContext sets the contextual hint of the input line, like the usage of the
+// command being typed. It is displayed above any other hint message, and is
+// computed again every time the input line is displayed.
+func (h *Hint) SetContext(hint string) {
+	h.context = []rune(hint)
+}
+
 // Text returns the current hint text.
 func (h *Hint) Text() string {
 	return string(h.text)
@@ -75,7 +83,7 @@ func DisplayHint(hint *Hint) {
 		hint.Reset()
 	}
 
-	if len(hint.text) == 0 && len(hint.persistent) == 0 {
+	if len(hint.text) == 0 && len(hint.persistent) == 0 && len(hint.context) == 0 {
 		if hint.cleanup {
 			term.Print(term.ClearLineAfter)
 		}
@@ -103,6 +111,10 @@ func (h *Hint) renderHint() (text string) {
 		text += string(h.persistent) + term.NewlineReturn
 	}
 
+	if len(h.context) > 0 {
+		text += string(h.context) + term.NewlineReturn
+	}
+
 	if len(h.text) > 0 {
 		text += string(h.text) + term.NewlineReturn
 	}
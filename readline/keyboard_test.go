@@ -0,0 +1,36 @@
+package readline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/chainreactors/tui/readline/inputrc"
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+func TestExtendedKeys(t *testing.T) {
+	var out bytes.Buffer
+
+	// Control-a, Control-Shift-a, and the release of Control-Shift-a.
+	input := "abc\x1b[97;5uX\x1b[97;6uY\x1b[97;6:3u\r"
+	terminal := rlterm.Stream(strings.NewReader(input), &out, &out, rlterm.NewControl(false, 80, 24))
+
+	rl := NewShellWithTerminal(terminal)
+	rl.Config.Set("keyboard-protocol", "kitty")
+	rl.Config.Bind("emacs", inputrc.Unescape(`\C-\S-a`), "end-of-line", false)
+
+	line, err := rl.Readline()
+	if err != nil {
+		t.Fatalf("Readline() error = %v", err)
+	}
+
+	if line != "XabcY" {
+		t.Fatalf("line = %q, want Control-a and Control-Shift-a bound to distinct commands", line)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "\x1b[>1u") || !strings.Contains(output, "\x1b[<u") {
+		t.Fatalf("kitty keyboard protocol not negotiated: %q", output)
+	}
+}
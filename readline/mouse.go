@@ -0,0 +1,91 @@
+package readline
+
+import (
+	"github.com/chainreactors/tui/readline/internal/completion"
+	"github.com/chainreactors/tui/readline/internal/core"
+	"github.com/chainreactors/tui/readline/internal/keymap"
+)
+
+// mouse handles a mouse event reported by the terminal when the enable-mouse
+// option is on: clicks move the cursor in the input line or select completion
+// candidates, drags select a region of the line and the wheel scrolls through
+// the completion menu.
+func (rl *Shell) mouse(event *core.MouseEvent) {
+	rl.History.SkipSave()
+
+	switch {
+	case event.Button == core.MouseWheelUp:
+		rl.mouseWheel(-1)
+	case event.Button == core.MouseWheelDown:
+		rl.mouseWheel(1)
+	case event.Button != core.MouseLeft:
+		return
+	case event.Release:
+		rl.mouseAnchor = -1
+	case event.Motion:
+		rl.mouseDrag(event)
+	default:
+		rl.mouseClick(event)
+	}
+}
+
+// mouseClick selects the completion candidate clicked, or moves the cursor
+// to the character clicked in the input line.
+func (rl *Shell) mouseClick(event *core.MouseEvent) {
+	if row, col, ok := rl.Display.CompletionPosition(event.X, event.Y); ok {
+		rl.completer.SelectAt(row, col)
+		return
+	}
+
+	pos, ok := rl.Display.LinePosition(event.X, event.Y)
+	if !ok || rl.Keymap.Local() == keymap.Isearch {
+		return
+	}
+
+	// Any candidate virtually inserted is now part of the line.
+	completion.UpdateInserted(rl.completer)
+	rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
+
+	rl.selection.Reset()
+	if rl.Keymap.Local() == keymap.Visual {
+		rl.Keymap.SetLocal("")
+	}
+
+	rl.cursor.Set(pos)
+	rl.mouseAnchor = pos
+}
+
+// mouseDrag selects the region of the line between the position
+// where the button was pressed and the one the mouse is on.
+func (rl *Shell) mouseDrag(event *core.MouseEvent) {
+	pos, ok := rl.Display.LinePosition(event.X, event.Y)
+	if !ok || rl.mouseAnchor == -1 {
+		return
+	}
+
+	rl.cursor.Set(pos)
+
+	if pos == rl.mouseAnchor {
+		rl.selection.Reset()
+		return
+	}
+
+	// Visual selections include their end position.
+	rl.selection.MarkRange(min(rl.mouseAnchor, pos), max(rl.mouseAnchor, pos)-1)
+	rl.selection.Visual(false)
+
+	// In Vim command mode, the selection can be used by operators.
+	if rl.Keymap.Main() == keymap.ViCommand {
+		rl.Keymap.SetLocal(keymap.Visual)
+	}
+}
+
+// mouseWheel moves the selection in the completion menu to the next
+// or previous candidate, scrolling the menu if it does not fit.
+func (rl *Shell) mouseWheel(candidates int) {
+	if rl.completer.Matches() == 0 {
+		return
+	}
+
+	rl.completer.Select(candidates, 0)
+}
@@ -0,0 +1,90 @@
+package readline
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	rlterm "github.com/chainreactors/tui/readline/terminal"
+)
+
+// cursorReplies answers cursor position queries like a terminal would,
+// with the input line always starting at row 5, column 3.
+type cursorReplies struct {
+	mutex   sync.Mutex
+	out     bytes.Buffer
+	replies io.Writer
+}
+
+func (o *cursorReplies) Write(p []byte) (int, error) {
+	for i := bytes.Count(p, []byte("\x1b[6n")); i > 0; i-- {
+		go o.replies.Write([]byte("\x1b[5;3R"))
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.out.Write(p)
+}
+
+func readMouse(t *testing.T, input string, completer func([]rune, int) Completions) string {
+	t.Helper()
+
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	out := &cursorReplies{replies: writer}
+	terminal := rlterm.Stream(reader, out, out, rlterm.NewControl(false, 80, 24))
+
+	rl := NewShellWithTerminal(terminal)
+	rl.Config.Set("enable-mouse", true)
+	rl.Completer = completer
+
+	go writer.Write([]byte(input))
+
+	line, err := rl.Readline()
+	if err != nil {
+		t.Fatalf("Readline() error = %v", err)
+	}
+
+	return line
+}
+
+// click returns the events of a click at some column on the input line row.
+func click(column string) string {
+	return "\x1b[<0;" + column + ";5M\x1b[<0;" + column + ";5m"
+}
+
+func TestMouseClick(t *testing.T) {
+	// The line starts at column 3: the fourth character is on column 6.
+	line := readMouse(t, "hello world"+click("6")+"X"+click("70")+"!\r", nil)
+
+	if line != "helXlo world!" {
+		t.Fatalf("line = %q, want the cursor moved by clicks", line)
+	}
+}
+
+func TestMouseDrag(t *testing.T) {
+	// Select "hello", and kill it.
+	drag := "\x1b[<0;3;5M\x1b[<32;6;5M\x1b[<32;8;5M\x1b[<0;8;5m"
+	line := readMouse(t, "hello world"+drag+"\x1bw\r", nil)
+
+	if line != " world" {
+		t.Fatalf("line = %q, want the dragged region killed", line)
+	}
+}
+
+func TestMouseCompletion(t *testing.T) {
+	completer := func([]rune, int) Completions {
+		return CompleteValues("sessions", "services", "sysinfo")
+	}
+
+	// List completions on the row below the line, in columns:
+	// click on the second one, then scroll down to the third one.
+	line := readMouse(t, "s\x1b?\x1b[<0;20;6M\x1b[<0;20;6m\x1b[<65;20;6M\r\r", completer)
+
+	if line != "sysinfo " && line != "sysinfo" {
+		t.Fatalf("line = %q, want the clicked candidate inserted", line)
+	}
+}
@@ -75,10 +75,28 @@ func (rl *Shell) Readline() (string, error) {
 		term.Print("\033[?2004h")
 		defer term.Print("\033[?2004l")
 	}
+	// Extended keyboard protocols report keys like Control-Shift-a or
+	// Control-Enter with distinct sequences, decoded by the key reader.
+	if enable, disable := term.KeyboardProtocol(rl.Config.GetString("keyboard-protocol")); enable != "" {
+		term.Print(enable)
+		defer term.Print(disable)
+	}
+	// Mouse events are reported by the terminal with SGR sequences.
+	mouse := rl.Config.GetBool("enable-mouse")
+	if mouse {
+		term.Print(term.MouseEnable)
+		defer term.Print(term.MouseDisable)
+	}
 	defer rl.Display.RefreshTransient()
 	defer term.Print(keymap.CursorStyle("default"))
 
 	rl.init()
+
+	// Asynchronous completions are redisplayed only while the main
+	// loop waits for keys, and stop with it.
+	rl.loop.Lock()
+	defer rl.loop.Unlock()
+	defer rl.cancelAsync()
 	// The done hook is registered after readline's display defers so async
 	// renderers are disabled before transient prompts or terminal modes restore.
 	if rl.OnReadlineDone != nil {
@@ -116,14 +134,31 @@ func (rl *Shell) Readline() (string, error) {
 		// Block and wait for available user input keys.
 		// These might be read on stdin, or already available because
 		// the macro engine has fed some keys in bulk when running one.
+		rl.idle = true
+		rl.loop.Unlock()
+
 		core.WaitAvailableKeys(rl.Keys, rl.Config)
 
+		rl.loop.Lock()
+		rl.idle = false
+
 		// If the input is closed, we must return the line
 		// and the error so that the caller can handle it.
 		if rl.Keys.IsEOF() {
 			return "", io.EOF
 		}
 
+		// Mouse events are not keys: they are handled regardless of the keymaps.
+		if mouse {
+			if event, read := core.ReadMouse(rl.Keys); read {
+				if event != nil {
+					rl.run(false, inputrc.Bind{Action: "mouse"}, func() { rl.mouse(event) })
+				}
+
+				continue
+			}
+		}
+
 		// 1 - Local keymap (Completion/Isearch/Vim operator pending).
 		bind, command, prefixed := keymap.MatchLocal(rl.Keymap)
 		if prefixed {
@@ -169,6 +204,7 @@ func (rl *Shell) init() {
 	rl.cursor.Set(0)
 	rl.cursor.ResetMark()
 	rl.selection.Reset()
+	rl.mouseAnchor = -1
 	rl.Buffers.Reset()
 	rl.History.Reset()
 	rl.Iterations.Reset()
@@ -181,7 +217,7 @@ func (rl *Shell) init() {
 	// Reset/initialize user interface components.
 	rl.Hint.Reset()
 	rl.completer.ResetForce()
-	display.Init(rl.Display, rl.SyntaxHighlighter)
+	display.Init(rl.Display, rl.SyntaxHighlighter, rl.Hinter)
 }
 
 // run wraps the execution of a target command/sequence with various pre/post actions
@@ -217,6 +253,9 @@ func (rl *Shell) run(main bool, bind inputrc.Bind, command func()) (bool, string
 	// buffer (acting on it), update the list of matches.
 	rl.completer.UpdateIsearch()
 
+	// Background completers started for another line are useless.
+	rl.cancelStaleAsync()
+
 	// Work is done: ask the completion system to
 	// return the correct input line and cursor.
 	rl.line, rl.cursor, rl.selection = rl.completer.GetBuffer()
@@ -23,7 +23,7 @@ func TestRefreshWithoutAutocompleteDoesNotGenerateMenu(t *testing.T) {
 	}
 	rl.Line().Set([]rune("/")...)
 	rl.Cursor().Set(1)
-	display.Init(rl.Display, nil)
+	display.Init(rl.Display, nil, nil)
 
 	rl.RefreshWithoutAutocomplete()
 	if calls != 0 {
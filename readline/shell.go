@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/chainreactors/tui/readline/inputrc"
 	"github.com/chainreactors/tui/readline/internal/completion"
@@ -46,6 +47,17 @@ type Shell struct {
 	completer *completion.Engine // Completions generation and display.
 	Display   *display.Engine    // Manages display refresh/update/clearing.
 
+	// Abbreviations are words expanded in the line when followed by a space.
+	Abbreviations *Abbreviations
+
+	// Asynchronous updates
+	async asyncCompletions // Background completers and their results.
+	loop  sync.Mutex       // Held by the main loop, except while it waits for keys.
+	idle  bool             // The main loop is waiting for keys (guarded by loop).
+
+	// Mouse
+	mouseAnchor int // Line position where the mouse button was pressed, or -1.
+
 	// User-provided functions
 
 	// AcceptMultiline enables the caller to decide if the shell should keep reading
@@ -60,6 +72,11 @@ type Shell struct {
 	// Once enabled, set to nil to disable again.
 	SyntaxHighlighter func(line []rune) string
 
+	// Hinter returns a contextual hint for the line and cursor position, displayed
+	// below the input line and updated as it is edited, like the usage of the command
+	// being typed or errors in its arguments. An empty hint is not displayed.
+	Hinter func(line []rune, cursor int) string
+
 	// PasteTransformer, when set, rewrites pasted text before it is inserted
 	// into the input buffer. It is called for bracketed paste payloads.
 	PasteTransformer func(text string) string
@@ -119,6 +136,7 @@ func NewShellWithTerminal(t *rlterm.Terminal, opts ...inputrc.Option) *Shell {
 	shell.cursor = cursor
 	shell.selection = selection
 	shell.Buffers = editor.NewBuffers()
+	shell.Abbreviations = new(Abbreviations)
 	shell.Iterations = iterations
 
 	// Keymaps and commands
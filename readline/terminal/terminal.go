@@ -59,15 +59,49 @@ const (
 	EventRawExit  EventType = "raw.exit"
 	EventClose    EventType = "close"
 	EventError    EventType = "error"
+	EventSignal   EventType = "signal"
+	EventEnv      EventType = "env"
 )
 
+// Signal names carried by EventSignal frames (in Event.Message),
+// for clients forwarding the signals their own terminal raised.
+const (
+	SignalInterrupt = "SIGINT"
+	SignalQuit      = "SIGQUIT"
+	SignalTerminate = "SIGTERM"
+	SignalSuspend   = "SIGTSTP"
+	SignalHangup    = "SIGHUP"
+)
+
+// Signaler is implemented by controls able to deliver signals raised on the
+// other side of the terminal, since those never reach the local process.
+type Signaler interface {
+	OnSignal(func(name string)) func()
+}
+
+// Environ is implemented by controls knowing the environment of the terminal
+// on the other side (TERM, COLORTERM, LANG...), from which its capabilities
+// are derived.
+type Environ interface {
+	Getenv(key string) string
+}
+
+// EnvVariables are the environment variables describing a terminal, which
+// clients send in EventEnv frames. Nothing else of their environment is sent.
+var EnvVariables = []string{
+	"TERM", "COLORTERM", "TERM_PROGRAM", "TERM_PROGRAM_VERSION", "LC_TERMINAL",
+	"LANG", "LC_ALL", "LC_CTYPE", "NO_COLOR", "CLICOLOR_FORCE", "COLORFGBG",
+	"WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "VTE_VERSION", "TMUX", "OS",
+}
+
 // Event is the minimal frame shape for adapting arbitrary carriers.
 type Event struct {
-	Type    EventType
-	Data    []byte
-	Cols    int
-	Rows    int
-	Message string
+	Type    EventType         `json:"type"`
+	Data    []byte            `json:"data,omitempty"`
+	Cols    int               `json:"cols,omitempty"`
+	Rows    int               `json:"rows,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
 }
 
 // Carrier is implemented by WebSocket, TCP, stdio relay, or any other channel.
@@ -136,6 +170,7 @@ func (r *Remote) recvLoop(carrier Carrier) {
 	for {
 		event, err := carrier.Recv(r.ctx)
 		if err != nil {
+			r.hangup()
 			return
 		}
 		switch event.Type {
@@ -147,12 +182,29 @@ func (r *Remote) recvLoop(carrier Carrier) {
 			if c, ok := r.Terminal.Control.(*carrierControl); ok {
 				c.SetSize(event.Cols, event.Rows)
 			}
+		case EventSignal:
+			if c, ok := r.Terminal.Control.(*carrierControl); ok {
+				c.Signal(event.Message)
+			}
+		case EventEnv:
+			if c, ok := r.Terminal.Control.(*carrierControl); ok {
+				c.SetEnv(event.Env)
+			}
 		case EventClose:
+			r.hangup()
 			return
 		}
 	}
 }
 
+// hangup notifies that the client went away, so that
+// whatever it was running does not outlive it.
+func (r *Remote) hangup() {
+	if c, ok := r.Terminal.Control.(*carrierControl); ok {
+		c.Signal(SignalHangup)
+	}
+}
+
 type carrierWriter struct {
 	ctx     context.Context
 	carrier Carrier
@@ -193,6 +245,8 @@ type StreamControl struct {
 	rows        int
 	nextID      int
 	callbacks   map[int]func(int, int)
+	signals     map[int]func(string)
+	env         map[string]string
 	closeFunc   func() error
 	makeRawFunc func() (func(), error)
 }
@@ -210,6 +264,7 @@ func NewControl(isTerminal bool, cols, rows int) *StreamControl {
 		cols:      cols,
 		rows:      rows,
 		callbacks: make(map[int]func(int, int)),
+		signals:   make(map[int]func(string)),
 	}
 }
 
@@ -271,6 +326,59 @@ func (c *StreamControl) OnResize(fn func(int, int)) func() {
 	}
 }
 
+// OnSignal registers fn to be called with the name of signals raised by Signal.
+func (c *StreamControl) OnSignal(fn func(name string)) func() {
+	if c == nil || fn == nil {
+		return func() {}
+	}
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.signals[id] = fn
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		delete(c.signals, id)
+		c.mu.Unlock()
+	}
+}
+
+// Signal delivers a signal (eg. SignalInterrupt) to the OnSignal callbacks.
+func (c *StreamControl) Signal(name string) {
+	if c == nil || name == "" {
+		return
+	}
+	c.mu.Lock()
+	signals := make([]func(string), 0, len(c.signals))
+	for _, cb := range c.signals {
+		signals = append(signals, cb)
+	}
+	c.mu.Unlock()
+	for _, cb := range signals {
+		cb(name)
+	}
+}
+
+// Getenv returns an environment variable of the terminal, set with SetEnv.
+func (c *StreamControl) Getenv(key string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.env[key]
+}
+
+// SetEnv replaces the environment variables of the terminal.
+func (c *StreamControl) SetEnv(env map[string]string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.env = env
+	c.mu.Unlock()
+}
+
 func (c *StreamControl) Close() error {
 	if c != nil && c.closeFunc != nil {
 		return c.closeFunc()
@@ -303,6 +411,10 @@ func (localControl) MakeRaw() (func(), error) {
 	return func() { _ = internalterm.Restore(int(os.Stdin.Fd()), state) }, nil
 }
 
+func (localControl) Getenv(key string) string {
+	return os.Getenv(key)
+}
+
 func (localControl) OnResize(func(int, int)) func() {
 	return func() {}
 }
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"github.com/atotto/clipboard"
+	"github.com/chainreactors/tui/term"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -119,6 +120,7 @@ func NewShell(sessionID string, handlers *ShellHandlers) *ShellModel {
 	vp := viewport.New(defaultViewportWidth, defaultViewportHeight)
 	// 普通终端样式：无边框、无装饰
 	vp.Style = lipgloss.NewStyle()
+	palette := term.CurrentPalette()
 
 	shell := &ShellModel{
 		input:      input,
@@ -140,11 +142,11 @@ func NewShell(sessionID string, handlers *ShellHandlers) *ShellModel {
 		lastClickTime: 0,
 
 		// Default styles
-		promptStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true),
-		outputStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("246")),
-		sessionStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color("36")).Bold(true),
-		errorStyle:     lipgloss.NewStyle().Foreground(lipgloss.Color("196")),
-		selectionStyle: lipgloss.NewStyle().Background(lipgloss.Color("57")).Foreground(lipgloss.Color("255")),
+		promptStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Prompt)).Bold(true),
+		outputStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Text)),
+		sessionStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Session)).Bold(true),
+		errorStyle:     lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Error)),
+		selectionStyle: lipgloss.NewStyle().Background(lipgloss.Color(palette.SelectionBg)).Foreground(lipgloss.Color(palette.Selection)),
 	}
 
 	return shell
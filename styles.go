@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"github.com/chainreactors/tui/term"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
 	etable "github.com/evertras/bubble-table/table"
@@ -8,53 +9,95 @@ import (
 
 // base styles
 var (
-	FootStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")).
-			BorderBottom(true).
-			Bold(false)
-	HeaderStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240"))
-	SelectStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("255")).
-			Background(lipgloss.Color("24")).
-			Bold(false)
-	HelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+	FootStyle   lipgloss.Style
+	HeaderStyle lipgloss.Style
+	SelectStyle lipgloss.Style
+	HelpStyle   func(strs ...string) string
 )
 
 // Default Styles
 var (
+	DefaultTableStyle         table.Styles
+	DefaultTableHighlineStyle lipgloss.Style
+	DocStyle                  = lipgloss.NewStyle().Margin(1, 2)
+	DefaultGroupStyle         lipgloss.Style
+	DefaultNameStyle          lipgloss.Style
+	DefaultBorder             etable.Border
+)
+
+// asciiBorder 用于无法绘制 unicode 制表符的终端
+var asciiBorder = lipgloss.Border{
+	Top: "-", Bottom: "-", Left: "|", Right: "|",
+	TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+	MiddleLeft: "+", MiddleRight: "+", Middle: "+", MiddleTop: "+", MiddleBottom: "+",
+}
+
+func init() {
+	ApplyTheme()
+	term.OnThemeChange(ApplyTheme)
+}
+
+// ApplyTheme rebuilds the package styles from the current term theme, picking
+// the light or dark palette by background. Theme, profile and background
+// changes made through the term package apply it automatically.
+func ApplyTheme() {
+	applyPalette(term.CurrentPalette())
+}
+
+func applyPalette(p term.Palette) {
+	lipgloss.SetColorProfile(term.ColorProfile())
+
+	// 终端无法绘制 unicode 制表符时退回 ASCII 边框
+	rounded, normal := lipgloss.RoundedBorder(), lipgloss.NormalBorder()
+	bottomJunction, innerJunction := "┴", "┬"
+	if !term.CurrentCapabilities().Unicode {
+		rounded, normal = asciiBorder, asciiBorder
+		bottomJunction, innerJunction = "+", "+"
+	}
+	DefaultBorder = etable.Border{
+		Top:    rounded.Top,
+		Left:   rounded.Left,
+		Right:  rounded.Right,
+		Bottom: rounded.Bottom,
+
+		TopRight:    rounded.TopRight,
+		TopLeft:     rounded.TopLeft,
+		BottomRight: rounded.BottomLeft,
+		BottomLeft:  rounded.BottomRight,
+
+		TopJunction:    rounded.Top,
+		LeftJunction:   rounded.Left,
+		RightJunction:  rounded.Right,
+		BottomJunction: bottomJunction,
+		InnerJunction:  innerJunction,
+		InnerDivider:   "",
+	}
+
+	FootStyle = lipgloss.NewStyle().BorderStyle(normal).
+		BorderForeground(lipgloss.Color(p.Border)).
+		BorderBottom(true).
+		Bold(false)
+	HeaderStyle = lipgloss.NewStyle().
+		BorderStyle(rounded).BorderForeground(lipgloss.Color(p.Border))
+	SelectStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(p.Selection)).
+		Background(lipgloss.Color(p.SelectionBg)).
+		Bold(false)
+	HelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(p.Muted)).Render
+
 	DefaultTableStyle = table.Styles{
-		Selected: table.DefaultStyles().Selected.Foreground(lipgloss.Color("255")).
-			Background(lipgloss.Color("24")).
+		Selected: table.DefaultStyles().Selected.Foreground(lipgloss.Color(p.Selection)).
+			Background(lipgloss.Color(p.SelectionBg)).
 			Bold(false),
-		Header: table.DefaultStyles().Header.BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")).
+		Header: table.DefaultStyles().Header.BorderStyle(normal).
+			BorderForeground(lipgloss.Color(p.Border)).
 			BorderBottom(true).
 			Bold(false),
 		Cell: lipgloss.NewStyle().Padding(0, 1),
 	}
-	DefaultTableHighlineStyle = table.DefaultStyles().Selected.Foreground(lipgloss.Color("229")).
-					Background(lipgloss.Color("107")).
-					Bold(false)
-	DocStyle          = lipgloss.NewStyle().Margin(1, 2)
-	DefaultGroupStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#8BE9FD"))
-	DefaultNameStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF79C6"))
-	DefaultBorder     = etable.Border{
-		Top:    lipgloss.RoundedBorder().Top,
-		Left:   lipgloss.RoundedBorder().Left,
-		Right:  lipgloss.RoundedBorder().Right,
-		Bottom: lipgloss.RoundedBorder().Bottom,
-
-		TopRight:    lipgloss.RoundedBorder().TopRight,
-		TopLeft:     lipgloss.RoundedBorder().TopLeft,
-		BottomRight: lipgloss.RoundedBorder().BottomLeft,
-		BottomLeft:  lipgloss.RoundedBorder().BottomRight,
-
-		TopJunction:    lipgloss.RoundedBorder().Top,
-		LeftJunction:   lipgloss.RoundedBorder().Left,
-		RightJunction:  lipgloss.RoundedBorder().Right,
-		BottomJunction: "┴",
-		InnerJunction:  "┬",
-		InnerDivider:   "",
-	}
-)
+	DefaultTableHighlineStyle = table.DefaultStyles().Selected.Foreground(lipgloss.Color(p.Highlight)).
+		Background(lipgloss.Color(p.HighlightBg)).
+		Bold(false)
+	DefaultGroupStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(p.Group))
+	DefaultNameStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(p.Name))
+}
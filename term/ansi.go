@@ -6,6 +6,8 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
 )
 
 const (
@@ -20,6 +22,10 @@ func WriteSynced(w io.Writer, fn func()) {
 	if w == nil {
 		return
 	}
+	if !CurrentCapabilities().SyncOutput {
+		fn()
+		return
+	}
 	fmt.Fprint(w, SyncBegin)
 	defer fmt.Fprint(w, SyncEnd)
 	fn()
@@ -114,3 +120,45 @@ func TrimANSIVisibleRight(line string) string {
 	}
 	return line[:cut]
 }
+
+// VisibleWidth returns the number of terminal cells s occupies, ignoring
+// escape sequences.
+func VisibleWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if end, ok := AnsiEscapeEnd(s, i); ok {
+			i = end
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += runewidth.RuneWidth(r)
+		i += size
+	}
+	return width
+}
+
+// TruncateANSI cuts s to at most width visible cells, keeping every escape
+// sequence so styles opened before the cut are still closed after it.
+func TruncateANSI(s string, width int) string {
+	if width < 0 || VisibleWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	used := 0
+	for i := 0; i < len(s); {
+		if end, ok := AnsiEscapeEnd(s, i); ok {
+			b.WriteString(s[i:end])
+			i = end
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if w := runewidth.RuneWidth(r); used+w <= width {
+			b.WriteString(s[i : i+size])
+			used += w
+		} else {
+			used = width + 1
+		}
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,353 @@
+package term
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muesli/termenv"
+	"github.com/xo/terminfo"
+	"golang.org/x/term"
+)
+
+const (
+	queryXTVersion = "\x1b[>0q"
+	querySyncMode  = "\x1b[?2026$p"
+	queryDA1       = "\x1b[c"
+)
+
+type GraphicsProtocol int
+
+const (
+	GraphicsNone GraphicsProtocol = iota
+	GraphicsKitty
+	GraphicsITerm2
+	GraphicsSixel
+)
+
+func (p GraphicsProtocol) String() string {
+	switch p {
+	case GraphicsKitty:
+		return "kitty"
+	case GraphicsITerm2:
+		return "iterm2"
+	case GraphicsSixel:
+		return "sixel"
+	default:
+		return "none"
+	}
+}
+
+// Capabilities describes what the attached terminal can render. Widgets
+// consult it to fall back to ASCII glyphs, plain output or fewer colours.
+type Capabilities struct {
+	Term    string
+	Program string
+	Version string
+
+	Dumb       bool
+	Profile    termenv.Profile
+	Unicode    bool
+	Emoji      bool
+	SyncOutput bool
+	Hyperlinks bool
+	Graphics   GraphicsProtocol
+
+	// Attributes are the DA1 parameters reported by the terminal (4 means
+	// sixel), only set once the terminal has been probed.
+	Attributes []int
+	Probed     bool
+}
+
+func (c Capabilities) HasAttribute(n int) bool {
+	for _, attr := range c.Attributes {
+		if attr == n {
+			return true
+		}
+	}
+	return false
+}
+
+var legacyTermPrefixes = []string{"vt52", "vt100", "vt102", "vt220", "vt320", "ansi", "cons25", "sun"}
+
+// DetectCapabilities derives the capabilities of the process terminal from
+// the environment, stdout and the terminfo database without writing anything
+// to the terminal.
+func DetectCapabilities() Capabilities {
+	return detectCapabilities(os.Getenv, termenv.EnvColorProfile(), runtime.GOOS == "windows")
+}
+
+// TerminalCapabilities derives the capabilities of a terminal other than the
+// process one, like that of a remote client, from its environment variables
+// (TERM, COLORTERM, LANG, NO_COLOR...) and the local terminfo database.
+// isTerminal is false when the output goes to a pipe or a file.
+func TerminalCapabilities(getenv func(string) string, isTerminal bool) Capabilities {
+	output := termenv.NewOutput(io.Discard, termenv.WithEnvironment(envFunc(getenv)), termenv.WithTTY(isTerminal))
+	return detectCapabilities(getenv, output.EnvColorProfile(), getenv("OS") == "Windows_NT")
+}
+
+// envFunc is a terminal environment for termenv, only looked up by name.
+type envFunc func(string) string
+
+func (e envFunc) Environ() []string        { return nil }
+func (e envFunc) Getenv(key string) string { return e(key) }
+
+// detectCapabilities derives capabilities from the environment of a terminal.
+// An unset TERM only says nothing about the terminal (services, Windows consoles),
+// so the output is only considered dumb when it says so.
+func detectCapabilities(getenv func(string) string, profile termenv.Profile, windows bool) Capabilities {
+	c := Capabilities{
+		Term:    getenv("TERM"),
+		Program: getenv("TERM_PROGRAM"),
+		Version: getenv("TERM_PROGRAM_VERSION"),
+		Profile: profile,
+	}
+	windowsTerminal := getenv("WT_SESSION") != ""
+	if c.Term == "dumb" {
+		c.Dumb = true
+		return c
+	}
+
+	legacy := false
+	for _, prefix := range legacyTermPrefixes {
+		if strings.HasPrefix(c.Term, prefix) {
+			legacy = true
+			break
+		}
+	}
+	linuxConsole := c.Term == "linux"
+
+	c.Unicode = !legacy && (localeIsUTF8(getenv) || windows)
+	c.Emoji = c.Unicode && !linuxConsole && (!windows || windowsTerminal)
+	// Terminals ignore private modes they do not know, so only the serial
+	// and hardware-era terminals that print them raw lose synchronized output.
+	c.SyncOutput = !legacy
+
+	if c.Term != "" {
+		if ti, err := terminfo.Load(c.Term); err == nil {
+			applyTerminfo(&c, ti, getenv)
+		}
+	}
+	if !legacy && !linuxConsole {
+		c.Hyperlinks = envHyperlinks(c, getenv)
+		c.Graphics = envGraphics(c, getenv)
+	}
+	return c
+}
+
+func envHyperlinks(c Capabilities, getenv func(string) string) bool {
+	switch c.Program {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby":
+		return true
+	}
+	if getenv("KITTY_WINDOW_ID") != "" || getenv("WT_SESSION") != "" || getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	if v, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	return strings.HasPrefix(c.Term, "xterm-kitty") || strings.HasPrefix(c.Term, "foot") ||
+		strings.HasPrefix(c.Term, "alacritty") || strings.HasPrefix(c.Term, "xterm-ghostty")
+}
+
+// envGraphics guesses the inline image protocol. Multiplexers swallow image
+// sequences unless configured for passthrough, so none is assumed there.
+func envGraphics(c Capabilities, getenv func(string) string) GraphicsProtocol {
+	if getenv("TMUX") != "" || strings.HasPrefix(c.Term, "screen") {
+		return GraphicsNone
+	}
+	switch {
+	case strings.HasPrefix(c.Term, "xterm-kitty"), getenv("KITTY_WINDOW_ID") != "",
+		c.Program == "ghostty", strings.HasPrefix(c.Term, "xterm-ghostty"):
+		return GraphicsKitty
+	case c.Program == "iTerm.app", c.Program == "WezTerm", getenv("LC_TERMINAL") == "iTerm2":
+		return GraphicsITerm2
+	case strings.HasPrefix(c.Term, "foot"), strings.HasPrefix(c.Term, "mlterm"), strings.Contains(c.Term, "sixel"):
+		return GraphicsSixel
+	}
+	return GraphicsNone
+}
+
+func applyTerminfo(c *Capabilities, ti *terminfo.Terminfo, getenv func(string) string) {
+	forced := getenv("CLICOLOR_FORCE") != "" && getenv("CLICOLOR_FORCE") != "0"
+	if colors, ok := ti.Nums[terminfo.MaxColors]; ok && !forced && c.Profile != termenv.Ascii {
+		switch {
+		case colors < 8:
+			c.Profile = termenv.Ascii
+		case colors < 256 && c.Profile < termenv.ANSI:
+			c.Profile = termenv.ANSI
+		}
+	}
+	ext := ti.ExtBoolCapsShort()
+	if (ext["Tc"] || ext["RGB"]) && c.Profile == termenv.ANSI256 {
+		c.Profile = termenv.TrueColor
+	}
+	if _, ok := ti.ExtStringCapsShort()["Sync"]; ok {
+		c.SyncOutput = true
+	}
+}
+
+func localeIsUTF8(getenv func(string) string) bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := getenv(name); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
+
+var (
+	da1Re       = regexp.MustCompile(`\x1b\[\?([\d;]*)c`)
+	xtversionRe = regexp.MustCompile(`\x1bP>\|([^\x1b]*)\x1b\\`)
+	decrpmRe    = regexp.MustCompile(`\x1b\[\?2026;(\d)\$y`)
+)
+
+// ProbeCapabilities refines DetectCapabilities by asking the terminal itself
+// (XTVERSION, DECRQM for synchronized output, DA1). It switches stdin to raw
+// mode for at most timeout, so it must run before any reader owns the input.
+func ProbeCapabilities(timeout time.Duration) Capabilities {
+	c := DetectCapabilities()
+	if c.Dumb || timeout <= 0 || !terminalQueriesSupported {
+		return c
+	}
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return c
+	}
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return c
+	}
+	defer term.Restore(in, state)
+
+	// DA1 goes last: every terminal answers it, so its reply marks the end.
+	if _, err := os.Stdout.WriteString(queryXTVersion + querySyncMode + queryDA1); err != nil {
+		return c
+	}
+	var reply strings.Builder
+	deadline := time.Now().Add(timeout)
+	for !da1Re.MatchString(reply.String()) {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		chunk := ReadPendingTerminalBytes(remaining)
+		if chunk == "" {
+			break
+		}
+		reply.WriteString(chunk)
+	}
+	applyProbeReplies(&c, reply.String())
+	return c
+}
+
+func applyProbeReplies(c *Capabilities, reply string) {
+	if m := da1Re.FindStringSubmatch(reply); m != nil {
+		c.Probed = true
+		for _, field := range strings.Split(m[1], ";") {
+			if n, err := strconv.Atoi(field); err == nil {
+				c.Attributes = append(c.Attributes, n)
+			}
+		}
+	}
+	if m := xtversionRe.FindStringSubmatch(reply); m != nil {
+		name, version := m[1], ""
+		if idx := strings.IndexAny(name, "( "); idx > 0 {
+			name, version = name[:idx], strings.Trim(name[idx:], "() ")
+		}
+		c.Program, c.Version = name, version
+	}
+	if m := decrpmRe.FindStringSubmatch(reply); m != nil {
+		c.SyncOutput = m[1] == "1" || m[1] == "2"
+	}
+	if c.Graphics == GraphicsNone && os.Getenv("TMUX") == "" {
+		switch program := strings.ToLower(c.Program); {
+		case program == "kitty", program == "ghostty":
+			c.Graphics = GraphicsKitty
+		case program == "iterm2", program == "wezterm":
+			c.Graphics = GraphicsITerm2
+		case c.HasAttribute(4):
+			c.Graphics = GraphicsSixel
+		}
+	}
+}
+
+// DefaultProbeTimeout bounds the wait for the replies of the terminal in ProbeTerminal.
+const DefaultProbeTimeout = 150 * time.Millisecond
+
+var (
+	capsMu    sync.RWMutex
+	caps      Capabilities
+	capsKnown bool
+	capsSet   bool // Set explicitly, so not replaced by probing.
+	probeOnce sync.Once
+)
+
+// ProbeTerminal probes the process terminal once, when an interactive
+// application starts and before it reads its input, and makes the results the
+// current capabilities unless they were set with SetCapabilities.
+func ProbeTerminal() {
+	probeOnce.Do(func() {
+		capsMu.RLock()
+		set := capsSet
+		capsMu.RUnlock()
+		if set {
+			return
+		}
+
+		c := ProbeCapabilities(DefaultProbeTimeout)
+
+		capsMu.Lock()
+		if capsSet {
+			capsMu.Unlock()
+			return
+		}
+		caps, capsKnown = c, true
+		capsMu.Unlock()
+		notifyThemeChange()
+	})
+}
+
+// CurrentCapabilities returns the capabilities set with SetCapabilities, or
+// the environment-derived ones when nothing was probed.
+func CurrentCapabilities() Capabilities {
+	capsMu.RLock()
+	if capsKnown {
+		c := caps
+		capsMu.RUnlock()
+		return c
+	}
+	capsMu.RUnlock()
+
+	capsMu.Lock()
+	defer capsMu.Unlock()
+	if !capsKnown {
+		caps = DetectCapabilities()
+		capsKnown = true
+	}
+	return caps
+}
+
+// SetCapabilities overrides the detected capabilities of the process terminal.
+func SetCapabilities(c Capabilities) {
+	capsMu.Lock()
+	caps = c
+	capsKnown, capsSet = true, true
+	capsMu.Unlock()
+	notifyThemeChange()
+}
+
+// Glyph picks the unicode form of a symbol, or its ASCII stand-in on
+// terminals that cannot draw it.
+func Glyph(unicode, ascii string) string {
+	if CurrentCapabilities().Unicode {
+		return unicode
+	}
+	return ascii
+}
@@ -0,0 +1,171 @@
+package term
+
+import (
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestTerminalCapabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		terminal   bool
+		dumb       bool
+		profile    termenv.Profile
+		unicode    bool
+		emoji      bool
+		hyperlinks bool
+		graphics   GraphicsProtocol
+	}{
+		{
+			name:     "dumb",
+			env:      map[string]string{"TERM": "dumb", "LANG": "en_US.UTF-8"},
+			terminal: true,
+			dumb:     true,
+			profile:  termenv.Ascii,
+		},
+		{
+			name:     "unset TERM",
+			env:      map[string]string{"LANG": "en_US.UTF-8"},
+			terminal: true,
+			profile:  termenv.Ascii,
+			unicode:  true,
+			emoji:    true,
+		},
+		{
+			name:     "256 colors",
+			env:      map[string]string{"TERM": "xterm-256color", "LANG": "en_US.UTF-8"},
+			terminal: true,
+			profile:  termenv.ANSI256,
+			unicode:  true,
+			emoji:    true,
+		},
+		{
+			name:     "true color",
+			env:      map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor", "LC_ALL": "C.UTF-8"},
+			terminal: true,
+			profile:  termenv.TrueColor,
+			unicode:  true,
+			emoji:    true,
+		},
+		{
+			name:     "not a terminal",
+			env:      map[string]string{"TERM": "xterm-256color", "LANG": "en_US.UTF-8"},
+			terminal: false,
+			profile:  termenv.Ascii,
+			unicode:  true,
+			emoji:    true,
+		},
+		{
+			name:     "colors disabled",
+			env:      map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"},
+			terminal: true,
+			profile:  termenv.Ascii,
+		},
+		{
+			name:     "legacy terminal",
+			env:      map[string]string{"TERM": "vt100", "LANG": "en_US.UTF-8"},
+			terminal: true,
+			profile:  termenv.Ascii,
+		},
+		{
+			name:     "non UTF-8 locale",
+			env:      map[string]string{"TERM": "xterm-256color", "LANG": "fr_FR.ISO-8859-1"},
+			terminal: true,
+			profile:  termenv.ANSI256,
+		},
+		{
+			name:       "iTerm2",
+			env:        map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app", "LANG": "en_US.UTF-8"},
+			terminal:   true,
+			profile:    termenv.ANSI256,
+			unicode:    true,
+			emoji:      true,
+			hyperlinks: true,
+			graphics:   GraphicsITerm2,
+		},
+		{
+			name:       "kitty",
+			env:        map[string]string{"TERM": "xterm-kitty", "KITTY_WINDOW_ID": "1", "LANG": "en_US.UTF-8"},
+			terminal:   true,
+			profile:    termenv.TrueColor,
+			unicode:    true,
+			emoji:      true,
+			hyperlinks: true,
+			graphics:   GraphicsKitty,
+		},
+		{
+			name:       "kitty in tmux",
+			env:        map[string]string{"TERM": "xterm-kitty", "KITTY_WINDOW_ID": "1", "TMUX": "/tmp/tmux", "LANG": "en_US.UTF-8"},
+			terminal:   true,
+			profile:    termenv.TrueColor,
+			unicode:    true,
+			emoji:      true,
+			hyperlinks: true,
+		},
+		{
+			name:     "windows console",
+			env:      map[string]string{"OS": "Windows_NT"},
+			terminal: true,
+			profile:  termenv.Ascii,
+			unicode:  true,
+		},
+		{
+			name:       "windows terminal",
+			env:        map[string]string{"OS": "Windows_NT", "WT_SESSION": "1"},
+			terminal:   true,
+			profile:    termenv.Ascii,
+			unicode:    true,
+			emoji:      true,
+			hyperlinks: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			c := TerminalCapabilities(getenv, tt.terminal)
+
+			if c.Dumb != tt.dumb {
+				t.Errorf("Dumb = %v, want %v", c.Dumb, tt.dumb)
+			}
+			if !c.Dumb && c.Profile != tt.profile {
+				t.Errorf("Profile = %v, want %v", c.Profile, tt.profile)
+			}
+			if c.Unicode != tt.unicode || c.Emoji != tt.emoji {
+				t.Errorf("Unicode, Emoji = %v, %v, want %v, %v", c.Unicode, c.Emoji, tt.unicode, tt.emoji)
+			}
+			if c.Hyperlinks != tt.hyperlinks {
+				t.Errorf("Hyperlinks = %v, want %v", c.Hyperlinks, tt.hyperlinks)
+			}
+			if c.Graphics != tt.graphics {
+				t.Errorf("Graphics = %v, want %v", c.Graphics, tt.graphics)
+			}
+		})
+	}
+}
+
+func TestApplyProbeReplies(t *testing.T) {
+	var c Capabilities
+	applyProbeReplies(&c, "\x1bP>|kitty(0.35.2)\x1b\\\x1b[?2026;2$y\x1b[?62;4;22c")
+
+	if !c.Probed || c.Program != "kitty" || c.Version != "0.35.2" {
+		t.Fatalf("probed terminal = %+v", c)
+	}
+	if !c.SyncOutput || !c.HasAttribute(4) {
+		t.Fatalf("probed modes = %+v", c)
+	}
+}
+
+func TestProbeTerminalKeepsExplicitCapabilities(t *testing.T) {
+	defer SetCapabilities(CurrentCapabilities())
+
+	want := Capabilities{Unicode: true, Program: "set"}
+	SetCapabilities(want)
+	ProbeTerminal()
+
+	if got := CurrentCapabilities(); got.Program != want.Program {
+		t.Fatalf("CurrentCapabilities() = %+v after probing, want %+v", got, want)
+	}
+}
@@ -20,6 +20,10 @@ func NewColor(enabled bool) Color {
 	return Color{Enabled: enabled}
 }
 
+func AutoColor() Color {
+	return Color{Enabled: ColorEnabled()}
+}
+
 func (c Color) Code(code string) string {
 	if !c.Enabled {
 		return ""
@@ -45,5 +49,16 @@ func (c Color) Dim(s string) string {
 	if !c.Enabled {
 		return s
 	}
-	return "\033[90m" + s + ANSIReset
+	return c.Theme(s, CurrentPalette().Muted)
+}
+
+func (c Color) Theme(s string, color ThemeColor) string {
+	if !c.Enabled {
+		return s
+	}
+	seq := color.Fg()
+	if seq == "" {
+		return s
+	}
+	return seq + s + ANSIReset
 }
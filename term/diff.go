@@ -0,0 +1,960 @@
+package term
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
+)
+
+const (
+	// DefaultDiffContext is the number of unchanged lines kept around changes
+	// by DiffBuffers when given a negative context.
+	DefaultDiffContext = 3
+
+	diffTabWidth      = 4
+	diffMaxEditCost   = 1024
+	diffMinColumn     = 10
+	diffWordThreshold = 0.4
+)
+
+// DiffOp is the edit applied to a line by a diff.
+type DiffOp int
+
+const (
+	DiffEqual  DiffOp = iota // Line present on both sides.
+	DiffDelete               // Line only present in the old file.
+	DiffInsert               // Line only present in the new file.
+)
+
+// DiffLine is a line of a hunk. Old and New are its 1-based line numbers
+// in each file, and are zero on the side the line is not present in.
+type DiffLine struct {
+	Op   DiffOp
+	Old  int
+	New  int
+	Text string
+}
+
+// DiffHunk is a group of changed lines with their context, as introduced by
+// a "@@ -OldStart,OldLines +NewStart,NewLines @@ Section" header.
+type DiffHunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string
+	Lines    []DiffLine
+}
+
+// FileDiff is the diff of one file. Created and deleted files have
+// /dev/null as old or new path, and renamed ones two different paths.
+type FileDiff struct {
+	OldPath string
+	NewPath string
+	Hunks   []DiffHunk
+}
+
+// Path returns the path of the file, which is the new one unless the file
+// was deleted, or an empty string if the diff carries no path.
+func (d FileDiff) Path() string {
+	if d.NewPath != "" && d.NewPath != "/dev/null" {
+		return d.NewPath
+	}
+	if d.OldPath != "/dev/null" {
+		return d.OldPath
+	}
+	return ""
+}
+
+// DiffOptions controls how diffs are rendered.
+type DiffOptions struct {
+	Color Color
+	// Width is the width of rendered lines, the terminal's when not set.
+	Width int
+	// SideBySide renders old and new lines in two columns, when they fit.
+	SideBySide bool
+	// Path overrides the file path used to pick a syntax lexer.
+	Path string
+	// Style is the chroma style of syntax highlighting, the theme's when not set.
+	Style string
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParseUnifiedDiff parses the files of a unified diff, as produced by diff -u
+// or git diff. Lines outside hunks (git extended headers, commit messages)
+// are skipped, and "\ No newline at end of file" markers are ignored.
+func ParseUnifiedDiff(text string) ([]FileDiff, error) {
+	lines := splitDiffLines(text)
+
+	var files []FileDiff
+	cur := -1
+	sawPaths := false
+	var hunk *DiffHunk
+	oldLeft, newLeft := 0, 0
+	oldNo, newNo := 0, 0
+
+	flush := func() {
+		if hunk != nil {
+			files[cur].Hunks = append(files[cur].Hunks, *hunk)
+			hunk = nil
+		}
+	}
+	startFile := func() {
+		flush()
+		files = append(files, FileDiff{})
+		cur = len(files) - 1
+		sawPaths = false
+	}
+
+	for i, line := range lines {
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			switch {
+			case line == "" || line[0] == ' ':
+				text := ""
+				if line != "" {
+					text = line[1:]
+				}
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: DiffEqual, Old: oldNo, New: newNo, Text: text})
+				oldNo++
+				newNo++
+				oldLeft--
+				newLeft--
+			case line[0] == '-':
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: DiffDelete, Old: oldNo, Text: line[1:]})
+				oldNo++
+				oldLeft--
+			case line[0] == '+':
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: DiffInsert, New: newNo, Text: line[1:]})
+				newNo++
+				newLeft--
+			case line[0] == '\\':
+				continue
+			default:
+				return nil, fmt.Errorf("diff line %d: unexpected %q inside hunk", i+1, line)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("diff line %d: hunk longer than its header", i+1)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			startFile()
+			files[cur].OldPath, files[cur].NewPath = parseDiffCommandPaths(line)
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if cur < 0 || sawPaths || hunk != nil || len(files[cur].Hunks) > 0 {
+				startFile()
+			}
+			files[cur].OldPath = cleanDiffPath(line[4:])
+			sawPaths = true
+		case strings.HasPrefix(line, "+++ ") && sawPaths && hunk == nil:
+			files[cur].NewPath = cleanDiffPath(line[4:])
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeaderRe.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("diff line %d: malformed hunk header %q", i+1, line)
+			}
+			if cur < 0 {
+				startFile()
+			}
+			flush()
+			hunk = &DiffHunk{
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+				Section:  m[5],
+			}
+			oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+			oldNo, newNo = hunk.OldStart, hunk.NewStart
+		}
+	}
+
+	if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+		return nil, fmt.Errorf("diff: truncated hunk at %s", formatHunkHeader(*hunk))
+	}
+	if cur >= 0 {
+		flush()
+	}
+	return files, nil
+}
+
+// DiffBuffers computes the diff of two versions of a file, with hunks keeping
+// context unchanged lines around changes (DefaultDiffContext if negative).
+func DiffBuffers(path string, old, new []byte, context int) FileDiff {
+	if context < 0 {
+		context = DefaultDiffContext
+	}
+	a := splitDiffLines(string(old))
+	b := splitDiffLines(string(new))
+
+	lines := make([]DiffLine, 0, len(a)+len(b))
+	oi, ni := 0, 0
+	for _, op := range diffSequence(a, b) {
+		switch op {
+		case DiffEqual:
+			lines = append(lines, DiffLine{Op: op, Old: oi + 1, New: ni + 1, Text: a[oi]})
+			oi++
+			ni++
+		case DiffDelete:
+			lines = append(lines, DiffLine{Op: op, Old: oi + 1, Text: a[oi]})
+			oi++
+		case DiffInsert:
+			lines = append(lines, DiffLine{Op: op, New: ni + 1, Text: b[ni]})
+			ni++
+		}
+	}
+	return FileDiff{OldPath: path, NewPath: path, Hunks: groupDiffHunks(lines, context)}
+}
+
+// HighlightDiff renders a unified diff with RenderDiff, or returns its lines
+// as they are if it cannot be parsed.
+func HighlightDiff(text string, opts DiffOptions) []string {
+	files, err := ParseUnifiedDiff(text)
+	if err != nil || len(files) == 0 {
+		return splitDiffLines(text)
+	}
+	var out []string
+	for i, f := range files {
+		if i > 0 {
+			out = append(out, "")
+		}
+		out = append(out, RenderDiff(f, opts)...)
+	}
+	return out
+}
+
+// RenderDiff renders a file diff inline or side by side, with line numbers,
+// syntax highlighting and changed words emphasised when colours are enabled.
+func RenderDiff(d FileDiff, opts DiffOptions) []string {
+	r := newDiffRenderer(d, opts)
+	var out []string
+	if path := d.Path(); path != "" {
+		header := path
+		if d.OldPath != "" && d.NewPath != "" && d.OldPath != d.NewPath {
+			header = d.OldPath + " → " + d.NewPath
+		}
+		out = append(out, opts.Color.Bold(header))
+	}
+	for _, h := range d.Hunks {
+		out = append(out, opts.Color.Wrap(formatHunkHeader(h), ANSICyan))
+		entries := r.prepare(h)
+		if r.sideBySide {
+			out = r.renderSplit(out, entries)
+		} else {
+			out = r.renderInline(out, entries)
+		}
+	}
+	return out
+}
+
+func formatHunkHeader(h DiffHunk) string {
+	s := fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	if h.Section != "" {
+		s += " " + h.Section
+	}
+	return s
+}
+
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	return strings.Split(text, "\n")
+}
+
+func parseDiffCommandPaths(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff ")
+	rest = strings.TrimPrefix(rest, "--git ")
+	if idx := strings.LastIndex(rest, " b/"); idx >= 0 {
+		return cleanDiffPath(rest[:idx]), cleanDiffPath(rest[idx+1:])
+	}
+	fields := strings.Fields(rest)
+	if len(fields) >= 2 {
+		return cleanDiffPath(fields[len(fields)-2]), cleanDiffPath(fields[len(fields)-1])
+	}
+	return "", ""
+}
+
+func cleanDiffPath(p string) string {
+	if idx := strings.IndexByte(p, '\t'); idx >= 0 {
+		p = p[:idx]
+	}
+	p = strings.Trim(strings.TrimSpace(p), `"`)
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func groupDiffHunks(lines []DiffLine, context int) []DiffHunk {
+	var hunks []DiffHunk
+	oldBefore := make([]int, len(lines)+1)
+	newBefore := make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldBefore[i+1] = oldBefore[i]
+		newBefore[i+1] = newBefore[i]
+		if l.Op != DiffInsert {
+			oldBefore[i+1]++
+		}
+		if l.Op != DiffDelete {
+			newBefore[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].Op == DiffEqual {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != DiffEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Op == DiffEqual {
+				next++
+			}
+			if next < len(lines) && next-end <= 2*context {
+				end = next
+				continue
+			}
+			end = min(end+context, len(lines))
+			break
+		}
+
+		h := DiffHunk{
+			OldLines: oldBefore[end] - oldBefore[start],
+			NewLines: newBefore[end] - newBefore[start],
+			Lines:    append([]DiffLine(nil), lines[start:end]...),
+		}
+		h.OldStart = oldBefore[start]
+		if h.OldLines > 0 {
+			h.OldStart++
+		}
+		h.NewStart = newBefore[start]
+		if h.NewLines > 0 {
+			h.NewStart++
+		}
+		hunks = append(hunks, h)
+		i = end
+	}
+	return hunks
+}
+
+// diffSequence computes a shortest edit script with Myers' algorithm. Past
+// diffMaxEditCost edits the remainder is reported as a full replacement, which
+// keeps memory bounded on unrelated inputs.
+func diffSequence(a, b []string) []DiffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]DiffOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, DiffEqual)
+	}
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, DiffEqual)
+	}
+	return ops
+}
+
+func myersDiff(a, b []string) []DiffOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceOps(n, m)
+	}
+
+	limit := min(n+m, diffMaxEditCost)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	final := -1
+
+	for d := 0; d <= limit && final < 0; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				final = d
+				break
+			}
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+	}
+	if final < 0 {
+		return replaceOps(n, m)
+	}
+
+	reversed := make([]DiffOp, 0, n+m)
+	x, y := n, m
+	for d := final; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, DiffEqual)
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, DiffInsert)
+		} else {
+			reversed = append(reversed, DiffDelete)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, DiffEqual)
+		x--
+		y--
+	}
+
+	ops := make([]DiffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+func replaceOps(n, m int) []DiffOp {
+	ops := make([]DiffOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, DiffDelete)
+	}
+	for i := 0; i < m; i++ {
+		ops = append(ops, DiffInsert)
+	}
+	return ops
+}
+
+func splitDiffWords(s string) []string {
+	var words []string
+	start := 0
+	class := -1
+	for i, r := range s {
+		c := 2
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			c = 0
+		case unicode.IsSpace(r):
+			c = 1
+		}
+		if i > start && (c != class || c == 2) {
+			words = append(words, s[start:i])
+			start = i
+		}
+		class = c
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// diffWordEmphasis marks the runes of a and b that differ at word
+// granularity. Lines that share too little are left unmarked, since
+// emphasising nearly everything is noise.
+func diffWordEmphasis(a, b string) ([]bool, []bool) {
+	wa, wb := splitDiffWords(a), splitDiffWords(b)
+	ea := make([]bool, 0, utf8.RuneCountInString(a))
+	eb := make([]bool, 0, utf8.RuneCountInString(b))
+	ai, bi, same := 0, 0, 0
+	for _, op := range diffSequence(wa, wb) {
+		switch op {
+		case DiffEqual:
+			n := utf8.RuneCountInString(wa[ai])
+			same += n
+			for i := 0; i < n; i++ {
+				ea = append(ea, false)
+				eb = append(eb, false)
+			}
+			ai++
+			bi++
+		case DiffDelete:
+			for range wa[ai] {
+				ea = append(ea, true)
+			}
+			ai++
+		case DiffInsert:
+			for range wb[bi] {
+				eb = append(eb, true)
+			}
+			bi++
+		}
+	}
+	total := len(ea) + len(eb)
+	if total == 0 || float64(2*same)/float64(total) < diffWordThreshold {
+		return nil, nil
+	}
+	return ea, eb
+}
+
+type diffCell struct {
+	r     rune
+	width int
+	style chroma.StyleEntry
+	emph  bool
+}
+
+type diffEntry struct {
+	line  DiffLine
+	cells []diffCell
+}
+
+type diffRenderer struct {
+	color      Color
+	profile    termenv.Profile
+	lexer      chroma.Lexer
+	style      *chroma.Style
+	width      int
+	numWidth   int
+	sideBySide bool
+	column     int
+	palette    Palette
+}
+
+func newDiffRenderer(d FileDiff, opts DiffOptions) *diffRenderer {
+	r := &diffRenderer{color: opts.Color, width: opts.Width}
+	if r.width <= 0 {
+		r.width = TerminalWidth()
+	}
+	if r.width <= 0 {
+		r.width = 80
+	}
+
+	maxNo := 1
+	for _, h := range d.Hunks {
+		maxNo = max(maxNo, h.OldStart+h.OldLines, h.NewStart+h.NewLines)
+	}
+	r.numWidth = len(strconv.Itoa(maxNo))
+
+	if opts.SideBySide {
+		r.column = (r.width - 2*(r.numWidth+3) - 3) / 2
+		r.sideBySide = r.column >= diffMinColumn
+	}
+
+	if !r.color.Enabled {
+		return r
+	}
+	r.palette = CurrentPalette()
+	r.profile = ColorProfile()
+	if r.profile == termenv.Ascii {
+		r.profile = termenv.ANSI
+	}
+	path := opts.Path
+	if path == "" {
+		path = d.Path()
+	}
+	if path != "" {
+		if lexer := lexers.Match(path); lexer != nil {
+			r.lexer = chroma.Coalesce(lexer)
+		}
+	}
+	name := opts.Style
+	if name == "" {
+		name = r.palette.Syntax
+	}
+	r.style = styles.Get(name)
+	if r.style == nil {
+		r.style = styles.Fallback
+	}
+	return r
+}
+
+func (r *diffRenderer) prepare(h DiffHunk) []diffEntry {
+	var oldLines, newLines []string
+	var oldIdx, newIdx []int
+	for i, l := range h.Lines {
+		if l.Op != DiffInsert {
+			oldLines = append(oldLines, l.Text)
+			oldIdx = append(oldIdx, i)
+		}
+		if l.Op != DiffDelete {
+			newLines = append(newLines, l.Text)
+			newIdx = append(newIdx, i)
+		}
+	}
+
+	syntax := make([][]chroma.StyleEntry, len(h.Lines))
+	for i, s := range r.highlight(newLines) {
+		syntax[newIdx[i]] = s
+	}
+	for i, s := range r.highlight(oldLines) {
+		if h.Lines[oldIdx[i]].Op == DiffDelete {
+			syntax[oldIdx[i]] = s
+		}
+	}
+
+	emph := make([][]bool, len(h.Lines))
+	if r.color.Enabled {
+		for i := 0; i < len(h.Lines); {
+			if h.Lines[i].Op != DiffDelete {
+				i++
+				continue
+			}
+			delStart := i
+			for i < len(h.Lines) && h.Lines[i].Op == DiffDelete {
+				i++
+			}
+			insStart := i
+			for i < len(h.Lines) && h.Lines[i].Op == DiffInsert {
+				i++
+			}
+			for j := 0; delStart+j < insStart && insStart+j < i; j++ {
+				emph[delStart+j], emph[insStart+j] = diffWordEmphasis(h.Lines[delStart+j].Text, h.Lines[insStart+j].Text)
+			}
+		}
+	}
+
+	entries := make([]diffEntry, len(h.Lines))
+	for i, l := range h.Lines {
+		entries[i] = diffEntry{line: l, cells: buildDiffCells(l.Text, syntax[i], emph[i])}
+	}
+	return entries
+}
+
+func (r *diffRenderer) highlight(lines []string) [][]chroma.StyleEntry {
+	if r.lexer == nil || len(lines) == 0 {
+		return nil
+	}
+	iterator, err := r.lexer.Tokenise(nil, strings.Join(lines, "\n"))
+	if err != nil {
+		return nil
+	}
+	out := make([][]chroma.StyleEntry, len(lines))
+	line := 0
+	for tok := iterator(); tok != chroma.EOF; tok = iterator() {
+		entry := r.style.Get(tok.Type)
+		for _, ch := range tok.Value {
+			if ch == '\n' {
+				line++
+				continue
+			}
+			if line < len(out) {
+				out[line] = append(out[line], entry)
+			}
+		}
+	}
+	for i, l := range lines {
+		if len(out[i]) != utf8.RuneCountInString(l) {
+			out[i] = nil
+		}
+	}
+	return out
+}
+
+func buildDiffCells(text string, syntax []chroma.StyleEntry, emph []bool) []diffCell {
+	cells := make([]diffCell, 0, len(text))
+	col, idx := 0, 0
+	for _, ch := range text {
+		cell := diffCell{r: ch}
+		if idx < len(syntax) {
+			cell.style = syntax[idx]
+		}
+		if idx < len(emph) {
+			cell.emph = emph[idx]
+		}
+		idx++
+
+		if ch == '\t' {
+			cell.r, cell.width = ' ', 1
+			for n := diffTabWidth - col%diffTabWidth; n > 0; n-- {
+				cells = append(cells, cell)
+				col++
+			}
+			continue
+		}
+		if !unicode.IsPrint(ch) {
+			cell.r = '?'
+		}
+		cell.width = runewidth.RuneWidth(cell.r)
+		if cell.width == 0 {
+			continue
+		}
+		cells = append(cells, cell)
+		col += cell.width
+	}
+	return cells
+}
+
+func wrapDiffCells(cells []diffCell, width int) [][]diffCell {
+	if width <= 0 || len(cells) == 0 {
+		return [][]diffCell{cells}
+	}
+	var rows [][]diffCell
+	start, used := 0, 0
+	for i, c := range cells {
+		if used+c.width > width && i > start {
+			rows = append(rows, cells[start:i])
+			start, used = i, 0
+		}
+		used += c.width
+	}
+	return append(rows, cells[start:])
+}
+
+func (r *diffRenderer) renderInline(out []string, entries []diffEntry) []string {
+	gutter := 2*r.numWidth + 4
+	contentWidth := r.width - gutter
+	if contentWidth < diffMinColumn {
+		contentWidth = 0
+	}
+	for _, e := range entries {
+		oldNo, newNo := "", ""
+		if e.line.Op != DiffInsert {
+			oldNo = strconv.Itoa(e.line.Old)
+		}
+		if e.line.Op != DiffDelete {
+			newNo = strconv.Itoa(e.line.New)
+		}
+		for i, row := range wrapDiffCells(e.cells, contentWidth) {
+			numbers := fmt.Sprintf("%*s %*s", r.numWidth, oldNo, r.numWidth, newNo)
+			marker := r.marker(e.line.Op)
+			if i > 0 {
+				numbers = strings.Repeat(" ", len(numbers))
+				marker = " "
+			}
+			out = append(out, r.color.Dim(numbers)+" "+marker+" "+r.paint(row, e.line.Op, 0))
+		}
+	}
+	return out
+}
+
+func (r *diffRenderer) renderSplit(out []string, entries []diffEntry) []string {
+	type pair struct{ left, right *diffEntry }
+	var pairs []pair
+	for i := 0; i < len(entries); {
+		if entries[i].line.Op == DiffEqual {
+			pairs = append(pairs, pair{&entries[i], &entries[i]})
+			i++
+			continue
+		}
+		var dels, ins []*diffEntry
+		for i < len(entries) && entries[i].line.Op == DiffDelete {
+			dels = append(dels, &entries[i])
+			i++
+		}
+		for i < len(entries) && entries[i].line.Op == DiffInsert {
+			ins = append(ins, &entries[i])
+			i++
+		}
+		for j := 0; j < max(len(dels), len(ins)); j++ {
+			var p pair
+			if j < len(dels) {
+				p.left = dels[j]
+			}
+			if j < len(ins) {
+				p.right = ins[j]
+			}
+			pairs = append(pairs, p)
+		}
+	}
+
+	sep := " " + r.color.Dim(Glyph("│", "|")) + " "
+	for _, p := range pairs {
+		var left, right [][]diffCell
+		if p.left != nil {
+			left = wrapDiffCells(p.left.cells, r.column)
+		}
+		if p.right != nil {
+			right = wrapDiffCells(p.right.cells, r.column)
+		}
+		for i := 0; i < max(len(left), len(right)); i++ {
+			out = append(out, r.splitSide(p.left, left, i, true, false)+sep+r.splitSide(p.right, right, i, false, true))
+		}
+	}
+	return out
+}
+
+func (r *diffRenderer) splitSide(e *diffEntry, rows [][]diffCell, i int, old, last bool) string {
+	blank := strings.Repeat(" ", r.numWidth+3)
+	if e == nil || i >= len(rows) {
+		if last && !r.color.Enabled {
+			return ""
+		}
+		return blank + strings.Repeat(" ", r.column)
+	}
+	op := e.line.Op
+	width := r.column
+	if last && !r.color.Enabled {
+		width = 0
+	}
+	if i > 0 {
+		return blank + r.paint(rows[i], op, width)
+	}
+	no := e.line.New
+	if old {
+		no = e.line.Old
+	}
+	return r.color.Dim(fmt.Sprintf("%*d", r.numWidth, no)) + " " + r.marker(op) + " " + r.paint(rows[i], op, width)
+}
+
+func (r *diffRenderer) marker(op DiffOp) string {
+	switch op {
+	case DiffDelete:
+		return r.color.Wrap("-", ANSIRed)
+	case DiffInsert:
+		return r.color.Wrap("+", ANSIGreen)
+	default:
+		return " "
+	}
+}
+
+func (r *diffRenderer) paint(cells []diffCell, op DiffOp, width int) string {
+	used := 0
+	var b strings.Builder
+	if !r.color.Enabled {
+		for _, c := range cells {
+			b.WriteRune(c.r)
+			used += c.width
+		}
+		if width > used {
+			b.WriteString(strings.Repeat(" ", width-used))
+		}
+		return b.String()
+	}
+
+	current := ""
+	for _, c := range cells {
+		if seq := r.sgr(c, op); seq != current {
+			if current != "" {
+				b.WriteString(ANSIReset)
+			}
+			b.WriteString(seq)
+			current = seq
+		}
+		b.WriteRune(c.r)
+		used += c.width
+	}
+	if width > used {
+		if seq := r.sgr(diffCell{}, op); seq != current {
+			if current != "" {
+				b.WriteString(ANSIReset)
+			}
+			b.WriteString(seq)
+			current = seq
+		}
+		b.WriteString(strings.Repeat(" ", width-used))
+	}
+	if current != "" {
+		b.WriteString(ANSIReset)
+	}
+	return b.String()
+}
+
+func (r *diffRenderer) sgr(c diffCell, op DiffOp) string {
+	var params []string
+	if c.style.Bold == chroma.Yes {
+		params = append(params, "1")
+	}
+	if c.style.Italic == chroma.Yes {
+		params = append(params, "3")
+	}
+	if c.style.Underline == chroma.Yes {
+		params = append(params, "4")
+	}
+
+	if r.profile == termenv.ANSI {
+		// Sixteen colours cannot carry tinted backgrounds, so changed lines
+		// trade syntax colours for red/green and words go reverse video.
+		switch op {
+		case DiffDelete:
+			params = append(params, "31")
+		case DiffInsert:
+			params = append(params, "32")
+		default:
+			params = r.appendColour(params, c.style.Colour, false)
+		}
+		if c.emph {
+			params = append(params, "7")
+		}
+	} else {
+		params = r.appendColour(params, c.style.Colour, false)
+		switch {
+		case op == DiffDelete && c.emph:
+			params = r.appendHex(params, string(r.palette.DiffDeleteWord), true)
+		case op == DiffDelete:
+			params = r.appendHex(params, string(r.palette.DiffDelete), true)
+		case op == DiffInsert && c.emph:
+			params = r.appendHex(params, string(r.palette.DiffInsertWord), true)
+		case op == DiffInsert:
+			params = r.appendHex(params, string(r.palette.DiffInsert), true)
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+func (r *diffRenderer) appendColour(params []string, c chroma.Colour, bg bool) []string {
+	if !c.IsSet() {
+		return params
+	}
+	return r.appendHex(params, c.String(), bg)
+}
+
+func (r *diffRenderer) appendHex(params []string, hex string, bg bool) []string {
+	color := r.profile.Color(hex)
+	if color == nil {
+		return params
+	}
+	if seq := color.Sequence(bg); seq != "" {
+		params = append(params, seq)
+	}
+	return params
+}
@@ -0,0 +1,314 @@
+package term
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		diff  string
+		paths [][2]string // Old and new path of each file.
+		hunks [][]string  // Lines of the hunks of each file, prefixed with their op.
+	}{
+		{
+			name: "multiple files",
+			diff: `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@ package main
+ a
+-b
++B
+ c
+diff --git a/util.go b/util.go
+--- a/util.go
++++ b/util.go
+@@ -10,2 +10,3 @@
+ x
++y
+ z
+`,
+			paths: [][2]string{{"main.go", "main.go"}, {"util.go", "util.go"}},
+			hunks: [][]string{{" a", "-b", "+B", " c"}, {" x", "+y", " z"}},
+		},
+		{
+			name:  "plain diff -u",
+			diff:  "--- old.txt\t2024-01-01\n+++ new.txt\t2024-01-02\n@@ -1 +1 @@\n-x\n+y\n--- a.txt\n+++ a.txt\n@@ -1 +0,0 @@\n-gone\n",
+			paths: [][2]string{{"old.txt", "new.txt"}, {"a.txt", "a.txt"}},
+			hunks: [][]string{{"-x", "+y"}, {"-gone"}},
+		},
+		{
+			name: "no newline markers",
+			diff: `--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+\ No newline at end of file
+`,
+			paths: [][2]string{{"f", "f"}},
+			hunks: [][]string{{" a", "-b", "+b"}},
+		},
+		{
+			name: "renames",
+			diff: `diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+diff --git a/a.go b/b.go
+similarity index 80%
+rename from a.go
+rename to b.go
+--- a/a.go
++++ b/b.go
+@@ -1 +1 @@
+-package a
++package b
+`,
+			paths: [][2]string{{"old.go", "new.go"}, {"a.go", "b.go"}},
+			hunks: [][]string{nil, {"-package a", "+package b"}},
+		},
+		{
+			name:  "created file",
+			diff:  "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+			paths: [][2]string{{"/dev/null", "new.go"}},
+			hunks: [][]string{{"+one", "+two"}},
+		},
+	}
+
+	ops := map[DiffOp]string{DiffEqual: " ", DiffDelete: "-", DiffInsert: "+"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ParseUnifiedDiff(tt.diff)
+			if err != nil {
+				t.Fatalf("ParseUnifiedDiff() error = %v", err)
+			}
+
+			if len(files) != len(tt.paths) {
+				t.Fatalf("ParseUnifiedDiff() = %d files, want %d", len(files), len(tt.paths))
+			}
+
+			for i, file := range files {
+				if paths := [2]string{file.OldPath, file.NewPath}; paths != tt.paths[i] {
+					t.Errorf("file %d paths = %q, want %q", i, paths, tt.paths[i])
+				}
+
+				var lines []string
+				for _, hunk := range file.Hunks {
+					for _, line := range hunk.Lines {
+						lines = append(lines, ops[line.Op]+line.Text)
+					}
+				}
+
+				if !reflect.DeepEqual(lines, tt.hunks[i]) {
+					t.Errorf("file %d lines = %q, want %q", i, lines, tt.hunks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnifiedDiffNumbers(t *testing.T) {
+	files, err := ParseUnifiedDiff("--- a/f\n+++ b/f\n@@ -4,3 +4,3 @@ func f() {\n x\n-y\n+Y\n z\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hunk := files[0].Hunks[0]
+	if hunk.Section != "func f() {" || hunk.OldStart != 4 || hunk.NewLines != 3 {
+		t.Fatalf("hunk header = %+v", hunk)
+	}
+
+	want := []DiffLine{
+		{Op: DiffEqual, Old: 4, New: 4, Text: "x"},
+		{Op: DiffDelete, Old: 5, Text: "y"},
+		{Op: DiffInsert, New: 5, Text: "Y"},
+		{Op: DiffEqual, Old: 6, New: 6, Text: "z"},
+	}
+	if !reflect.DeepEqual(hunk.Lines, want) {
+		t.Fatalf("hunk lines = %+v, want %+v", hunk.Lines, want)
+	}
+}
+
+func TestParseUnifiedDiffErrors(t *testing.T) {
+	tests := []string{
+		"--- a/f\n+++ b/f\n@@ -1,x +1 @@\n",
+		"--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n",
+		"--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n?b\n",
+	}
+
+	for _, diff := range tests {
+		if _, err := ParseUnifiedDiff(diff); err == nil {
+			t.Errorf("ParseUnifiedDiff(%q) did not fail", diff)
+		}
+	}
+}
+
+// applyDiffOps rebuilds both sides of an edit script, and counts its edits.
+func applyDiffOps(a, b []string, ops []DiffOp) (old, new []string, edits int) {
+	ai, bi := 0, 0
+
+	for _, op := range ops {
+		switch op {
+		case DiffEqual:
+			old, new = append(old, a[ai]), append(new, b[bi])
+			ai++
+			bi++
+		case DiffDelete:
+			old = append(old, a[ai])
+			ai++
+			edits++
+		case DiffInsert:
+			new = append(new, b[bi])
+			bi++
+			edits++
+		}
+	}
+
+	return old, new, edits
+}
+
+func TestDiffSequence(t *testing.T) {
+	E, D, I := DiffEqual, DiffDelete, DiffInsert
+
+	tests := []struct {
+		a, b  string
+		ops   []DiffOp // Expected script, if unambiguous.
+		edits int
+	}{
+		{"", "", nil, 0},
+		{"abc", "abc", []DiffOp{E, E, E}, 0},
+		{"", "ab", []DiffOp{I, I}, 2},
+		{"ab", "", []DiffOp{D, D}, 2},
+		{"abc", "axc", []DiffOp{E, D, I, E}, 2},
+		{"abcd", "acd", []DiffOp{E, D, E, E}, 1},
+		{"acd", "abcd", []DiffOp{E, I, E, E}, 1},
+		{"abcabba", "cbabac", nil, 5},
+		{"xyz", "abc", []DiffOp{D, D, D, I, I, I}, 6},
+	}
+
+	for _, tt := range tests {
+		a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+		ops := diffSequence(a, b)
+
+		old, new, edits := applyDiffOps(a, b, ops)
+		if strings.Join(old, "") != tt.a || strings.Join(new, "") != tt.b {
+			t.Errorf("diffSequence(%q, %q) = %v, rebuilds %q and %q", tt.a, tt.b, ops, old, new)
+		}
+
+		if edits != tt.edits {
+			t.Errorf("diffSequence(%q, %q) = %d edits, want %d", tt.a, tt.b, edits, tt.edits)
+		}
+
+		if tt.ops != nil && !reflect.DeepEqual(ops, tt.ops) {
+			t.Errorf("diffSequence(%q, %q) = %v, want %v", tt.a, tt.b, ops, tt.ops)
+		}
+	}
+}
+
+func TestDiffBuffers(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	new := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	diff := DiffBuffers("f.txt", []byte(old), []byte(new), 1)
+
+	var headers []string
+	for _, hunk := range diff.Hunks {
+		headers = append(headers, formatHunkHeader(hunk))
+	}
+
+	want := []string{"@@ -2,3 +2,3 @@", "@@ -12,1 +12,2 @@"}
+	if !reflect.DeepEqual(headers, want) {
+		t.Fatalf("hunks = %q, want %q", headers, want)
+	}
+
+	if diff := DiffBuffers("f.txt", []byte(old), []byte(old), -1); len(diff.Hunks) != 0 {
+		t.Fatalf("hunks of identical buffers = %+v", diff.Hunks)
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	defer SetCapabilities(CurrentCapabilities())
+	SetCapabilities(Capabilities{Unicode: true})
+
+	files, err := ParseUnifiedDiff("--- a/old.txt\n+++ b/new.txt\n@@ -8,3 +8,3 @@\n keep\n-before\n+after\n same\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts DiffOptions
+		want []string
+	}{
+		{
+			name: "inline",
+			opts: DiffOptions{Width: 40},
+			want: []string{
+				"old.txt → new.txt",
+				"@@ -8,3 +8,3 @@",
+				" 8  8   keep",
+				" 9    - before",
+				"    9 + after",
+				"10 10   same",
+			},
+		},
+		{
+			name: "side by side",
+			opts: DiffOptions{Width: 40, SideBySide: true},
+			want: []string{
+				"old.txt → new.txt",
+				"@@ -8,3 +8,3 @@",
+				" 8   keep          │  8   keep",
+				" 9 - before        │  9 + after",
+				"10   same          │ 10   same",
+			},
+		},
+		{
+			name: "side by side too narrow",
+			opts: DiffOptions{Width: 30, SideBySide: true},
+			want: []string{
+				"old.txt → new.txt",
+				"@@ -8,3 +8,3 @@",
+				" 8  8   keep",
+				" 9    - before",
+				"    9 + after",
+				"10 10   same",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderDiff(files[0], tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RenderDiff() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRenderDiffWraps(t *testing.T) {
+	diff := FileDiff{Hunks: []DiffHunk{{
+		OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 0,
+		Lines: []DiffLine{{Op: DiffDelete, Old: 1, Text: "abcdefghijklmnop"}},
+	}}}
+
+	want := []string{"@@ -1,1 +1,0 @@", "1   - abcdefghijkl", "      mnop"}
+	if got := RenderDiff(diff, DiffOptions{Width: 18}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("RenderDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestHighlightDiffInvalid(t *testing.T) {
+	text := "not a diff\n@@ broken\n"
+	if got := HighlightDiff(text, DiffOptions{}); !reflect.DeepEqual(got, []string{"not a diff", "@@ broken"}) {
+		t.Fatalf("HighlightDiff() = %q, want the lines as they are", got)
+	}
+}
@@ -4,6 +4,18 @@ package term
 
 import "time"
 
+const terminalQueriesSupported = false
+
 func ReadPendingTerminalBytes(_ time.Duration) string {
 	return ""
 }
+
+func terminalCellSize() (int, int) {
+	return 0, 0
+}
+
+// watchResize is a no-op: there is no resize signal, so live regions only
+// notice a new size on their next frame.
+func watchResize(func()) func() {
+	return func() {}
+}
@@ -4,11 +4,14 @@ package term
 
 import (
 	"os"
+	"os/signal"
 	"time"
 
 	"golang.org/x/sys/unix"
 )
 
+const terminalQueriesSupported = true
+
 func ReadPendingTerminalBytes(timeout time.Duration) string {
 	file := os.Stdin
 	fd := int32(file.Fd()) //nolint:gosec // stdin fd is always small
@@ -36,3 +39,35 @@ func ReadPendingTerminalBytes(timeout time.Duration) string {
 	}
 	return string(buf[:read])
 }
+
+// terminalCellSize reports the pixel size of one character cell, or zeros
+// when the terminal does not fill in the pixel fields of its window size.
+func terminalCellSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return 0, 0
+	}
+	return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row)
+}
+
+// watchResize calls fn whenever the process terminal is resized, until the
+// returned function is called.
+func watchResize(fn func()) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, unix.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				fn()
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -50,7 +50,7 @@ func HighlightCode(path string, lines []string, color Color) []string {
 		formatter = formatters.Fallback
 	}
 
-	style := styles.Get("monokai")
+	style := styles.Get(CurrentPalette().Syntax)
 	if style == nil {
 		style = styles.Fallback
 	}
@@ -87,12 +87,14 @@ func HighlightCode(path string, lines []string, color Color) []string {
 }
 
 func SelectChromaFormatter() string {
-	switch termenv.ColorProfile() {
+	switch ColorProfile() {
 	case termenv.TrueColor:
 		return "terminal16m"
 	case termenv.ANSI256:
 		return "terminal256"
+	case termenv.ANSI:
+		return "terminal16"
 	default:
-		return "terminal256"
+		return "noop"
 	}
 }
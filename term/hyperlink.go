@@ -0,0 +1,61 @@
+package term
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	hyperlinkOpen  = "\x1b]8;;"
+	hyperlinkClose = "\x1b]8;;\x1b\\"
+)
+
+// Hyperlink makes text open target when clicked (OSC 8). Terminals without
+// hyperlink support get "text (target)", or just target when text is empty or
+// the same as it.
+func Hyperlink(target, text string) string {
+	if text == "" {
+		text = target
+	}
+	if target == "" {
+		return text
+	}
+	if !CurrentCapabilities().Hyperlinks {
+		if text == target {
+			return text
+		}
+		return text + " (" + target + ")"
+	}
+	return hyperlinkOpen + sanitizeHyperlinkTarget(target) + "\x1b\\" + text + hyperlinkClose
+}
+
+// FileHyperlink links text to a local file. The host name is part of the URL
+// so terminals do not open paths that belong to a remote machine.
+func FileHyperlink(path, text string) string {
+	if text == "" {
+		text = path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return text
+	}
+	host, _ := os.Hostname()
+	u := url.URL{Scheme: "file", Host: host, Path: filepath.ToSlash(abs)}
+	if !CurrentCapabilities().Hyperlinks {
+		return Hyperlink(path, text)
+	}
+	return Hyperlink(u.String(), text)
+}
+
+// sanitizeHyperlinkTarget drops bytes that would terminate the OSC sequence
+// early.
+func sanitizeHyperlinkTarget(target string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, target)
+}
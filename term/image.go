@@ -0,0 +1,297 @@
+package term
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	kittyChunkSize = 4096
+	// fallback cell size in pixels when the terminal does not report one
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+	// MaxImageFileSize caps the size of the files InlineImageFile reads.
+	MaxImageFileSize = 32 << 20
+)
+
+// ImageOptions sizes an inline image in terminal cells. Zero values keep the
+// image's own size, capped to the terminal width.
+type ImageOptions struct {
+	Name   string
+	Width  int
+	Height int
+	// Protocol overrides the detected graphics protocol.
+	Protocol GraphicsProtocol
+}
+
+// InlineImage renders an encoded image (PNG, JPEG or GIF) for the terminal's
+// graphics protocol, or a text placeholder when it has none.
+func InlineImage(data []byte, opts ImageOptions) string {
+	protocol := opts.Protocol
+	if protocol == GraphicsNone {
+		protocol = CurrentCapabilities().Graphics
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ImagePlaceholder(opts.Name, 0, 0)
+	}
+	if opts.Width <= 0 && opts.Height <= 0 {
+		if w := TerminalWidth(); w > 0 {
+			cw, _ := cellSize()
+			if cfg.Width > w*cw {
+				opts.Width = w
+			}
+		}
+	}
+
+	switch protocol {
+	case GraphicsKitty:
+		if format != "png" {
+			if data, err = reencodePNG(data); err != nil {
+				return ImagePlaceholder(opts.Name, cfg.Width, cfg.Height)
+			}
+		}
+		return kittyImage(data, opts)
+	case GraphicsITerm2:
+		return iterm2Image(data, opts)
+	case GraphicsSixel:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return ImagePlaceholder(opts.Name, cfg.Width, cfg.Height)
+		}
+		return encodeSixel(fitImage(img, opts))
+	default:
+		return ImagePlaceholder(opts.Name, cfg.Width, cfg.Height)
+	}
+}
+
+// InlineImageFile renders the image stored at path like InlineImage. Only
+// regular files of at most MaxImageFileSize bytes are read.
+func InlineImageFile(path string, opts ImageOptions) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s: not a regular file", path)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, MaxImageFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxImageFileSize {
+		return "", fmt.Errorf("%s: image larger than %d bytes", path, MaxImageFileSize)
+	}
+	if opts.Name == "" {
+		opts.Name = filepath.Base(path)
+	}
+	if CurrentCapabilities().Graphics == GraphicsNone && opts.Protocol == GraphicsNone {
+		cfg, _, _ := image.DecodeConfig(bytes.NewReader(data))
+		return FileHyperlink(path, ImagePlaceholder(opts.Name, cfg.Width, cfg.Height)), nil
+	}
+	return InlineImage(data, opts), nil
+}
+
+func ImagePlaceholder(name string, width, height int) string {
+	var b strings.Builder
+	b.WriteString("[image")
+	if name != "" {
+		b.WriteString(": " + name)
+	}
+	if width > 0 && height > 0 {
+		fmt.Fprintf(&b, " %dx%d", width, height)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+func kittyImage(data []byte, opts ImageOptions) string {
+	payload := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for first := true; first || payload != ""; first = false {
+		chunk := payload
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		b.WriteString("\x1b_G")
+		if first {
+			b.WriteString("a=T,f=100,q=2")
+			if opts.Width > 0 {
+				fmt.Fprintf(&b, ",c=%d", opts.Width)
+			}
+			if opts.Height > 0 {
+				fmt.Fprintf(&b, ",r=%d", opts.Height)
+			}
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "m=%d;%s\x1b\\", more, chunk)
+	}
+	return b.String()
+}
+
+func iterm2Image(data []byte, opts ImageOptions) string {
+	var b strings.Builder
+	b.WriteString("\x1b]1337;File=inline=1")
+	fmt.Fprintf(&b, ";size=%d", len(data))
+	if opts.Name != "" {
+		b.WriteString(";name=" + base64.StdEncoding.EncodeToString([]byte(opts.Name)))
+	}
+	if opts.Width > 0 {
+		fmt.Fprintf(&b, ";width=%d", opts.Width)
+	}
+	if opts.Height > 0 {
+		fmt.Fprintf(&b, ";height=%d", opts.Height)
+	}
+	b.WriteString(":" + base64.StdEncoding.EncodeToString(data) + "\a")
+	return b.String()
+}
+
+func reencodePNG(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func cellSize() (int, int) {
+	if w, h := terminalCellSize(); w > 0 && h > 0 {
+		return w, h
+	}
+	return defaultCellWidth, defaultCellHeight
+}
+
+// fitImage scales img (nearest neighbour) into the pixel box covered by the
+// requested cells, keeping its aspect ratio. Sixel has no notion of cells, so
+// this is the only way to honour ImageOptions there.
+func fitImage(img image.Image, opts ImageOptions) image.Image {
+	b := img.Bounds()
+	cw, ch := cellSize()
+	maxW, maxH := opts.Width*cw, opts.Height*ch
+	scale := 1.0
+	if maxW > 0 && b.Dx() > maxW {
+		scale = float64(maxW) / float64(b.Dx())
+	}
+	if maxH > 0 && float64(b.Dy())*scale > float64(maxH) {
+		scale = float64(maxH) / float64(b.Dy())
+	}
+	if scale >= 1 {
+		return img
+	}
+	w, h := int(float64(b.Dx())*scale), int(float64(b.Dy())*scale)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	return dst
+}
+
+// encodeSixel dithers img to a 256 colour palette and emits it as a sixel
+// sequence. Mostly transparent pixels are left unpainted.
+func encodeSixel(img image.Image) string {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	pal := image.NewPaletted(image.Rect(0, 0, w, h), palette.Plan9)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), img, bounds.Min)
+	opaque := func(x, y int) bool {
+		_, _, _, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+		return a >= 0x8000
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1bP0;1q\"1;1;%d;%d", w, h)
+	var defined [256]bool
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			defined[pal.ColorIndexAt(x, y)] = true
+		}
+	}
+	for i, c := range pal.Palette {
+		if !defined[i] {
+			continue
+		}
+		r, g, bl, _ := color.RGBAModel.Convert(c).RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	row := make([]byte, w)
+	for y := 0; y < h; y += 6 {
+		var used [256]bool
+		for x := 0; x < w; x++ {
+			for k := 0; k < 6 && y+k < h; k++ {
+				if opaque(x, y+k) {
+					used[pal.ColorIndexAt(x, y+k)] = true
+				}
+			}
+		}
+		for idx := range used {
+			if !used[idx] {
+				continue
+			}
+			for x := 0; x < w; x++ {
+				bits := byte(0)
+				for k := 0; k < 6 && y+k < h; k++ {
+					if int(pal.ColorIndexAt(x, y+k)) == idx && opaque(x, y+k) {
+						bits |= 1 << k
+					}
+				}
+				row[x] = 63 + bits
+			}
+			fmt.Fprintf(&b, "#%d", idx)
+			writeSixelRow(&b, row)
+			b.WriteByte('$')
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+func writeSixelRow(b *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(b, "!%d%c", n, row[i])
+		} else {
+			b.Write(row[i:j])
+		}
+		i = j
+	}
+}
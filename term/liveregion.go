@@ -0,0 +1,392 @@
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	bspinner "github.com/charmbracelet/bubbles/spinner"
+	"golang.org/x/term"
+)
+
+const liveRegionTick = 50 * time.Millisecond
+
+// LiveRegion keeps several named live blocks at the bottom of the terminal.
+// Blocks render in creation order and update independently; output written
+// through Println or Write scrolls above all of them.
+type LiveRegion struct {
+	w    io.Writer
+	size func() (int, int)
+
+	mu      sync.Mutex
+	blocks  []*LiveBlock
+	running bool
+	partial []byte
+	// width and height are set by Resize, and override the size of w.
+	width, height int
+	// drawn holds the visible width of every row currently on screen, so the
+	// region can be erased even after the terminal rewrapped it on resize.
+	drawn     []int
+	lastFrame string
+	lastWidth int
+	stop      chan struct{}
+	done      chan struct{}
+	unwatch   func()
+}
+
+type LiveBlock struct {
+	region *LiveRegion
+	name   string
+
+	accent  string
+	spinner bspinner.Spinner
+	started time.Time
+	status  string
+	lines   []string
+}
+
+func NewLiveRegion(w io.Writer) *LiveRegion {
+	return &LiveRegion{w: w, size: writerSize(w)}
+}
+
+func writerSize(w io.Writer) func() (int, int) {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return func() (int, int) { return 0, 0 }
+	}
+	fd := int(f.Fd())
+	return func() (int, int) {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			return 0, 0
+		}
+		return width, height
+	}
+}
+
+// Block returns the block called name, appending a new one after the
+// existing blocks if there is none yet.
+func (r *LiveRegion) Block(name string) *LiveBlock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.blocks {
+		if b.name == name {
+			return b
+		}
+	}
+	b := &LiveBlock{
+		region:  r,
+		name:    name,
+		accent:  CurrentPalette().Accent.Fg(),
+		spinner: spinnerFrames(),
+		started: time.Now(),
+	}
+	r.blocks = append(r.blocks, b)
+	r.renderLocked(false)
+	return b
+}
+
+func (r *LiveRegion) Blocks() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.blocks))
+	for i, b := range r.blocks {
+		names[i] = b.name
+	}
+	return names
+}
+
+func (r *LiveRegion) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(name)
+	r.renderLocked(false)
+}
+
+func (r *LiveRegion) removeLocked(name string) {
+	for i, b := range r.blocks {
+		if b.name == name {
+			r.blocks = append(r.blocks[:i], r.blocks[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *LiveRegion) Start() {
+	if r == nil || r.w == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	r.running = true
+	r.renderLocked(true)
+	go r.tick()
+	// Redraw as soon as the terminal is resized, not on the next tick:
+	// it rewraps the rows drawn meanwhile, and further writes mangle them.
+	if _, ok := r.w.(interface{ Fd() uintptr }); ok {
+		r.unwatch = watchResize(func() {
+			r.mu.Lock()
+			r.renderLocked(false)
+			r.mu.Unlock()
+		})
+	}
+}
+
+// Resize sets the size of the terminal for writers that cannot be queried for
+// it (like the streams of remote clients), and redraws the blocks at once.
+func (r *LiveRegion) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.width, r.height = width, height
+	r.renderLocked(false)
+}
+
+func (r *LiveRegion) sizeLocked() (int, int) {
+	if r.width > 0 {
+		return r.width, r.height
+	}
+	return r.size()
+}
+
+func (r *LiveRegion) tick() {
+	defer close(r.done)
+	t := time.NewTicker(liveRegionTick)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-t.C:
+			r.mu.Lock()
+			r.renderLocked(false)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Stop erases every block and flushes output still waiting for a newline.
+// Blocks are kept, so Start shows them again.
+func (r *LiveRegion) Stop() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	close(r.stop)
+	r.running = false
+	done, unwatch := r.done, r.unwatch
+	r.unwatch = nil
+	r.mu.Unlock()
+	<-done
+	if unwatch != nil {
+		unwatch()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	WriteSynced(r.w, func() {
+		r.eraseLocked()
+		if len(r.partial) > 0 {
+			r.w.Write(append(r.partial, '\n'))
+			r.partial = nil
+		}
+	})
+}
+
+// Write prints p above the blocks. Incomplete lines are held back until their
+// newline arrives so they are not overdrawn by the next frame.
+func (r *LiveRegion) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(p), r.writeLocked(p)
+}
+
+// writeLocked prints the complete lines of p above the blocks in one frame,
+// or only redraws them if there is none.
+func (r *LiveRegion) writeLocked(p []byte) error {
+	r.partial = append(r.partial, p...)
+	idx := bytes.LastIndexByte(r.partial, '\n')
+	if idx < 0 {
+		r.renderLocked(false)
+		return nil
+	}
+	out := r.partial[:idx+1]
+	if !r.running {
+		_, err := r.w.Write(out)
+		r.partial = append([]byte(nil), r.partial[idx+1:]...)
+		return err
+	}
+	var err error
+	WriteSynced(r.w, func() {
+		r.eraseLocked()
+		_, err = r.w.Write(out)
+		r.partial = append([]byte(nil), r.partial[idx+1:]...)
+		r.drawLocked(true)
+	})
+	return err
+}
+
+func (r *LiveRegion) Println(a ...any) {
+	fmt.Fprintln(r, a...)
+}
+
+func (r *LiveRegion) Printf(format string, a ...any) {
+	fmt.Fprintf(r, format, a...)
+}
+
+func (r *LiveRegion) renderLocked(force bool) {
+	if !r.running {
+		return
+	}
+	WriteSynced(r.w, func() {
+		r.drawLocked(force)
+	})
+}
+
+// drawLocked redraws the blocks unless neither their content nor the
+// terminal width changed since the last frame.
+func (r *LiveRegion) drawLocked(force bool) {
+	width, height := r.sizeLocked()
+	lines := r.composeLocked(width, height)
+	frame := strings.Join(lines, "\n")
+	if !force && frame == r.lastFrame && width == r.lastWidth {
+		return
+	}
+	r.eraseLocked()
+	fmt.Fprint(r.w, frame)
+	r.drawn = r.drawn[:0]
+	for _, line := range lines {
+		r.drawn = append(r.drawn, VisibleWidth(line))
+	}
+	r.lastFrame = frame
+	r.lastWidth = width
+}
+
+func (r *LiveRegion) eraseLocked() {
+	if len(r.drawn) == 0 {
+		return
+	}
+	width, _ := r.sizeLocked()
+	rows := 0
+	for _, w := range r.drawn {
+		// Rows drawn at a wider size are rewrapped by the terminal when it
+		// shrinks, so count them at the current width.
+		if width > 0 && w > width {
+			rows += (w + width - 1) / width
+		} else {
+			rows++
+		}
+	}
+	EraseLines(r.w, rows)
+	r.drawn = r.drawn[:0]
+	r.lastFrame = ""
+}
+
+// composeLocked lays the blocks out as terminal rows: every line is cut to the
+// width so it never wraps, and when the blocks are taller than the screen the
+// oldest rows are folded into a summary line, since rows scrolled off the top
+// can no longer be erased.
+func (r *LiveRegion) composeLocked(width, height int) []string {
+	var lines []string
+	now := time.Now()
+	for _, b := range r.blocks {
+		lines = append(lines, b.render(now)...)
+	}
+	if height > 1 && len(lines) > height-1 {
+		hidden := len(lines) - (height - 2)
+		summary := CurrentPalette().Muted.Wrap(fmt.Sprintf("%s %d more lines", Glyph("…", "..."), hidden))
+		lines = append([]string{summary}, lines[hidden:]...)
+	}
+	if width > 1 {
+		for i, line := range lines {
+			// Leave the last column free: writing into it arms the pending
+			// wrap on some terminals and breaks the row count.
+			lines[i] = TruncateANSI(line, width-1)
+		}
+	}
+	return lines
+}
+
+func (b *LiveBlock) Name() string {
+	return b.name
+}
+
+// Update replaces the block's lines. A SpinnerSentinel in a line is replaced
+// by the block's spinner frame.
+func (b *LiveBlock) Update(lines []string) {
+	r := b.region
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.lines = make([]string, len(lines))
+	copy(b.lines, lines)
+	r.renderLocked(false)
+}
+
+// SetStatus shows status next to the spinner on the block's first row; an
+// empty status removes that row.
+func (b *LiveBlock) SetStatus(status string) {
+	r := b.region
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.status = status
+	r.renderLocked(false)
+}
+
+func (b *LiveBlock) SetAccent(accent string) {
+	r := b.region
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.accent = accent
+}
+
+func (b *LiveBlock) SetSpinner(s bspinner.Spinner) {
+	if len(s.Frames) == 0 || s.FPS <= 0 {
+		return
+	}
+	r := b.region
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.spinner = s
+}
+
+// Finish removes the block and prints lines above the remaining blocks, so a
+// finished job leaves its result in the scrollback. Both happen in one frame:
+// other writers never see the block gone without its lines.
+func (b *LiveBlock) Finish(lines ...string) {
+	r := b.region
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(b.name)
+	var out bytes.Buffer
+	for _, line := range lines {
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	r.writeLocked(out.Bytes())
+}
+
+func (b *LiveBlock) render(now time.Time) []string {
+	frames := b.spinner.Frames
+	frame := frames[int(now.Sub(b.started)/b.spinner.FPS)%len(frames)]
+	marker := b.accent + frame + "\x1b[0m"
+
+	lines := make([]string, 0, len(b.lines)+1)
+	if b.status != "" {
+		lines = append(lines, marker+" "+b.status)
+	}
+	for _, line := range b.lines {
+		lines = append(lines, strings.Replace(line, SpinnerSentinel, marker, 1))
+	}
+	return lines
+}
@@ -0,0 +1,131 @@
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// lockedBuffer is written to by the region, and read by the test meanwhile.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *lockedBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+func newTestRegion(out *lockedBuffer) *LiveRegion {
+	r := NewLiveRegion(out)
+	r.size = func() (int, int) { return 40, 10 }
+	return r
+}
+
+func TestLiveBlockFinishIsOneFrame(t *testing.T) {
+	defer SetCapabilities(CurrentCapabilities())
+	SetCapabilities(Capabilities{SyncOutput: true})
+
+	var out lockedBuffer
+	r := newTestRegion(&out)
+	r.Block("keep").Update([]string{"kept"})
+	done := r.Block("done")
+	done.Update([]string{"running"})
+	r.Start()
+	defer r.Stop()
+
+	out.Reset()
+	done.Finish("result 1", "result 2")
+
+	frame := out.String()
+	if n := strings.Count(frame, SyncBegin); n != 1 {
+		t.Fatalf("Finish() wrote %d frames, want 1: %q", n, frame)
+	}
+	if !strings.Contains(frame, "result 1\nresult 2\n") || !strings.Contains(frame, "kept") {
+		t.Fatalf("Finish() frame = %q, want the results and the remaining block", frame)
+	}
+	if strings.Contains(frame, "running") {
+		t.Fatalf("Finish() frame = %q still draws the finished block", frame)
+	}
+}
+
+func TestLiveRegionResize(t *testing.T) {
+	defer SetCapabilities(CurrentCapabilities())
+	SetCapabilities(Capabilities{})
+
+	var out lockedBuffer
+	r := newTestRegion(&out)
+	r.Block("a").Update([]string{strings.Repeat("x", 30)})
+	r.Start()
+	defer r.Stop()
+
+	// The row was drawn 30 columns wide: at 10 columns it takes 3 rows to erase.
+	out.Reset()
+	r.Resize(10, 10)
+
+	frame := out.String()
+	if n := strings.Count(frame, CursorUp+EraseLine); n != 2 {
+		t.Fatalf("Resize() erased %d rows above the cursor, want 2: %q", n, frame)
+	}
+	if !strings.HasSuffix(frame, strings.Repeat("x", 9)) {
+		t.Fatalf("Resize() frame = %q, want the row cut to the new width", frame)
+	}
+}
+
+func TestLiveRegionConcurrent(t *testing.T) {
+	var out lockedBuffer
+	r := newTestRegion(&out)
+	r.Start()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := r.Block(fmt.Sprintf("job %d", i))
+			for j := 0; j < 20; j++ {
+				b.Update([]string{fmt.Sprintf("job %d step %d", i, j)})
+				b.SetStatus(fmt.Sprintf("%d%%", j*5))
+				r.Printf("job %d log %d\n", i, j)
+			}
+			b.Finish(fmt.Sprintf("job %d done", i))
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for width := 20; width < 60; width++ {
+			r.Resize(width, 10)
+		}
+	}()
+
+	wg.Wait()
+	r.Stop()
+
+	if blocks := r.Blocks(); len(blocks) != 0 {
+		t.Fatalf("Blocks() = %q after every block finished", blocks)
+	}
+	output := out.String()
+	for i := 0; i < 8; i++ {
+		if n := strings.Count(output, fmt.Sprintf("job %d done\n", i)); n != 1 {
+			t.Errorf("result of job %d printed %d times", i, n)
+		}
+	}
+}
@@ -12,7 +12,14 @@ import (
 
 const SpinnerSentinel = "\x00"
 
-var defaultFrames = bspinner.Dot
+// spinnerFrames falls back to the ASCII line spinner on terminals without
+// unicode glyphs.
+func spinnerFrames() bspinner.Spinner {
+	if CurrentCapabilities().Unicode {
+		return bspinner.Dot
+	}
+	return bspinner.Line
+}
 
 type LiveView struct {
 	w      io.Writer
@@ -23,6 +30,7 @@ type LiveView struct {
 	running  bool
 	hidden   bool
 	frame    string
+	spinner  bspinner.Spinner
 	rendered int
 	stop     chan struct{}
 	done     chan struct{}
@@ -57,15 +65,16 @@ func (v *LiveView) Start() {
 	v.stop = make(chan struct{})
 	v.done = make(chan struct{})
 	v.running = true
-	v.frame = defaultFrames.Frames[0]
+	v.spinner = spinnerFrames()
+	v.frame = v.spinner.Frames[0]
 	v.renderLocked(v.frame)
 	go v.tick()
 }
 
 func (v *LiveView) tick() {
 	defer close(v.done)
-	frames := defaultFrames.Frames
-	t := time.NewTicker(defaultFrames.FPS)
+	frames := v.spinner.Frames
+	t := time.NewTicker(v.spinner.FPS)
 	defer t.Stop()
 	idx := 0
 	for {
@@ -184,5 +193,5 @@ func (v *LiveView) currentFrame() string {
 	if v.frame != "" {
 		return v.frame
 	}
-	return defaultFrames.Frames[0]
+	return spinnerFrames().Frames[0]
 }
@@ -1,9 +1,13 @@
 package term
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/muesli/termenv"
@@ -11,12 +15,21 @@ import (
 )
 
 var (
+	mdLocalImages atomic.Bool
 	mdRenderer    *glamour.TermRenderer
 	mdRendererErr error
 	mdRendererW   int
+	mdRendererKey string
 	mdRendererMu  sync.Mutex
 )
 
+// SetMarkdownLocalImages allows RenderMarkdown to show the images of local
+// files (relative or absolute paths, and file URLs) inline. It is disabled by
+// default, since rendered markdown often comes from untrusted sources.
+func SetMarkdownLocalImages(enabled bool) {
+	mdLocalImages.Store(enabled)
+}
+
 func RenderMarkdown(content string, enabled bool) string {
 	content = strings.TrimSpace(content)
 	if content == "" {
@@ -29,11 +42,12 @@ func RenderMarkdown(content string, enabled bool) string {
 	if err != nil {
 		return content
 	}
-	rendered, err := r.Render(content)
+	source, media := extractMarkdownMedia(content, CurrentCapabilities(), mdLocalImages.Load())
+	rendered, err := r.Render(source)
 	if err != nil {
 		return content
 	}
-	rendered = strings.TrimSpace(TrimRenderedMarkdownLineEnds(rendered))
+	rendered = strings.TrimSpace(TrimRenderedMarkdownLineEnds(applyMarkdownMedia(rendered, media)))
 	if rendered == "" {
 		return content
 	}
@@ -42,14 +56,20 @@ func RenderMarkdown(content string, enabled bool) string {
 
 func getMarkdownRenderer() (*glamour.TermRenderer, error) {
 	w := TerminalWidth()
+	profile := ColorProfile()
+	style := CurrentPalette().Markdown
+	if style == "" || profile == termenv.Ascii {
+		style = "notty"
+	}
+	key := fmt.Sprintf("%s/%d", style, profile)
 	mdRendererMu.Lock()
 	defer mdRendererMu.Unlock()
-	if mdRenderer != nil && w == mdRendererW {
+	if mdRenderer != nil && w == mdRendererW && key == mdRendererKey {
 		return mdRenderer, mdRendererErr
 	}
 	opts := []glamour.TermRendererOption{
-		glamour.WithAutoStyle(),
-		glamour.WithColorProfile(termenv.ColorProfile()),
+		glamour.WithStandardStyle(style),
+		glamour.WithColorProfile(profile),
 		glamour.WithEmoji(),
 	}
 	if w > 0 {
@@ -57,6 +77,7 @@ func getMarkdownRenderer() (*glamour.TermRenderer, error) {
 	}
 	mdRenderer, mdRendererErr = glamour.NewTermRenderer(opts...)
 	mdRendererW = w
+	mdRendererKey = key
 	return mdRenderer, mdRendererErr
 }
 
@@ -87,3 +108,157 @@ func TrimRenderedMarkdownLineEnds(s string) string {
 	}
 	return b.String()
 }
+
+// Links and images the terminal can show natively are swapped for markers
+// before glamour renders the document and turned into OSC 8 hyperlinks and
+// inline images afterwards. The markers are escape sequences whose media
+// index is spelled with intermediate bytes: both wrappers of glamour measure
+// them as zero width, so they wrap the text exactly as it will be displayed,
+// and they hold no character markdown would parse and style apart.
+const (
+	mdMarkLink   = 'z' // Final byte of the marker opening a link.
+	mdMarkImage  = 'y' // Final byte of the marker standing for an image.
+	mdMarkClose  = "\x1bz"
+	mdMarkDigits = "#$%,"
+)
+
+var (
+	mdMediaRe     = regexp.MustCompile(`(!?)\[([^\]\n]*)\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+	mdLinkMarkRe  = regexp.MustCompile("\x1b([#$%,]*)z")
+	mdImageMarkRe = regexp.MustCompile("\x1b([#$%,]+)y")
+	mdCodeFenceRe = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+type mdMedia struct {
+	target string
+	image  string
+}
+
+func extractMarkdownMedia(content string, caps Capabilities, localImages bool) (string, []mdMedia) {
+	if !localImages {
+		caps.Graphics = GraphicsNone
+	}
+	if !caps.Hyperlinks && caps.Graphics == GraphicsNone {
+		return content, nil
+	}
+	var media []mdMedia
+	replace := func(m string) string {
+		parts := mdMediaRe.FindStringSubmatch(m)
+		target := parts[3]
+		if parts[1] == "!" {
+			path, ok := localImagePath(target)
+			if !ok || caps.Graphics == GraphicsNone {
+				return m
+			}
+			img, err := InlineImageFile(path, ImageOptions{Name: parts[2]})
+			if err != nil {
+				return m
+			}
+			media = append(media, mdMedia{image: img})
+			return mdMark(len(media)-1, mdMarkImage)
+		}
+		if !caps.Hyperlinks || strings.HasPrefix(target, "#") {
+			return m
+		}
+		media = append(media, mdMedia{target: target})
+		return mdMark(len(media)-1, mdMarkLink) + parts[2] + mdMarkClose
+	}
+
+	lines := strings.Split(content, "\n")
+	inFence := false
+	for i, line := range lines {
+		if mdCodeFenceRe.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		// Even segments lie outside inline code spans.
+		segments := strings.Split(line, "`")
+		for j := 0; j < len(segments); j += 2 {
+			segments[j] = mdMediaRe.ReplaceAllStringFunc(segments[j], replace)
+		}
+		lines[i] = strings.Join(segments, "`")
+	}
+	return strings.Join(lines, "\n"), media
+}
+
+func mdMark(index int, final byte) string {
+	digits := []byte{final}
+	for {
+		digits = append([]byte{mdMarkDigits[index%len(mdMarkDigits)]}, digits...)
+		if index /= len(mdMarkDigits); index == 0 {
+			break
+		}
+	}
+	return "\x1b" + string(digits)
+}
+
+func parseMdMark(digits string) int {
+	index := 0
+	for i := 0; i < len(digits); i++ {
+		index = index*len(mdMarkDigits) + strings.IndexByte(mdMarkDigits, digits[i])
+	}
+	return index
+}
+
+func localImagePath(target string) (string, bool) {
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" {
+		if u.Scheme != "file" {
+			return "", false
+		}
+		target = u.Path
+	}
+	if info, err := os.Stat(target); err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return target, true
+}
+
+// applyMarkdownMedia resolves the markers left by extractMarkdownMedia. A
+// hyperlink wrapped over several lines is closed at each line end and reopened
+// on the next, and images go on their own line below the text around them.
+func applyMarkdownMedia(rendered string, media []mdMedia) string {
+	if len(media) == 0 {
+		return rendered
+	}
+	open := ""
+	lines := strings.Split(rendered, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		var images []string
+		line = mdImageMarkRe.ReplaceAllStringFunc(line, func(m string) string {
+			if idx := parseMdMark(mdImageMarkRe.FindStringSubmatch(m)[1]); idx < len(media) {
+				images = append(images, media[idx].image)
+			}
+			return ""
+		})
+		// A link left open on the previous line is reopened first.
+		reopen := open
+		line = reopen + mdLinkMarkRe.ReplaceAllStringFunc(line, func(m string) string {
+			if m == mdMarkClose {
+				open = ""
+				return hyperlinkClose
+			}
+			idx := parseMdMark(mdLinkMarkRe.FindStringSubmatch(m)[1])
+			if idx >= len(media) {
+				return ""
+			}
+			open = hyperlinkOpen + sanitizeHyperlinkTarget(media[idx].target) + "\x1b\\"
+			return open
+		})
+		if open != "" {
+			line += hyperlinkClose
+		}
+		if len(images) == 0 {
+			out = append(out, line)
+			continue
+		}
+		if TrimANSIVisibleRight(line) != "" {
+			out = append(out, line)
+		}
+		out = append(out, images...)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,125 @@
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glamour"
+)
+
+var (
+	testHyperlinkRe = regexp.MustCompile("\x1b]8;;[^\x1b]*\x1b\\\\")
+	testStyleRe     = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// TestMarkdownHyperlinksWrap checks that hyperlinks do not change how glamour
+// wraps the text: once the links are stripped, the output is that of the
+// document with every link replaced by its text.
+func TestMarkdownHyperlinksWrap(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("Some words and [a link with words](https://example.com/a) then more words, ")
+	content.WriteString("**bold [link](https://example.com/b) here** and `[code](https://example.com/c)`.\n\n")
+	for i := 0; i < 12; i++ {
+		fmt.Fprintf(&content, "- item [number %d of the list](https://example.com/%d) with a tail\n", i, i)
+	}
+	plain := mdMediaRe.ReplaceAllStringFunc(content.String(), func(m string) string {
+		if strings.Contains(m, "example.com/c") {
+			return m
+		}
+		return mdMediaRe.FindStringSubmatch(m)[2]
+	})
+
+	for _, style := range []string{"notty", "dark"} {
+		for width := 12; width <= 60; width += 3 {
+			r, err := glamour.NewTermRenderer(glamour.WithStandardStyle(style), glamour.WithWordWrap(width))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			source, media := extractMarkdownMedia(content.String(), Capabilities{Hyperlinks: true}, false)
+			if len(media) != 14 {
+				t.Fatalf("extractMarkdownMedia() = %d links, want 14", len(media))
+			}
+			on, err := r.Render(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			on = TrimRenderedMarkdownLineEnds(applyMarkdownMedia(on, media))
+
+			off, err := r.Render(plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			off = TrimRenderedMarkdownLineEnds(off)
+
+			if got, want := testStyleRe.ReplaceAllString(testHyperlinkRe.ReplaceAllString(on, ""), ""), testStyleRe.ReplaceAllString(off, ""); got != want {
+				t.Fatalf("%s style at width %d:\n%s\nwant\n%s", style, width, got, want)
+			}
+			if !strings.Contains(on, hyperlinkOpen+"https://example.com/11\x1b\\") {
+				t.Fatalf("%s style at width %d: last link missing from %q", style, width, on)
+			}
+		}
+	}
+}
+
+func TestApplyMarkdownMediaReopensWrappedLinks(t *testing.T) {
+	media := []mdMedia{{target: "https://example.com"}}
+	rendered := "see " + mdMark(0, mdMarkLink) + "a long\nlink" + mdMarkClose + " here"
+
+	open := hyperlinkOpen + "https://example.com\x1b\\"
+	want := "see " + open + "a long" + hyperlinkClose + "\n" + open + "link" + hyperlinkClose + " here"
+	if got := applyMarkdownMedia(rendered, media); got != want {
+		t.Fatalf("applyMarkdownMedia() = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownLocalImages(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pixel.png")
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	caps := Capabilities{Graphics: GraphicsKitty}
+	tests := []struct {
+		name    string
+		content string
+		enabled bool
+		images  int
+	}{
+		{"disabled", "![pixel](" + path + ")", false, 0},
+		{"enabled", "![pixel](" + path + ")", true, 1},
+		{"file URL", "![pixel](file://" + path + ")", true, 1},
+		{"directory", "![dir](" + dir + ")", true, 0},
+		{"remote", "![remote](https://example.com/pixel.png)", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, media := extractMarkdownMedia(tt.content, caps, tt.enabled)
+			if len(media) != tt.images {
+				t.Fatalf("extractMarkdownMedia() = %d images, want %d", len(media), tt.images)
+			}
+			if tt.images == 0 && source != tt.content {
+				t.Fatalf("extractMarkdownMedia() = %q, want the image left as it is", source)
+			}
+		})
+	}
+}
+
+func TestInlineImageFileRegular(t *testing.T) {
+	if _, err := InlineImageFile(t.TempDir(), ImageOptions{}); err == nil {
+		t.Fatal("InlineImageFile() read a directory")
+	}
+}
@@ -0,0 +1,311 @@
+package term
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/muesli/termenv"
+	"gopkg.in/yaml.v3"
+)
+
+// ThemeColor is a hex colour ("#1abc9c"), an ANSI/256 palette index ("6",
+// "244") or empty for the terminal default. Escape sequences are always
+// produced through ColorProfile so every consumer downgrades the same way.
+type ThemeColor string
+
+func (c ThemeColor) Fg() string {
+	return c.sequence(false)
+}
+
+func (c ThemeColor) Bg() string {
+	return c.sequence(true)
+}
+
+func (c ThemeColor) Wrap(s string) string {
+	seq := c.Fg()
+	if seq == "" {
+		return s
+	}
+	return seq + s + "\x1b[39m"
+}
+
+func (c ThemeColor) sequence(bg bool) string {
+	return c.Sequence(ColorProfile(), bg)
+}
+
+// Sequence returns the foreground or background escape sequence of the colour
+// in a given profile, for output to a terminal other than the process one.
+func (c ThemeColor) Sequence(p termenv.Profile, bg bool) string {
+	if c == "" {
+		return ""
+	}
+	color := p.Color(string(c))
+	if color == nil {
+		return ""
+	}
+	seq := color.Sequence(bg)
+	if seq == "" {
+		return ""
+	}
+	return "\x1b[" + seq + "m"
+}
+
+type Palette struct {
+	Text        ThemeColor `yaml:"text" toml:"text"`
+	Muted       ThemeColor `yaml:"muted" toml:"muted"`
+	Border      ThemeColor `yaml:"border" toml:"border"`
+	Accent      ThemeColor `yaml:"accent" toml:"accent"`
+	AccentText  ThemeColor `yaml:"accent_text" toml:"accent_text"`
+	Info        ThemeColor `yaml:"info" toml:"info"`
+	Success     ThemeColor `yaml:"success" toml:"success"`
+	Warning     ThemeColor `yaml:"warning" toml:"warning"`
+	Error       ThemeColor `yaml:"error" toml:"error"`
+	Special     ThemeColor `yaml:"special" toml:"special"`
+	Prompt      ThemeColor `yaml:"prompt" toml:"prompt"`
+	Session     ThemeColor `yaml:"session" toml:"session"`
+	Selection   ThemeColor `yaml:"selection" toml:"selection"`
+	SelectionBg ThemeColor `yaml:"selection_bg" toml:"selection_bg"`
+	Highlight   ThemeColor `yaml:"highlight" toml:"highlight"`
+	HighlightBg ThemeColor `yaml:"highlight_bg" toml:"highlight_bg"`
+	Group       ThemeColor `yaml:"group" toml:"group"`
+	Name        ThemeColor `yaml:"name" toml:"name"`
+	Command     ThemeColor `yaml:"command" toml:"command"`
+	Flag        ThemeColor `yaml:"flag" toml:"flag"`
+
+	DiffInsert     ThemeColor `yaml:"diff_insert" toml:"diff_insert"`
+	DiffInsertWord ThemeColor `yaml:"diff_insert_word" toml:"diff_insert_word"`
+	DiffDelete     ThemeColor `yaml:"diff_delete" toml:"diff_delete"`
+	DiffDeleteWord ThemeColor `yaml:"diff_delete_word" toml:"diff_delete_word"`
+
+	// Syntax names a chroma style, Markdown a glamour standard style.
+	Syntax   string `yaml:"syntax" toml:"syntax"`
+	Markdown string `yaml:"markdown" toml:"markdown"`
+}
+
+type Theme struct {
+	Name  string  `yaml:"name" toml:"name"`
+	Dark  Palette `yaml:"dark" toml:"dark"`
+	Light Palette `yaml:"light" toml:"light"`
+}
+
+func (t *Theme) Palette() Palette {
+	if t == nil {
+		return DefaultTheme().Palette()
+	}
+	if HasDarkBackground() {
+		return t.Dark
+	}
+	return t.Light
+}
+
+func DefaultTheme() *Theme {
+	return &Theme{
+		Name: "default",
+		Dark: Palette{
+			Text:        "246",
+			Muted:       "8",
+			Border:      "240",
+			Accent:      "6",
+			AccentText:  "0",
+			Info:        "#3398DA",
+			Success:     "2",
+			Warning:     "3",
+			Error:       "196",
+			Special:     "5",
+			Prompt:      "205",
+			Session:     "36",
+			Selection:   "255",
+			SelectionBg: "24",
+			Highlight:   "229",
+			HighlightBg: "107",
+			Group:       "#8BE9FD",
+			Name:        "#FF79C6",
+			Command:     "2",
+			Flag:        "244",
+
+			DiffInsert:     "#1f3a26",
+			DiffInsertWord: "#2f6b3a",
+			DiffDelete:     "#3c1f24",
+			DiffDeleteWord: "#8b2f36",
+
+			Syntax:   "monokai",
+			Markdown: "dark",
+		},
+		Light: Palette{
+			Text:        "240",
+			Muted:       "8",
+			Border:      "250",
+			Accent:      "6",
+			AccentText:  "15",
+			Info:        "#1F6FB2",
+			Success:     "2",
+			Warning:     "130",
+			Error:       "160",
+			Special:     "5",
+			Prompt:      "162",
+			Session:     "30",
+			Selection:   "255",
+			SelectionBg: "24",
+			Highlight:   "235",
+			HighlightBg: "150",
+			Group:       "#0087AF",
+			Name:        "#AF005F",
+			Command:     "2",
+			Flag:        "242",
+
+			DiffInsert:     "#dafbe1",
+			DiffInsertWord: "#aceebb",
+			DiffDelete:     "#ffebe9",
+			DiffDeleteWord: "#ffc1c0",
+
+			Syntax:   "github",
+			Markdown: "light",
+		},
+	}
+}
+
+// ParseTheme decodes a YAML or TOML theme on top of DefaultTheme, so a file
+// only needs to list the roles it changes.
+func ParseTheme(data []byte, format string) (*Theme, error) {
+	theme := DefaultTheme()
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(theme); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+	case "toml":
+		md, err := toml.Decode(string(data), theme)
+		if err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("theme: unknown key %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("theme: unsupported format %q", format)
+	}
+	return theme, nil
+}
+
+func LoadTheme(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	theme, err := ParseTheme(data, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if theme.Name == "default" {
+		theme.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return theme, nil
+}
+
+var (
+	themeMu        sync.RWMutex
+	currentTheme   = DefaultTheme()
+	themeListeners []func()
+
+	profileMu  sync.RWMutex
+	profile    termenv.Profile
+	profileSet bool
+
+	darkBgMu    sync.Mutex
+	darkBgValue bool
+	darkBgKnown bool
+)
+
+func CurrentTheme() *Theme {
+	themeMu.RLock()
+	defer themeMu.RUnlock()
+	return currentTheme
+}
+
+func CurrentPalette() Palette {
+	return CurrentTheme().Palette()
+}
+
+func SetTheme(t *Theme) {
+	if t == nil {
+		t = DefaultTheme()
+	}
+	themeMu.Lock()
+	currentTheme = t
+	themeMu.Unlock()
+	notifyThemeChange()
+}
+
+// OnThemeChange registers fn to run whenever the theme, colour profile or
+// background detection changes, so packages holding pre-built styles can
+// rebuild them.
+func OnThemeChange(fn func()) {
+	if fn == nil {
+		return
+	}
+	themeMu.Lock()
+	themeListeners = append(themeListeners, fn)
+	themeMu.Unlock()
+}
+
+func notifyThemeChange() {
+	themeMu.RLock()
+	listeners := make([]func(), len(themeListeners))
+	copy(listeners, themeListeners)
+	themeMu.RUnlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// ColorProfile returns the profile every package renders colours with. It
+// comes from the terminal capabilities (stdout, NO_COLOR, CLICOLOR_FORCE and
+// terminfo) unless pinned with SetColorProfile.
+func ColorProfile() termenv.Profile {
+	profileMu.RLock()
+	p, set := profile, profileSet
+	profileMu.RUnlock()
+	if set {
+		return p
+	}
+	return CurrentCapabilities().Profile
+}
+
+func SetColorProfile(p termenv.Profile) {
+	profileMu.Lock()
+	profile = p
+	profileSet = true
+	profileMu.Unlock()
+	notifyThemeChange()
+}
+
+func ColorEnabled() bool {
+	return ColorProfile() != termenv.Ascii
+}
+
+func HasDarkBackground() bool {
+	darkBgMu.Lock()
+	defer darkBgMu.Unlock()
+	if !darkBgKnown {
+		darkBgValue = termenv.HasDarkBackground()
+		darkBgKnown = true
+	}
+	return darkBgValue
+}
+
+func SetDarkBackground(dark bool) {
+	darkBgMu.Lock()
+	darkBgValue = dark
+	darkBgKnown = true
+	darkBgMu.Unlock()
+	notifyThemeChange()
+}